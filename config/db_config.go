@@ -4,36 +4,137 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
+	"time"
 
-	"github.com/joho/godotenv"
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
-func GetDatabaseConnection() (*gorm.DB, error) {
-	err := godotenv.Load()
+type DatabaseConfig struct {
+	Driver          string
+	Path            string // arquivo do SQLite; ":memory:" para um banco em memória
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	ConnectRetries  int
+	RetryBackoff    time.Duration
+}
+
+func LoadDatabaseConfig() DatabaseConfig {
+	LoadEnv()
+
+	return DatabaseConfig{
+		Driver:          GetEnv("DB_DRIVER", "mysql"),
+		Path:            GetEnv("DB_PATH", "config/database.db"),
+		MaxOpenConns:    GetEnvInt("DB_MAX_OPEN_CONNS", 25),
+		MaxIdleConns:    GetEnvInt("DB_MAX_IDLE_CONNS", 10),
+		ConnMaxLifetime: GetEnvDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+		ConnMaxIdleTime: GetEnvDuration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+		ConnectRetries:  GetEnvInt("DB_CONNECT_RETRIES", 5),
+		RetryBackoff:    GetEnvDuration("DB_CONNECT_BACKOFF", time.Second),
+	}
+}
+
+// OpenDatabase cria o pool de conexões da aplicação. Deve ser chamada uma vez
+// na inicialização; o *gorm.DB retornado é compartilhado por todo o servidor.
+func OpenDatabase(cfg DatabaseConfig) (*gorm.DB, error) {
+	LoadEnv()
 
+	dialector, err := newDialector(cfg)
 	if err != nil {
-		log.Printf("Erro ao carregar o arquivo .env: %v", err)
-		return nil, fmt.Errorf("erro ao carregar o arquivo .env: %w", err)
+		return nil, err
 	}
 
-	connection := fmt.Sprintf(
-		"%s:%s@tcp(%s:%s)/%s?parseTime=true",
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_NAME"),
-	)
+	var db *gorm.DB
+
+	backoff := cfg.RetryBackoff
+
+	for attempt := 1; ; attempt++ {
+		db, err = connect(dialector, cfg)
+		if err == nil {
+			return db, nil
+		}
 
-	db, err := gorm.Open(mysql.Open(connection), &gorm.Config{})
+		if attempt > cfg.ConnectRetries {
+			break
+		}
 
+		log.Printf("Erro ao conectar ao banco de dados (tentativa %d): %v. Nova tentativa em %s", attempt, err, backoff)
+		time.Sleep(backoff)
+
+		backoff *= 2
+		if backoff > 30*time.Second {
+			backoff = 30 * time.Second
+		}
+	}
+
+	log.Printf("Erro ao conectar ao banco de dados: %v", err)
+	return nil, fmt.Errorf("erro ao conectar ao banco de dados: %w", err)
+}
+
+// newDialector escolhe o driver pela variável DB_DRIVER: "mysql" (padrão) ou
+// "sqlite", que usa um driver em Go puro e não precisa de cgo.
+func newDialector(cfg DatabaseConfig) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case "mysql":
+		return mysql.Open(fmt.Sprintf(
+			"%s:%s@tcp(%s:%s)/%s?parseTime=true",
+			os.Getenv("DB_USER"),
+			os.Getenv("DB_PASSWORD"),
+			os.Getenv("DB_HOST"),
+			os.Getenv("DB_PORT"),
+			os.Getenv("DB_NAME"),
+		)), nil
+	case "sqlite":
+		return sqlite.Open(sqliteDSN(cfg.Path)), nil
+	default:
+		return nil, fmt.Errorf("driver de banco de dados desconhecido: %s", cfg.Driver)
+	}
+}
+
+// sqliteDSN liga as chaves estrangeiras (desligadas por padrão no SQLite) e um
+// busy_timeout para que escritas concorrentes esperem em vez de falhar.
+func sqliteDSN(path string) string {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+
+	return path + separator + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+}
+
+func connect(dialector gorm.Dialector, cfg DatabaseConfig) (*gorm.DB, error) {
+	db, err := gorm.Open(dialector, &gorm.Config{TranslateError: true})
 	if err != nil {
-		log.Printf("Erro ao conectar ao banco de dados: %v", err)
-		return nil, fmt.Errorf("erro ao conectar ao banco de dados: %w", err)
+		return nil, err
 	}
 
-	return db, nil
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	// Um banco em memória só existe dentro da conexão que o abriu: usa uma
+	// única conexão, que nunca é fechada por tempo.
+	if cfg.Driver == "sqlite" && strings.Contains(cfg.Path, ":memory:") {
+		cfg.MaxOpenConns = 1
+		cfg.MaxIdleConns = 1
+		cfg.ConnMaxLifetime = 0
+		cfg.ConnMaxIdleTime = 0
+	}
+
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
+	if err := sqlDB.Ping(); err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+
+	return db, nil
 }
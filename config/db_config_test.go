@@ -0,0 +1,83 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func sqliteConfig(path string) DatabaseConfig {
+	return DatabaseConfig{
+		Driver:          "sqlite",
+		Path:            path,
+		MaxOpenConns:    25,
+		MaxIdleConns:    10,
+		ConnMaxLifetime: 30 * time.Minute,
+		ConnMaxIdleTime: 5 * time.Minute,
+	}
+}
+
+func TestOpenDatabaseInMemory(t *testing.T) {
+	// O pool segue o cfg, não o ambiente.
+	t.Setenv("DB_PATH", filepath.Join(t.TempDir(), "env.db"))
+
+	db, err := OpenDatabase(sqliteConfig(":memory:"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+
+	if max := sqlDB.Stats().MaxOpenConnections; max != 1 {
+		t.Fatalf("MaxOpenConnections = %d, esperado 1", max)
+	}
+
+	// Com uma só conexão, o que foi criado continua visível.
+	if err := db.Exec("CREATE TABLE things (id INTEGER PRIMARY KEY)").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if !db.Migrator().HasTable("things") {
+		t.Fatal("a tabela sumiu do banco em memória")
+	}
+}
+
+func TestOpenDatabaseFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	for _, dsn := range []string{path, path + "?mode=rwc"} {
+		db, err := OpenDatabase(sqliteConfig(dsn))
+		if err != nil {
+			t.Fatalf("%s: %v", dsn, err)
+		}
+
+		sqlDB, err := db.DB()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if max := sqlDB.Stats().MaxOpenConnections; max != 25 {
+			t.Fatalf("%s: MaxOpenConnections = %d, esperado 25", dsn, max)
+		}
+
+		var foreignKeys, busyTimeout int
+		db.Raw("PRAGMA foreign_keys").Scan(&foreignKeys)
+		db.Raw("PRAGMA busy_timeout").Scan(&busyTimeout)
+
+		if foreignKeys != 1 || busyTimeout != 5000 {
+			t.Fatalf("%s: foreign_keys = %d, busy_timeout = %d", dsn, foreignKeys, busyTimeout)
+		}
+
+		sqlDB.Close()
+	}
+}
+
+func TestOpenDatabaseUnknownDriver(t *testing.T) {
+	if _, err := OpenDatabase(DatabaseConfig{Driver: "postgres"}); err == nil {
+		t.Fatal("esperava erro para driver desconhecido")
+	}
+}
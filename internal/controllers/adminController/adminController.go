@@ -0,0 +1,188 @@
+package adminController
+
+import (
+	"errors"
+	"go-web-socket/internal/middleware"
+	messageRepository "go-web-socket/internal/repositories/MessageRepository"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	authService "go-web-socket/internal/services/AuthService"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Sockets é a parte do hub usada pelas operações de administração.
+type Sockets interface {
+	DisconnectUser(userId string) int
+	BroadcastSystem(status, text string)
+}
+
+type AdminController struct {
+	users    userRepository.UserRepository
+	messages messageRepository.MessageRepository
+	auth     *authService.AuthService
+	sockets  Sockets
+}
+
+func New(users userRepository.UserRepository, messages messageRepository.MessageRepository, auth *authService.AuthService, sockets Sockets) *AdminController {
+	return &AdminController{users: users, messages: messages, auth: auth, sockets: sockets}
+}
+
+func (c *AdminController) GetUsers(ctx *gin.Context) {
+	filter := userRepository.UserFilter{
+		Search: ctx.Query("search"),
+		Role:   ctx.Query("role"),
+	}
+
+	if value := ctx.Query("disabled"); value != "" {
+		disabled, err := strconv.ParseBool(value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"message": "O filtro disabled deve ser true ou false",
+			})
+
+			return
+		}
+
+		filter.Disabled = &disabled
+	}
+
+	users, err := c.users.Search(filter)
+
+	if err != nil {
+		log.Printf("Erro ao listar usuários: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Erro ao listar usuários",
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": users,
+	})
+}
+
+func (c *AdminController) DisableUser(ctx *gin.Context) {
+	c.setDisabled(ctx, true)
+}
+
+func (c *AdminController) EnableUser(ctx *gin.Context) {
+	c.setDisabled(ctx, false)
+}
+
+func (c *AdminController) setDisabled(ctx *gin.Context, disabled bool) {
+	userId := ctx.Param("user_id")
+
+	if disabled && userId == middleware.CurrentUser(ctx).UserId {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "Você não pode desativar a própria conta",
+		})
+
+		return
+	}
+
+	err := c.users.SetDisabled(userId, disabled)
+
+	if errors.Is(err, userRepository.ErrUserNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "Usuário não encontrado",
+		})
+
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao alterar usuário %s: %v", userId, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Erro ao alterar usuário",
+		})
+
+		return
+	}
+
+	if disabled {
+		if _, err := c.auth.RevokeAllSessions(userId, ""); err != nil {
+			log.Printf("Erro ao encerrar sessões de %s: %v", userId, err)
+		}
+
+		c.sockets.DisconnectUser(userId)
+	}
+
+	log.Printf("Admin %s alterou disabled=%t do usuário %s", middleware.CurrentUser(ctx).UserId, disabled, userId)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":  "Usuário atualizado",
+		"disabled": disabled,
+	})
+}
+
+func (c *AdminController) DisconnectUser(ctx *gin.Context) {
+	closed := c.sockets.DisconnectUser(ctx.Param("user_id"))
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":     "Conexões encerradas",
+		"connections": closed,
+	})
+}
+
+func (c *AdminController) DeleteMessage(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "Id de mensagem inválido",
+		})
+
+		return
+	}
+
+	err = c.messages.Delete(uint(id))
+
+	if errors.Is(err, messageRepository.ErrMessageNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "Mensagem não encontrada",
+		})
+
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao remover mensagem %d: %v", id, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Erro ao remover mensagem",
+		})
+
+		return
+	}
+
+	c.sockets.BroadcastSystem("message-deleted", strconv.FormatUint(id, 10))
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Mensagem removida",
+	})
+}
+
+type BroadcastRequest struct {
+	Message string `json:"message" binding:"required"`
+}
+
+func (c *AdminController) Broadcast(ctx *gin.Context) {
+	var request BroadcastRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "O campo message é obrigatório",
+		})
+
+		return
+	}
+
+	c.sockets.BroadcastSystem("announcement", request.Message)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Aviso enviado",
+	})
+}
@@ -0,0 +1,141 @@
+package apiKeyController
+
+import (
+	"errors"
+	"go-web-socket/internal/middleware"
+	"go-web-socket/internal/models"
+	apiKeyService "go-web-socket/internal/services/APIKeyService"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SocketDisconnector fecha as conexões abertas com uma chave revogada.
+type SocketDisconnector interface {
+	DisconnectSession(sessionId string) int
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type APIKeyController struct {
+	keys    *apiKeyService.APIKeyService
+	sockets SocketDisconnector
+}
+
+func New(keys *apiKeyService.APIKeyService, sockets SocketDisconnector) *APIKeyController {
+	return &APIKeyController{keys: keys, sockets: sockets}
+}
+
+func present(key models.APIKey) gin.H {
+	return gin.H{
+		"id":           key.KeyId,
+		"name":         key.Name,
+		"scopes":       apiKeyService.ScopeList(key),
+		"expires_at":   key.ExpiresAt,
+		"revoked_at":   key.RevokedAt,
+		"last_used_at": key.LastUsedAt,
+		"last_used_ip": key.LastUsedIP,
+		"created_at":   key.CreatedAt,
+	}
+}
+
+func (c *APIKeyController) CreateAPIKey(ctx *gin.Context) {
+	var request CreateAPIKeyRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "name é obrigatório",
+		})
+
+		return
+	}
+
+	claims := middleware.CurrentUser(ctx)
+
+	secret, key, err := c.keys.Create(claims.UserId, request.Name, request.Scopes, request.ExpiresAt)
+
+	if errors.Is(err, apiKeyService.ErrInvalidScope) || errors.Is(err, apiKeyService.ErrNoScopes) || errors.Is(err, apiKeyService.ErrInvalidExpiry) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message":          err.Error(),
+			"available_scopes": apiKeyService.Scopes,
+		})
+
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao criar chave de API: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao criar a chave de API",
+		})
+
+		return
+	}
+
+	data := present(key)
+	data["key"] = secret // só aparece nesta resposta
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": data,
+	})
+}
+
+func (c *APIKeyController) GetAPIKeys(ctx *gin.Context) {
+	claims := middleware.CurrentUser(ctx)
+
+	keys, err := c.keys.List(claims.UserId)
+
+	if err != nil {
+		log.Printf("Erro ao listar chaves de API: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao listar as chaves de API",
+		})
+
+		return
+	}
+
+	data := make([]gin.H, 0, len(keys))
+	for _, key := range keys {
+		data = append(data, present(key))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": data,
+	})
+}
+
+func (c *APIKeyController) DeleteAPIKey(ctx *gin.Context) {
+	claims := middleware.CurrentUser(ctx)
+	keyId := ctx.Param("id")
+
+	err := c.keys.Revoke(claims.UserId, keyId)
+
+	if errors.Is(err, apiKeyService.ErrAPIKeyNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "Chave de API não encontrada",
+		})
+
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao revogar chave de API: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao revogar a chave de API",
+		})
+
+		return
+	}
+
+	c.sockets.DisconnectSession(apiKeyService.SessionId(keyId))
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Chave de API revogada",
+	})
+}
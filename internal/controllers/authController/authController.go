@@ -0,0 +1,161 @@
+package authController
+
+import (
+	"errors"
+	"go-web-socket/internal/middleware"
+	authService "go-web-socket/internal/services/AuthService"
+	jwtService "go-web-socket/internal/services/JWTService"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SocketDisconnector fecha as conexões WebSocket de uma sessão encerrada.
+type SocketDisconnector interface {
+	DisconnectSession(sessionId string) int
+}
+
+type AuthController struct {
+	auth    *authService.AuthService
+	sockets SocketDisconnector
+}
+
+func New(auth *authService.AuthService, sockets SocketDisconnector) *AuthController {
+	return &AuthController{auth: auth, sockets: sockets}
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+func (c *AuthController) Refresh(ctx *gin.Context) {
+	var request RefreshRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "refresh_token é obrigatório",
+		})
+
+		return
+	}
+
+	tokens, err := c.auth.Refresh(request.RefreshToken, middleware.Meta(ctx))
+
+	if errors.Is(err, authService.ErrInvalidRefreshToken) || errors.Is(err, authService.ErrRefreshTokenReused) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao renovar token: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao renovar o token",
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+func (c *AuthController) Logout(ctx *gin.Context) {
+	claims := middleware.CurrentUser(ctx)
+
+	if err := c.auth.Logout(claims); err != nil {
+		log.Printf("Erro ao fazer logout: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao fazer logout",
+		})
+
+		return
+	}
+
+	c.sockets.DisconnectSession(claims.SessionId)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Logout realizado com sucesso",
+	})
+}
+
+func (c *AuthController) GetSessions(ctx *gin.Context) {
+	claims := middleware.CurrentUser(ctx)
+
+	sessions, err := c.auth.ListSessions(claims.UserId)
+
+	if err != nil {
+		log.Printf("Erro ao listar sessões: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao listar as sessões",
+		})
+
+		return
+	}
+
+	data := make([]gin.H, 0, len(sessions))
+	for _, session := range sessions {
+		data = append(data, gin.H{
+			"id":           session.SessionId,
+			"user_agent":   session.UserAgent,
+			"ip":           session.IP,
+			"created_at":   session.CreatedAt,
+			"last_seen_at": session.LastSeenAt,
+			"current":      session.SessionId == claims.SessionId,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": data,
+	})
+}
+
+func (c *AuthController) DeleteSession(ctx *gin.Context) {
+	claims := middleware.CurrentUser(ctx)
+	sessionId := ctx.Param("id")
+
+	err := c.auth.RevokeSession(claims.UserId, sessionId)
+
+	if errors.Is(err, authService.ErrSessionNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "Sessão não encontrada",
+		})
+
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao encerrar sessão: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao encerrar a sessão",
+		})
+
+		return
+	}
+
+	c.sockets.DisconnectSession(sessionId)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Sessão encerrada",
+	})
+}
+
+func (c *AuthController) JWKS(ctx *gin.Context) {
+	keys, err := jwtService.JWKS()
+
+	if err != nil {
+		log.Printf("Erro ao carregar chaves JWT: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao carregar as chaves",
+		})
+
+		return
+	}
+
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, gin.H{
+		"keys": keys,
+	})
+}
@@ -0,0 +1,226 @@
+package botController
+
+import (
+	"errors"
+	"go-web-socket/internal/middleware"
+	"go-web-socket/internal/models"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	apiKeyService "go-web-socket/internal/services/APIKeyService"
+	"go-web-socket/internal/utils/audit"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// SocketDisconnector fecha as conexões abertas com uma chave revogada.
+type SocketDisconnector interface {
+	DisconnectSession(sessionId string) int
+}
+
+type CreateBotRequest struct {
+	Username string `json:"username" binding:"required,max=255"`
+	Name     string `json:"name" binding:"max=150"`
+}
+
+type CreateKeyRequest struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes"`
+}
+
+// BotController cria contas de bot e administra as chaves delas, já que um
+// bot não faz login para gerenciar as próprias chaves.
+type BotController struct {
+	users   userRepository.UserRepository
+	keys    *apiKeyService.APIKeyService
+	sockets SocketDisconnector
+}
+
+func New(users userRepository.UserRepository, keys *apiKeyService.APIKeyService, sockets SocketDisconnector) *BotController {
+	return &BotController{users: users, keys: keys, sockets: sockets}
+}
+
+func presentKey(secret string, key models.APIKey) gin.H {
+	return gin.H{
+		"id":         key.KeyId,
+		"name":       key.Name,
+		"key":        secret,
+		"scopes":     apiKeyService.ScopeList(key),
+		"created_at": key.CreatedAt,
+	}
+}
+
+func (c *BotController) CreateBot(ctx *gin.Context) {
+	var request CreateBotRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "username é obrigatório",
+		})
+
+		return
+	}
+
+	if request.Name == "" {
+		request.Name = request.Username
+	}
+
+	// Sem senha e sem e-mail: o bot só entra com chave de API.
+	bot := models.User{
+		UserId:   uuid.NewString(),
+		Username: request.Username,
+		Name:     request.Name,
+		Verified: true,
+		Bot:      true,
+	}
+
+	err := c.users.Create(&bot)
+
+	if errors.Is(err, userRepository.ErrDuplicateUser) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao criar bot: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao criar o bot",
+		})
+
+		return
+	}
+
+	audit.Log("bot.created", map[string]interface{}{
+		"user_id":  bot.UserId,
+		"username": bot.Username,
+		"admin_id": middleware.CurrentUser(ctx).UserId,
+	})
+
+	secret, key, err := c.keys.Create(bot.UserId, "default", apiKeyService.BotScopes, nil)
+
+	if err != nil {
+		log.Printf("Erro ao criar chave do bot %s: %v", bot.UserId, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Bot criado, mas houve um erro ao gerar a chave de API",
+			"bot":     bot,
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"bot":     bot,
+		"api_key": presentKey(secret, key),
+	})
+}
+
+// findBot responde 404 se o usuário da rota não existe ou não é um bot.
+func (c *BotController) findBot(ctx *gin.Context) (models.User, bool) {
+	bot, err := c.users.FindByUserId(ctx.Param("user_id"))
+
+	if err == nil && !bot.Bot {
+		err = userRepository.ErrUserNotFound
+	}
+
+	if errors.Is(err, userRepository.ErrUserNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "Bot não encontrado",
+		})
+
+		return bot, false
+	}
+
+	if err != nil {
+		log.Printf("Erro ao buscar bot: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao buscar o bot",
+		})
+
+		return bot, false
+	}
+
+	return bot, true
+}
+
+func (c *BotController) CreateAPIKey(ctx *gin.Context) {
+	bot, ok := c.findBot(ctx)
+	if !ok {
+		return
+	}
+
+	var request CreateKeyRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "name é obrigatório",
+		})
+
+		return
+	}
+
+	if len(request.Scopes) == 0 {
+		request.Scopes = apiKeyService.BotScopes
+	}
+
+	secret, key, err := c.keys.Create(bot.UserId, request.Name, request.Scopes, nil)
+
+	if errors.Is(err, apiKeyService.ErrInvalidScope) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message":          err.Error(),
+			"available_scopes": apiKeyService.Scopes,
+		})
+
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao criar chave do bot %s: %v", bot.UserId, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao criar a chave de API",
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": presentKey(secret, key),
+	})
+}
+
+func (c *BotController) DeleteAPIKey(ctx *gin.Context) {
+	bot, ok := c.findBot(ctx)
+	if !ok {
+		return
+	}
+
+	keyId := ctx.Param("id")
+
+	err := c.keys.Revoke(bot.UserId, keyId)
+
+	if errors.Is(err, apiKeyService.ErrAPIKeyNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "Chave de API não encontrada",
+		})
+
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao revogar chave do bot %s: %v", bot.UserId, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao revogar a chave de API",
+		})
+
+		return
+	}
+
+	c.sockets.DisconnectSession(apiKeyService.SessionId(keyId))
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Chave de API revogada",
+	})
+}
@@ -0,0 +1,72 @@
+package fileController
+
+import (
+	"errors"
+	"go-web-socket/internal/middleware"
+	jwtService "go-web-socket/internal/services/JWTService"
+	storageService "go-web-socket/internal/services/StorageService"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FileController struct {
+	files *storageService.StorageService
+}
+
+func New(files *storageService.StorageService) *FileController {
+	return &FileController{files: files}
+}
+
+// DeleteFile remove um arquivo de quem o enviou; administradores removem
+// qualquer um.
+func (c *FileController) DeleteFile(ctx *gin.Context) {
+	user := middleware.CurrentUser(ctx)
+
+	file, err := c.files.Find(ctx.Param("file_id"))
+
+	if err == nil && file.UserId != user.UserId && !user.HasRole(jwtService.RoleAdmin) {
+		err = storageService.ErrFileNotFound // não revela que o arquivo existe
+	}
+
+	if errors.Is(err, storageService.ErrFileNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "Arquivo não encontrado",
+		})
+
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao buscar arquivo: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Erro ao remover arquivo",
+		})
+
+		return
+	}
+
+	err = c.files.Release(file.FileId)
+
+	if errors.Is(err, storageService.ErrFileNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "Arquivo não encontrado",
+		})
+
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Erro ao remover arquivo",
+			"details": err.Error(),
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Arquivo removido com sucesso",
+	})
+}
@@ -0,0 +1,256 @@
+package incomingWebhookController
+
+import (
+	"errors"
+	"go-web-socket/internal/middleware"
+	"go-web-socket/internal/models"
+	roomRepository "go-web-socket/internal/repositories/RoomRepository"
+	incomingWebhookService "go-web-socket/internal/services/IncomingWebhookService"
+	"go-web-socket/internal/socket"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPayloadSize limita o corpo aceito em POST /hooks.
+const maxPayloadSize = 64 << 10
+
+type CreateIncomingWebhookRequest struct {
+	Name    string `json:"name" binding:"required,max=100"`
+	IconURL string `json:"icon_url" binding:"max=2048"`
+}
+
+// Poster publica a mensagem de um webhook de entrada (implementado pelo Hub).
+type Poster interface {
+	PostAsIntegration(hook models.IncomingWebhook, name, iconURL, text string) error
+}
+
+// IncomingWebhookController atende as mesmas rotas em
+// /rooms/:room_id/incoming-webhooks, para o dono da sala, e em
+// /me/incoming-webhooks, para os webhooks pessoais, além da rota pública
+// POST /hooks/:hook_id/:token.
+type IncomingWebhookController struct {
+	hooks  *incomingWebhookService.IncomingWebhookService
+	rooms  roomRepository.RoomRepository
+	poster Poster
+}
+
+func New(hooks *incomingWebhookService.IncomingWebhookService, rooms roomRepository.RoomRepository, poster Poster) *IncomingWebhookController {
+	return &IncomingWebhookController{hooks: hooks, rooms: rooms, poster: poster}
+}
+
+// scope devolve a sala da rota (vazia nas rotas pessoais) e exige que quem
+// pediu seja o dono dela.
+func (c *IncomingWebhookController) scope(ctx *gin.Context) (string, bool) {
+	roomId := ctx.Param("room_id")
+	if roomId == "" {
+		return "", true
+	}
+
+	room, err := c.rooms.FindByRoomId(roomId)
+
+	if errors.Is(err, roomRepository.ErrRoomNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "Sala não encontrada",
+		})
+
+		return "", false
+	}
+
+	if err != nil {
+		log.Printf("Erro ao buscar sala: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao buscar a sala",
+		})
+
+		return "", false
+	}
+
+	if room.OwnerId != middleware.CurrentUser(ctx).UserId {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"message": "Só o dono da sala pode gerenciar os webhooks de entrada",
+		})
+
+		return "", false
+	}
+
+	return room.RoomId, true
+}
+
+func (c *IncomingWebhookController) CreateIncomingWebhook(ctx *gin.Context) {
+	roomId, ok := c.scope(ctx)
+	if !ok {
+		return
+	}
+
+	var request CreateIncomingWebhookRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "name é obrigatório",
+		})
+
+		return
+	}
+
+	hook, url, err := c.hooks.Create(middleware.CurrentUser(ctx).UserId, roomId, request.Name, request.IconURL)
+
+	if errors.Is(err, incomingWebhookService.ErrInvalidIconURL) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao criar webhook de entrada: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao criar o webhook de entrada",
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": hook,
+		"url":  url, // só aparece nesta resposta
+	})
+}
+
+func (c *IncomingWebhookController) GetIncomingWebhooks(ctx *gin.Context) {
+	roomId, ok := c.scope(ctx)
+	if !ok {
+		return
+	}
+
+	var hooks []models.IncomingWebhook
+	var err error
+
+	if roomId != "" {
+		hooks, err = c.hooks.ListByRoom(roomId)
+	} else {
+		hooks, err = c.hooks.ListPersonal(middleware.CurrentUser(ctx).UserId)
+	}
+
+	if err != nil {
+		log.Printf("Erro ao listar webhooks de entrada: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao listar os webhooks de entrada",
+		})
+
+		return
+	}
+
+	if hooks == nil {
+		hooks = []models.IncomingWebhook{}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": hooks,
+	})
+}
+
+func (c *IncomingWebhookController) DeleteIncomingWebhook(ctx *gin.Context) {
+	roomId, ok := c.scope(ctx)
+	if !ok {
+		return
+	}
+
+	userId := middleware.CurrentUser(ctx).UserId
+
+	hook, err := c.hooks.Find(ctx.Param("id"))
+
+	// os pessoais só são vistos pelo dono; os da sala, pelo dono da sala
+	if err == nil && (hook.RoomId != roomId || (roomId == "" && hook.OwnerId != userId)) {
+		err = incomingWebhookService.ErrHookNotFound
+	}
+
+	if err == nil {
+		err = c.hooks.Delete(hook.HookId, userId)
+	}
+
+	if errors.Is(err, incomingWebhookService.ErrHookNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "Webhook de entrada não encontrado",
+		})
+
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao remover webhook de entrada: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao remover o webhook de entrada",
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Webhook de entrada removido",
+	})
+}
+
+// Post recebe a mensagem de um sistema externo. Não exige autenticação: o
+// token da URL é a credencial. Responde "ok" em texto, como o Slack, para
+// que clientes feitos para ele funcionem sem mudanças.
+func (c *IncomingWebhookController) Post(ctx *gin.Context) {
+	hook, err := c.hooks.Authenticate(ctx.Param("hook_id"), ctx.Param("token"))
+
+	if errors.Is(err, incomingWebhookService.ErrInvalidHook) {
+		ctx.String(http.StatusNotFound, "no_service")
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao autenticar webhook de entrada: %v", err)
+		ctx.String(http.StatusInternalServerError, "internal_error")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxPayloadSize))
+	if err != nil {
+		ctx.String(http.StatusRequestEntityTooLarge, "payload_too_large")
+		return
+	}
+
+	payload, err := incomingWebhookService.ParsePayload(ctx.ContentType(), body)
+	if err != nil {
+		ctx.String(http.StatusBadRequest, "invalid_payload")
+		return
+	}
+
+	text, err := incomingWebhookService.Render(payload)
+
+	switch {
+	case errors.Is(err, incomingWebhookService.ErrNoText):
+		ctx.String(http.StatusBadRequest, "no_text")
+		return
+	case errors.Is(err, incomingWebhookService.ErrTextTooLong):
+		ctx.String(http.StatusBadRequest, "msg_too_long")
+		return
+	}
+
+	name, iconURL := incomingWebhookService.Sender(hook, payload)
+
+	err = c.poster.PostAsIntegration(hook, name, iconURL, text)
+
+	switch {
+	case errors.Is(err, socket.ErrNotRoomMember):
+		// o dono saiu da sala ou ela foi removida
+		ctx.String(http.StatusGone, "channel_not_found")
+		return
+	case errors.Is(err, socket.ErrMutedInRoom):
+		ctx.String(http.StatusForbidden, "action_prohibited")
+		return
+	case err != nil:
+		log.Printf("Erro ao publicar mensagem do webhook de entrada %s: %v", hook.HookId, err)
+		ctx.String(http.StatusInternalServerError, "internal_error")
+		return
+	}
+
+	ctx.String(http.StatusOK, "ok")
+}
@@ -1,11 +1,20 @@
 package logincontroller
 
 import (
-	"go-web-socket/config"
+	"errors"
+	"go-web-socket/internal/middleware"
 	"go-web-socket/internal/models"
-	jwtService "go-web-socket/internal/services/JWTService"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	authService "go-web-socket/internal/services/AuthService"
+	loginGuardService "go-web-socket/internal/services/LoginGuardService"
+	oidcService "go-web-socket/internal/services/OIDCService"
+	twoFactorService "go-web-socket/internal/services/TwoFactorService"
+	verificationService "go-web-socket/internal/services/VerificationService"
 	useHash "go-web-socket/internal/utils/hash"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -16,22 +25,42 @@ type Credentials struct {
 	Password *string `json:"password"`
 }
 
-func Login(ctx *gin.Context) {
-	var user models.User //Model to scan query results
+type TwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
 
-	db, err := config.GetDatabaseConnection()
+type LoginController struct {
+	users        userRepository.UserRepository
+	auth         *authService.AuthService
+	guard        *loginGuardService.LoginGuard
+	twoFactor    *twoFactorService.TwoFactorService
+	verification *verificationService.VerificationService
+	oidc         *oidcService.OIDCService
+}
 
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"message": err.Error(),
-		})
+func New(users userRepository.UserRepository, auth *authService.AuthService, guard *loginGuardService.LoginGuard, twoFactor *twoFactorService.TwoFactorService, verification *verificationService.VerificationService, oidc *oidcService.OIDCService) *LoginController {
+	// Calcula o hash falso agora para que o primeiro login de um usuário
+	// inexistente não seja mais lento que os outros.
+	go useHash.CheckDummyHash("")
 
-		return
-	}
+	return &LoginController{users: users, auth: auth, guard: guard, twoFactor: twoFactor, verification: verification, oidc: oidc}
+}
+
+func retryLater(ctx *gin.Context, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+
+	ctx.Header("Retry-After", strconv.Itoa(seconds))
+	ctx.JSON(http.StatusTooManyRequests, gin.H{
+		"message":     "Muitas tentativas de login. Tente novamente mais tarde.",
+		"retry_after": seconds,
+	})
+}
 
+func (c *LoginController) Login(ctx *gin.Context) {
 	var credentials Credentials
 
-	if err := ctx.ShouldBindJSON(&credentials); err != nil {
+	if err := ctx.ShouldBindJSON(&credentials); err != nil || credentials.Username == nil || credentials.Password == nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"message": "Nunhum campo foi passo no corpo da requisição",
 		})
@@ -39,19 +68,41 @@ func Login(ctx *gin.Context) {
 		return
 	}
 
-	result := db.Where("username", credentials.Username).First(&user)
+	ip := ctx.ClientIP()
 
-	if result.RowsAffected == 0 {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"message": "O usúario fornecido não existe.",
+	attempt, wait := c.guard.Begin(*credentials.Username, ip)
+	if wait > 0 {
+		retryLater(ctx, wait)
+		return
+	}
+	defer attempt.Release()
+
+	user, err := c.users.FindByUsername(*credentials.Username)
+
+	if err != nil && !errors.Is(err, userRepository.ErrUserNotFound) {
+		log.Printf("Erro ao buscar usuário: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro tentar fazer login",
 		})
 
 		return
 	}
 
-	passwordMatches := useHash.CheckPasswordHash(*credentials.Password, user.Password)
+	var passwordMatches bool
+
+	// Usuário inexistente, senha errada e conta desativada dão a mesma
+	// resposta, no mesmo tempo: a resposta não confirma que a senha de uma
+	// conta desativada está certa. Bots não têm senha e respondem como se não
+	// existissem.
+	if err != nil || user.Bot {
+		useHash.CheckDummyHash(*credentials.Password)
+	} else {
+		passwordMatches = useHash.CheckPasswordHash(*credentials.Password, user.Password) && !user.Disabled
+	}
 
 	if !passwordMatches {
+		attempt.Fail()
+
 		ctx.JSON(http.StatusUnauthorized, gin.H{
 			"message": "Credenciais inválidas",
 		})
@@ -59,32 +110,156 @@ func Login(ctx *gin.Context) {
 		return
 	}
 
-	token, err := jwtService.CreateToken(&jwtService.UserToken{
-		UserId:   user.UserId,
-		Name:     user.Name,
-		Username: user.Username,
-	})
+	c.rehashPassword(user, *credentials.Password)
+
+	if !c.verification.AllowsLogin(user) {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"message":        "E-mail não verificado",
+			"email_verified": false,
+		})
 
+		return
+	}
+
+	// Com 2FA, a senha só libera o token de desafio. O contador de falhas
+	// continua valendo até o código ser aceito.
+	if user.TOTPEnabled {
+		c.sendChallenge(ctx, user)
+		return
+	}
+
+	attempt.Succeed()
+	c.issueTokens(ctx, user)
+}
+
+// sendChallenge responde com o token de desafio do segundo passo do login.
+func (c *LoginController) sendChallenge(ctx *gin.Context, user models.User) {
+	challengeToken, challenge, err := c.twoFactor.Challenge(user)
 	if err != nil {
+		log.Printf("Erro ao gerar desafio 2FA: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro tentar fazer login",
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"mfa_required":    true,
+		"challenge_token": challengeToken,
+		"expires_at":      challenge.ExpiresAt.Unix(),
+	})
+}
+
+// VerifyTwoFactor é o segundo passo do login: troca o token de desafio e um
+// código TOTP (ou de recuperação) pelos tokens de acesso.
+func (c *LoginController) VerifyTwoFactor(ctx *gin.Context) {
+	var request TwoFactorRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "challenge_token e code são obrigatórios",
+		})
+
+		return
+	}
+
+	user, challenge, err := c.twoFactor.ResolveChallenge(request.ChallengeToken)
+	if errors.Is(err, twoFactorService.ErrInvalidChallenge) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao validar desafio 2FA: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro tentar fazer login",
+		})
+
+		return
+	}
+
+	ip := ctx.ClientIP()
+
+	attempt, wait := c.guard.Begin(user.Username, ip)
+	if wait > 0 {
+		retryLater(ctx, wait)
+		return
+	}
+	defer attempt.Release()
+
+	err = c.twoFactor.CompleteChallenge(user, challenge, request.Code)
+	if errors.Is(err, twoFactorService.ErrInvalidCode) {
+		attempt.Fail()
+
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Código inválido",
+		})
+
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao verificar código 2FA: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "Houve um erro tentar fazer login",
 		})
 
 		return
 	}
 
-	sqlDB, err := db.DB()
+	attempt.Succeed()
+	c.issueTokens(ctx, user)
+}
 
-	if err == nil {
-		defer sqlDB.Close()
+// rehashPassword refaz o hash da senha quando ele foi gerado com outro
+// algoritmo ou custo. Só é possível no login, que é quando temos a senha.
+func (c *LoginController) rehashPassword(user models.User, password string) {
+	if !useHash.NeedsRehash(user.Password) {
+		return
 	}
 
-	user.Password = ""
+	hash, err := useHash.HashPassword(password)
+	if err != nil {
+		log.Printf("Erro ao refazer hash da senha de %s: %v", user.UserId, err)
+		return
+	}
+
+	if _, err := c.users.ReplacePasswordHash(user.UserId, user.Password, hash); err != nil {
+		log.Printf("Erro ao gravar novo hash da senha de %s: %v", user.UserId, err)
+	}
+}
+
+func (c *LoginController) issueTokens(ctx *gin.Context, user models.User) {
+	tokens, err := c.auth.IssueTokens(user, middleware.Meta(ctx))
+
+	// A conta pode ter sido desativada depois da senha ser aceita (ex.: entre
+	// os dois passos do 2FA); responde como no login com senha errada.
+	if errors.Is(err, authService.ErrUserDisabled) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Credenciais inválidas",
+		})
+
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "Houve um erro tentar fazer login",
+		})
+
+		return
+	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"token": token,
-		"exp":   int64((time.Minute * 60 * 48).Seconds()),
-		"user":  user,
+		"token":              tokens.AccessToken,
+		"exp":                tokens.ExpiresIn,
+		"expires_at":         tokens.ExpiresAt,
+		"refresh_token":      tokens.RefreshToken,
+		"refresh_expires_at": tokens.RefreshExpiresAt,
+		"user":               user,
 	})
-
 }
@@ -0,0 +1,134 @@
+package logincontroller
+
+import (
+	"errors"
+	oidcService "go-web-socket/internal/services/OIDCService"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oidcFlowCookie guarda o login OIDC em andamento (state, nonce e o
+// verificador PKCE, assinados) até o callback.
+const oidcFlowCookie = "oidc_flow"
+
+func (c *LoginController) setFlowCookie(ctx *gin.Context, value string, maxAge int) {
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(oidcFlowCookie, value, maxAge, "/auth/oidc", "", c.oidc.SecureCookie(), true)
+}
+
+// OIDCLogin redireciona para o provedor de SSO. Com ?format=json devolve a
+// URL em vez de redirecionar, para frontends que abrem o login num popup.
+// Nos dois casos, o callback tem que vir do mesmo navegador, com o cookie.
+func (c *LoginController) OIDCLogin(ctx *gin.Context) {
+	authURL, flowToken, err := c.oidc.AuthURL()
+
+	if errors.Is(err, oidcService.ErrDisabled) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao iniciar login OIDC: %v", err)
+		ctx.JSON(http.StatusBadGateway, gin.H{
+			"message": "Não foi possível falar com o provedor de login",
+		})
+
+		return
+	}
+
+	c.setFlowCookie(ctx, flowToken, int(oidcService.FlowTTL.Seconds()))
+
+	if ctx.Query("format") == "json" {
+		ctx.JSON(http.StatusOK, gin.H{
+			"authorization_url": authURL,
+		})
+
+		return
+	}
+
+	ctx.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback recebe code e state do provedor e responde como o /login:
+// tokens de acesso ou, se o usuário tiver 2FA, o token de desafio.
+func (c *LoginController) OIDCCallback(ctx *gin.Context) {
+	if providerErr := ctx.Query("error"); providerErr != "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Login recusado pelo provedor",
+			"error":   providerErr,
+		})
+
+		return
+	}
+
+	code, state := ctx.Query("code"), ctx.Query("state")
+	if code == "" || state == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "code e state são obrigatórios",
+		})
+
+		return
+	}
+
+	// O cookie só serve para um callback
+	flowToken, _ := ctx.Cookie(oidcFlowCookie)
+	c.setFlowCookie(ctx, "", -1)
+
+	claims, err := c.oidc.Exchange(code, state, flowToken)
+
+	if errors.Is(err, oidcService.ErrDisabled) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	if errors.Is(err, oidcService.ErrInvalidState) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro no callback OIDC: %v", err)
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Não foi possível validar o login no provedor",
+		})
+
+		return
+	}
+
+	user, err := c.oidc.Resolve(claims)
+
+	if errors.Is(err, oidcService.ErrNotLinked) {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao vincular usuário OIDC: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro tentar fazer login",
+		})
+
+		return
+	}
+
+	if user.TOTPEnabled {
+		c.sendChallenge(ctx, user)
+		return
+	}
+
+	c.issueTokens(ctx, user)
+}
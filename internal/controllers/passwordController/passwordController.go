@@ -0,0 +1,192 @@
+package passwordController
+
+import (
+	"errors"
+	"go-web-socket/internal/middleware"
+	loginGuardService "go-web-socket/internal/services/LoginGuardService"
+	passwordService "go-web-socket/internal/services/PasswordService"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Sockets fecha as conexões das sessões encerradas pela troca de senha.
+type Sockets interface {
+	DisconnectSession(sessionId string) int
+	DisconnectUser(userId string) int
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	// Login é o username ou o e-mail da conta.
+	Login string `json:"login" binding:"required"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+type PasswordController struct {
+	passwords *passwordService.PasswordService
+	guard     *loginGuardService.LoginGuard
+	sockets   Sockets
+}
+
+func New(passwords *passwordService.PasswordService, guard *loginGuardService.LoginGuard, sockets Sockets) *PasswordController {
+	return &PasswordController{passwords: passwords, guard: guard, sockets: sockets}
+}
+
+func retryLater(ctx *gin.Context, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+
+	ctx.Header("Retry-After", strconv.Itoa(seconds))
+	ctx.JSON(http.StatusTooManyRequests, gin.H{
+		"message":     "Muitas tentativas com a senha atual. Tente novamente mais tarde.",
+		"retry_after": seconds,
+	})
+}
+
+// policyError responde 400 com as regras violadas, se err for da política.
+func policyError(ctx *gin.Context, err error) bool {
+	var policyErr *passwordService.PolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+
+	ctx.JSON(http.StatusBadRequest, gin.H{
+		"message":  "A senha não atende à política de senhas",
+		"problems": policyErr.Problems,
+	})
+
+	return true
+}
+
+func (c *PasswordController) ChangePassword(ctx *gin.Context) {
+	var request ChangePasswordRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "current_password e new_password são obrigatórios",
+		})
+
+		return
+	}
+
+	claims := middleware.CurrentUser(ctx)
+
+	// A senha atual conta como uma tentativa de login: um token roubado não
+	// serve para adivinhá-la sem limite.
+	attempt, wait := c.guard.Begin(claims.Username, ctx.ClientIP())
+	if wait > 0 {
+		retryLater(ctx, wait)
+		return
+	}
+	defer attempt.Release()
+
+	revoked, err := c.passwords.Change(claims.UserId, claims.SessionId, request.CurrentPassword, request.NewPassword)
+
+	if errors.Is(err, passwordService.ErrWrongPassword) {
+		attempt.Fail()
+
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"message": "Senha atual incorreta",
+		})
+
+		return
+	}
+
+	if policyError(ctx, err) {
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao trocar senha: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao trocar a senha",
+		})
+
+		return
+	}
+
+	attempt.Succeed()
+
+	for _, sessionId := range revoked {
+		c.sockets.DisconnectSession(sessionId)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":          "Senha alterada com sucesso",
+		"revoked_sessions": len(revoked),
+	})
+}
+
+func (c *PasswordController) ForgotPassword(ctx *gin.Context) {
+	var request ForgotPasswordRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "login é obrigatório",
+		})
+
+		return
+	}
+
+	if err := c.passwords.RequestReset(request.Login); err != nil {
+		log.Printf("Erro ao pedir redefinição de senha: %v", err)
+	}
+
+	// A resposta é sempre a mesma, exista a conta ou não.
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "Se a conta existir e tiver e-mail confirmado, enviaremos um link de redefinição",
+	})
+}
+
+func (c *PasswordController) ResetPassword(ctx *gin.Context) {
+	var request ResetPasswordRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "token e new_password são obrigatórios",
+		})
+
+		return
+	}
+
+	userId, err := c.passwords.Reset(request.Token, request.NewPassword)
+
+	if errors.Is(err, passwordService.ErrInvalidResetToken) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	if policyError(ctx, err) {
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao redefinir senha: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao redefinir a senha",
+		})
+
+		return
+	}
+
+	c.sockets.DisconnectUser(userId)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Senha redefinida com sucesso",
+	})
+}
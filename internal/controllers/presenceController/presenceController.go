@@ -0,0 +1,164 @@
+package presenceController
+
+import (
+	"errors"
+	"go-web-socket/internal/middleware"
+	"go-web-socket/internal/models"
+	presenceService "go-web-socket/internal/services/PresenceService"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SetStatusRequest struct {
+	Status    string     `json:"status" binding:"required"`
+	Text      string     `json:"text"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// Notifier anuncia a presença aos contatos (implementado pelo Hub).
+type Notifier interface {
+	StatusChanged(before, after models.User)
+	Presences(userIds []string) ([]presenceService.Presence, error)
+}
+
+type PresenceController struct {
+	presence *presenceService.PresenceService
+	notifier Notifier
+}
+
+func New(presence *presenceService.PresenceService, notifier Notifier) *PresenceController {
+	return &PresenceController{presence: presence, notifier: notifier}
+}
+
+// respondStatus devolve o status escolhido e a presença como os contatos a
+// veem.
+func (c *PresenceController) respondStatus(ctx *gin.Context, user models.User) {
+	presences, err := c.notifier.Presences([]string{user.UserId})
+	if err != nil {
+		log.Printf("Erro ao consultar a presença de %s: %v", user.UserId, err)
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{
+			"message": "Presença indisponível no momento",
+		})
+
+		return
+	}
+
+	status, text, until := presenceService.Chosen(user, time.Now())
+
+	data := gin.H{
+		"status":     status,
+		"text":       text,
+		"expires_at": until,
+		"presence":   nil,
+	}
+
+	if len(presences) > 0 {
+		data["presence"] = presences[0]
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": data,
+	})
+}
+
+func (c *PresenceController) GetStatus(ctx *gin.Context) {
+	user := middleware.CurrentUser(ctx)
+
+	after, err := c.presence.User(user.UserId)
+	if err != nil {
+		log.Printf("Erro ao buscar usuário %s: %v", user.UserId, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao buscar o status",
+		})
+
+		return
+	}
+
+	c.respondStatus(ctx, after)
+}
+
+func (c *PresenceController) SetStatus(ctx *gin.Context) {
+	var request SetStatusRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "status é obrigatório",
+		})
+
+		return
+	}
+
+	c.setStatus(ctx, request)
+}
+
+// ClearStatus volta ao status automático.
+func (c *PresenceController) ClearStatus(ctx *gin.Context) {
+	c.setStatus(ctx, SetStatusRequest{Status: presenceService.StatusOnline})
+}
+
+func (c *PresenceController) setStatus(ctx *gin.Context, request SetStatusRequest) {
+	userId := middleware.CurrentUser(ctx).UserId
+
+	before, after, err := c.presence.SetStatus(userId, request.Status, request.Text, request.ExpiresAt)
+
+	if errors.Is(err, presenceService.ErrInvalidStatus) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message":            err.Error(),
+			"available_statuses": presenceService.Statuses,
+		})
+
+		return
+	}
+
+	if errors.Is(err, presenceService.ErrTextTooLong) || errors.Is(err, presenceService.ErrInvalidExpiry) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao alterar o status de %s: %v", userId, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao alterar o status",
+		})
+
+		return
+	}
+
+	c.notifier.StatusChanged(before, after)
+	c.respondStatus(ctx, after)
+}
+
+// GetContacts lista a presença de quem o usuário pode acompanhar.
+func (c *PresenceController) GetContacts(ctx *gin.Context) {
+	userId := middleware.CurrentUser(ctx).UserId
+
+	contacts, err := c.presence.Contacts(userId)
+	if err != nil {
+		log.Printf("Erro ao listar contatos de %s: %v", userId, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao listar os contatos",
+		})
+
+		return
+	}
+
+	presences, err := c.notifier.Presences(contacts)
+	if err != nil {
+		log.Printf("Erro ao consultar a presença dos contatos de %s: %v", userId, err)
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{
+			"message": "Presença indisponível no momento",
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": presences,
+	})
+}
@@ -0,0 +1,262 @@
+package roomController
+
+import (
+	"errors"
+	"go-web-socket/internal/middleware"
+	"go-web-socket/internal/models"
+	roomRepository "go-web-socket/internal/repositories/RoomRepository"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CreateRoomRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type AddMemberRequest struct {
+	UserId string `json:"user_id" binding:"required"`
+}
+
+type RoomController struct {
+	rooms roomRepository.RoomRepository
+	users userRepository.UserRepository
+}
+
+func New(rooms roomRepository.RoomRepository, users userRepository.UserRepository) *RoomController {
+	return &RoomController{rooms: rooms, users: users}
+}
+
+func (c *RoomController) CreateRoom(ctx *gin.Context) {
+	var request CreateRoomRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "name é obrigatório",
+		})
+
+		return
+	}
+
+	room := models.Room{
+		RoomId:  uuid.NewString(),
+		Name:    request.Name,
+		OwnerId: middleware.CurrentUser(ctx).UserId,
+	}
+
+	if err := c.rooms.Create(&room); err != nil {
+		log.Printf("Erro ao criar sala: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao criar a sala",
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": room,
+	})
+}
+
+func (c *RoomController) GetRooms(ctx *gin.Context) {
+	rooms, err := c.rooms.ListByUser(middleware.CurrentUser(ctx).UserId)
+
+	if err != nil {
+		log.Printf("Erro ao listar salas: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao listar as salas",
+		})
+
+		return
+	}
+
+	if rooms == nil {
+		rooms = []models.Room{}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": rooms,
+	})
+}
+
+// findRoom carrega a sala da rota e responde 404 quando ela não existe ou
+// quem pediu não é membro, para não revelar salas alheias.
+func (c *RoomController) findRoom(ctx *gin.Context) (models.Room, bool) {
+	room, err := c.rooms.FindByRoomId(ctx.Param("room_id"))
+
+	if err == nil {
+		var member bool
+		member, err = c.rooms.IsMember(room.RoomId, middleware.CurrentUser(ctx).UserId)
+
+		if err == nil && !member {
+			err = roomRepository.ErrRoomNotFound
+		}
+	}
+
+	if errors.Is(err, roomRepository.ErrRoomNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "Sala não encontrada",
+		})
+
+		return room, false
+	}
+
+	if err != nil {
+		log.Printf("Erro ao buscar sala: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao buscar a sala",
+		})
+
+		return room, false
+	}
+
+	return room, true
+}
+
+func (c *RoomController) GetMembers(ctx *gin.Context) {
+	room, ok := c.findRoom(ctx)
+	if !ok {
+		return
+	}
+
+	memberIds, err := c.rooms.Members(room.RoomId)
+
+	if err != nil {
+		log.Printf("Erro ao listar membros da sala %s: %v", room.RoomId, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao listar os membros",
+		})
+
+		return
+	}
+
+	members := make([]gin.H, 0, len(memberIds))
+	for _, userId := range memberIds {
+		user, err := c.users.FindByUserId(userId)
+		if err != nil {
+			continue
+		}
+
+		members = append(members, gin.H{
+			"user_id":  user.UserId,
+			"username": user.Username,
+			"name":     user.Name,
+			"bot":      user.Bot,
+			"owner":    user.UserId == room.OwnerId,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": members,
+	})
+}
+
+func (c *RoomController) AddMember(ctx *gin.Context) {
+	room, ok := c.findRoom(ctx)
+	if !ok {
+		return
+	}
+
+	if room.OwnerId != middleware.CurrentUser(ctx).UserId {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"message": "Só o dono da sala pode adicionar membros",
+		})
+
+		return
+	}
+
+	var request AddMemberRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "user_id é obrigatório",
+		})
+
+		return
+	}
+
+	if _, err := c.users.FindByUserId(request.UserId); err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "Usuário não encontrado",
+		})
+
+		return
+	}
+
+	err := c.rooms.AddMember(room.RoomId, request.UserId)
+
+	if errors.Is(err, roomRepository.ErrAlreadyMember) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao adicionar membro à sala %s: %v", room.RoomId, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao adicionar o membro",
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "Membro adicionado",
+	})
+}
+
+// RemoveMember tira um membro da sala. O dono remove qualquer um; os demais
+// só podem sair.
+func (c *RoomController) RemoveMember(ctx *gin.Context) {
+	room, ok := c.findRoom(ctx)
+	if !ok {
+		return
+	}
+
+	userId := ctx.Param("user_id")
+	current := middleware.CurrentUser(ctx).UserId
+
+	if room.OwnerId != current && userId != current {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"message": "Só o dono da sala pode remover outros membros",
+		})
+
+		return
+	}
+
+	if userId == room.OwnerId {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "O dono não pode sair da sala",
+		})
+
+		return
+	}
+
+	err := c.rooms.RemoveMember(room.RoomId, userId)
+
+	if errors.Is(err, roomRepository.ErrNotMember) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao remover membro da sala %s: %v", room.RoomId, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao remover o membro",
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Membro removido",
+	})
+}
@@ -0,0 +1,155 @@
+package twoFactorController
+
+import (
+	"errors"
+	"go-web-socket/internal/middleware"
+	"go-web-socket/internal/models"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	twoFactorService "go-web-socket/internal/services/TwoFactorService"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorController struct {
+	users     userRepository.UserRepository
+	twoFactor *twoFactorService.TwoFactorService
+}
+
+func New(users userRepository.UserRepository, twoFactor *twoFactorService.TwoFactorService) *TwoFactorController {
+	return &TwoFactorController{users: users, twoFactor: twoFactor}
+}
+
+// currentUser carrega do banco o usuário do token; os dados de 2FA não vão
+// no token.
+func (c *TwoFactorController) currentUser(ctx *gin.Context) (models.User, bool) {
+	claims := middleware.CurrentUser(ctx)
+
+	user, err := c.users.FindByUserId(claims.UserId)
+	if err != nil {
+		log.Printf("Erro ao buscar usuário %s: %v", claims.UserId, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Erro ao buscar usuário",
+		})
+
+		return models.User{}, false
+	}
+
+	return user, true
+}
+
+func bindCode(ctx *gin.Context) (string, bool) {
+	var request CodeRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "code é obrigatório",
+		})
+
+		return "", false
+	}
+
+	return request.Code, true
+}
+
+func respondError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, twoFactorService.ErrInvalidCode):
+		ctx.JSON(http.StatusUnauthorized, gin.H{"message": "Código inválido"})
+	case errors.Is(err, twoFactorService.ErrAlreadyEnabled),
+		errors.Is(err, twoFactorService.ErrNotEnrolled),
+		errors.Is(err, twoFactorService.ErrNotEnabled):
+		ctx.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	default:
+		log.Printf("Erro na autenticação em dois fatores: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro na autenticação em dois fatores",
+		})
+	}
+}
+
+func (c *TwoFactorController) Enroll(ctx *gin.Context) {
+	user, ok := c.currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	enrollment, err := c.twoFactor.Enroll(user)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, enrollment)
+}
+
+func (c *TwoFactorController) Confirm(ctx *gin.Context) {
+	code, ok := bindCode(ctx)
+	if !ok {
+		return
+	}
+
+	user, ok := c.currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	recoveryCodes, err := c.twoFactor.Confirm(user, code)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":        "Autenticação em dois fatores ativada",
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+func (c *TwoFactorController) Disable(ctx *gin.Context) {
+	code, ok := bindCode(ctx)
+	if !ok {
+		return
+	}
+
+	user, ok := c.currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.twoFactor.Disable(user, code); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Autenticação em dois fatores desativada",
+	})
+}
+
+func (c *TwoFactorController) RegenerateRecoveryCodes(ctx *gin.Context) {
+	code, ok := bindCode(ctx)
+	if !ok {
+		return
+	}
+
+	user, ok := c.currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	recoveryCodes, err := c.twoFactor.RegenerateRecoveryCodes(user, code)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"recovery_codes": recoveryCodes,
+	})
+}
@@ -2,20 +2,87 @@ package userController
 
 import (
 	"encoding/base64"
-	"go-web-socket/config"
+	"errors"
+	"go-web-socket/internal/middleware"
 	"go-web-socket/internal/models"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	jwtService "go-web-socket/internal/services/JWTService"
+	passwordService "go-web-socket/internal/services/PasswordService"
+	presenceService "go-web-socket/internal/services/PresenceService"
 	s3uploadservice "go-web-socket/internal/services/S3UploadService"
 	userService "go-web-socket/internal/services/UserService"
+	verificationService "go-web-socket/internal/services/VerificationService"
 	useHash "go-web-socket/internal/utils/hash"
+	"go-web-socket/internal/utils/pagination"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"net/mail"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-func EditUser(ctx *gin.Context) {
+type CreateUserRequest struct {
+	Username string  `json:"username"`
+	Name     string  `json:"name"`
+	Password string  `json:"password"`
+	Email    *string `json:"email"`
+}
+
+// Presences consulta a presença dos usuários que viewerId pode acompanhar
+// (implementado pelo Hub).
+type Presences interface {
+	ContactPresences(viewerId string, userIds []string) ([]presenceService.Presence, error)
+}
+
+type UserController struct {
+	repo         userRepository.UserRepository
+	users        *userService.UserService
+	passwords    *passwordService.PasswordService
+	verification *verificationService.VerificationService
+	presences    Presences
+}
+
+func New(repo userRepository.UserRepository, users *userService.UserService, passwords *passwordService.PasswordService, verification *verificationService.VerificationService, presences Presences) *UserController {
+	return &UserController{repo: repo, users: users, passwords: passwords, verification: verification, presences: presences}
+}
+
+// profiles monta os perfis públicos, com a presença só dos contatos de quem
+// pediu e dele mesmo. Se a presença estiver indisponível, os perfis saem sem
+// ela.
+func (c *UserController) profiles(ctx *gin.Context, users []models.User) []userService.Profile {
+	userIds := make([]string, 0, len(users))
+	for _, user := range users {
+		userIds = append(userIds, user.UserId)
+	}
+
+	presences, err := c.presences.ContactPresences(middleware.CurrentUser(ctx).UserId, userIds)
+	if err != nil {
+		log.Printf("Erro ao consultar a presença dos usuários: %v", err)
+	}
+
+	return userService.Profiles(users, presences)
+}
+
+// canEdit diz se quem fez a requisição pode alterar o usuário do path: só
+// ele mesmo ou um administrador.
+func canEdit(ctx *gin.Context) bool {
+	user := middleware.CurrentUser(ctx)
+
+	return user.UserId == ctx.Param("user_id") || user.HasRole(jwtService.RoleAdmin)
+}
+
+func (c *UserController) EditUser(ctx *gin.Context) {
+	if !canEdit(ctx) {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"message": "Você só pode editar o próprio usuário",
+		})
+
+		return
+	}
+
 	var requestBody models.User
 
 	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
@@ -25,7 +92,7 @@ func EditUser(ctx *gin.Context) {
 		return
 	}
 
-	user, err := userService.EditUser(ctx.Param("user_id"), requestBody)
+	user, err := c.users.EditUser(ctx.Param("user_id"), requestBody)
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
@@ -35,89 +102,89 @@ func EditUser(ctx *gin.Context) {
 		return
 	}
 
-	user.Password = ""
-
 	ctx.JSON(http.StatusOK, gin.H{
 		"message": "Usúario editado com sucesso",
-		"user":    user,
+		"user":    c.profiles(ctx, []models.User{user})[0],
 	})
 
 }
 
-func GetUser(ctx *gin.Context) {
-	var user models.User
+func (c *UserController) GetUser(ctx *gin.Context) {
 	userName := ctx.Param("username")
 
-	db, err := config.GetDatabaseConnection()
+	user, err := c.repo.FindByUsername(userName)
 
-	if err != nil {
-		log.Printf("Error while connecting to database: %v", err)
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"message": err.Error(),
+	if errors.Is(err, userRepository.ErrUserNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"mesage": "Usuário não econtrado",
 		})
 
 		return
 	}
 
-	result := db.Where("username", userName).Find(&user)
-
-	if err := result.Error; err != nil {
-		log.Fatalf("Error while make query: %v", err.Error())
-		ctx.JSON(http.StatusOK, gin.H{
+	if err != nil {
+		log.Printf("Error while make query: %v", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "Erro while making query",
 		})
 
 		return
 	}
 
-	if result.RowsAffected == 0 {
-		ctx.JSON(http.StatusNotFound, gin.H{
-			"mesage": "Usuário não econtrado",
+	ctx.JSON(http.StatusOK, gin.H{
+		"user": c.profiles(ctx, []models.User{user})[0],
+	})
+}
+
+// GetUsers lista os perfis públicos das contas ativas, paginados e
+// filtrados por ?search= (trecho do nome ou do username).
+func (c *UserController) GetUsers(ctx *gin.Context) {
+	page, err := pagination.Parse(ctx.Query("page"), ctx.Query("per_page"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
 		})
 
 		return
 	}
 
-	sqlDB, err := db.DB()
-
-	if err == nil {
-		defer sqlDB.Close()
+	disabled := false
+	filter := userRepository.UserFilter{
+		Search:   ctx.Query("search"),
+		Disabled: &disabled,
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"user": user,
-	})
-}
-
-func GetUsers(ctx *gin.Context) {
-	var users []models.User
-
-	db, err := config.GetDatabaseConnection()
+	total, err := c.repo.Count(filter)
 
 	if err != nil {
-		log.Printf("Error while connecting to database: %v", err)
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"message": err.Error(),
+		log.Printf("Error while make query: %v", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Erro while making query",
 		})
 
 		return
 	}
 
-	db.Find(&users)
+	filter.Limit, filter.Offset = page.PerPage, page.Offset()
+
+	users, err := c.repo.Search(filter)
 
-	sqlDB, err := db.DB()
+	if err != nil {
+		log.Printf("Error while make query: %v", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Erro while making query",
+		})
 
-	if err == nil {
-		defer sqlDB.Close()
+		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"data": users,
+		"data":       c.profiles(ctx, users),
+		"pagination": page.Meta(total),
 	})
-
 }
 
-func UpdateUserAvatar(ctx *gin.Context) {
+func (c *UserController) UpdateUserAvatar(ctx *gin.Context) {
 	if ctx.Query("filename") == "" {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"message": "Filename was not provided",
@@ -172,7 +239,14 @@ func UpdateUserAvatar(ctx *gin.Context) {
 	})
 }
 
-func UploadUserAvatar(ctx *gin.Context) {
+func (c *UserController) UploadUserAvatar(ctx *gin.Context) {
+	if !canEdit(ctx) {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"message": "Você só pode editar o próprio usuário",
+		})
+
+		return
+	}
 
 	file, err := ctx.FormFile("file")
 	if err != nil {
@@ -214,7 +288,7 @@ func UploadUserAvatar(ctx *gin.Context) {
 		return
 	}
 
-	user, err := userService.EditUser(ctx.Param("user_id"), models.User{
+	user, err := c.users.EditUser(ctx.Param("user_id"), models.User{
 		Avatar: fileURl,
 	})
 
@@ -228,33 +302,53 @@ func UploadUserAvatar(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"message": "Foto de perfil atualizada com sucesso!",
-		"user":    user,
+		"user":    c.profiles(ctx, []models.User{user})[0],
 	})
 }
 
-func CreateUser(ctx *gin.Context) {
-	db, err := config.GetDatabaseConnection()
+func (c *UserController) CreateUser(ctx *gin.Context) {
+	var requestBody CreateUserRequest
 
-	if err != nil {
-		log.Printf("Error while connecting to database: %v", err)
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		log.Printf("Error while binding JSON: %v", err.Error())
 		ctx.JSON(http.StatusBadRequest, gin.H{
-			"message": err.Error(),
+			"message": "No fields were passed in the request body",
 		})
 
 		return
 	}
 
-	var requestBody models.User
+	if err := c.passwords.Validate(requestBody.Password, requestBody.Username); err != nil {
+		var policyErr *passwordService.PolicyError
+		errors.As(err, &policyErr)
 
-	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
-		log.Printf("Error while binding JSON: %v", err.Error())
 		ctx.JSON(http.StatusBadRequest, gin.H{
-			"message": "No fields were passed in the request body",
+			"message":  "A senha não atende à política de senhas",
+			"problems": policyErr.Problems,
+		})
+
+		return
+	}
+
+	if requestBody.Email == nil || *requestBody.Email == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "E-mail é obrigatório",
 		})
 
 		return
 	}
 
+	address, err := mail.ParseAddress(*requestBody.Email)
+	if err != nil || address.Address != *requestBody.Email {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "E-mail inválido",
+		})
+
+		return
+	}
+
+	email := strings.ToLower(address.Address)
+
 	userId := uuid.New()
 
 	hashed_password, err := useHash.HashPassword(requestBody.Password)
@@ -273,12 +367,21 @@ func CreateUser(ctx *gin.Context) {
 		UserId:   userId.String(),
 		Username: requestBody.Username,
 		Name:     requestBody.Name,
+		Email:    &email,
 		Password: hashed_password,
 	}
 
-	result := db.Create(&user)
+	err = c.repo.Create(&user)
+
+	if errors.Is(err, userRepository.ErrDuplicateUser) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
 
-	if err := result.Error; err != nil {
+		return
+	}
+
+	if err != nil {
 		log.Printf("Error while creating user: %v", err)
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
@@ -287,10 +390,8 @@ func CreateUser(ctx *gin.Context) {
 		return
 	}
 
-	sqlDB, err := db.DB()
-
-	if err == nil {
-		defer sqlDB.Close()
+	if err := c.verification.Send(user); err != nil {
+		log.Printf("Erro ao enviar verificação de e-mail: %v", err)
 	}
 
 	ctx.JSON(http.StatusCreated, gin.H{
@@ -0,0 +1,83 @@
+package verificationController
+
+import (
+	"errors"
+	verificationService "go-web-socket/internal/services/VerificationService"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ResendRequest struct {
+	// Login é o username ou o e-mail da conta.
+	Login string `json:"login" binding:"required"`
+}
+
+type VerificationController struct {
+	verification *verificationService.VerificationService
+}
+
+func New(verification *verificationService.VerificationService) *VerificationController {
+	return &VerificationController{verification: verification}
+}
+
+func (c *VerificationController) VerifyEmail(ctx *gin.Context) {
+	var request VerifyEmailRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "token é obrigatório",
+		})
+
+		return
+	}
+
+	err := c.verification.Verify(request.Token)
+
+	if errors.Is(err, verificationService.ErrInvalidToken) || errors.Is(err, verificationService.ErrTokenExpired) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"expired": errors.Is(err, verificationService.ErrTokenExpired),
+		})
+
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao verificar e-mail: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao verificar o e-mail",
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "E-mail verificado com sucesso",
+	})
+}
+
+func (c *VerificationController) Resend(ctx *gin.Context) {
+	var request ResendRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "login é obrigatório",
+		})
+
+		return
+	}
+
+	if err := c.verification.Resend(request.Login); err != nil {
+		log.Printf("Erro ao reenviar verificação de e-mail: %v", err)
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "Se a conta existir e ainda não estiver verificada, enviaremos um novo link",
+	})
+}
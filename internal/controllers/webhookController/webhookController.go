@@ -0,0 +1,250 @@
+package webhookController
+
+import (
+	"errors"
+	"go-web-socket/internal/middleware"
+	"go-web-socket/internal/models"
+	roomRepository "go-web-socket/internal/repositories/RoomRepository"
+	webhookService "go-web-socket/internal/services/WebhookService"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required,max=2048"`
+	Events []string `json:"events"`
+}
+
+// WebhookController atende as mesmas rotas em /rooms/:room_id/webhooks, para
+// o dono da sala, e em /admin/webhooks, para os webhooks globais.
+type WebhookController struct {
+	webhooks *webhookService.WebhookService
+	rooms    roomRepository.RoomRepository
+}
+
+func New(webhooks *webhookService.WebhookService, rooms roomRepository.RoomRepository) *WebhookController {
+	return &WebhookController{webhooks: webhooks, rooms: rooms}
+}
+
+func present(webhook models.Webhook) gin.H {
+	return gin.H{
+		"id":          webhook.WebhookId,
+		"room_id":     webhook.RoomId,
+		"url":         webhook.URL,
+		"events":      webhookService.EventList(webhook),
+		"failures":    webhook.Failures,
+		"disabled_at": webhook.DisabledAt,
+		"created_at":  webhook.CreatedAt,
+	}
+}
+
+// scope devolve a sala da rota (vazia nas rotas de administração) e exige
+// que quem pediu seja o dono dela.
+func (c *WebhookController) scope(ctx *gin.Context) (string, bool) {
+	roomId := ctx.Param("room_id")
+	if roomId == "" {
+		return "", true
+	}
+
+	room, err := c.rooms.FindByRoomId(roomId)
+
+	if errors.Is(err, roomRepository.ErrRoomNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "Sala não encontrada",
+		})
+
+		return "", false
+	}
+
+	if err != nil {
+		log.Printf("Erro ao buscar sala: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao buscar a sala",
+		})
+
+		return "", false
+	}
+
+	if room.OwnerId != middleware.CurrentUser(ctx).UserId {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"message": "Só o dono da sala pode gerenciar os webhooks",
+		})
+
+		return "", false
+	}
+
+	return room.RoomId, true
+}
+
+// findWebhook carrega o webhook da rota, desde que pertença ao escopo dela.
+func (c *WebhookController) findWebhook(ctx *gin.Context) (models.Webhook, bool) {
+	roomId, ok := c.scope(ctx)
+	if !ok {
+		return models.Webhook{}, false
+	}
+
+	webhook, err := c.webhooks.Find(ctx.Param("id"))
+
+	if err == nil && webhook.RoomId != roomId {
+		err = webhookService.ErrWebhookNotFound
+	}
+
+	if errors.Is(err, webhookService.ErrWebhookNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "Webhook não encontrado",
+		})
+
+		return webhook, false
+	}
+
+	if err != nil {
+		log.Printf("Erro ao buscar webhook: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao buscar o webhook",
+		})
+
+		return webhook, false
+	}
+
+	return webhook, true
+}
+
+func (c *WebhookController) CreateWebhook(ctx *gin.Context) {
+	roomId, ok := c.scope(ctx)
+	if !ok {
+		return
+	}
+
+	var request CreateWebhookRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "url é obrigatória",
+		})
+
+		return
+	}
+
+	webhook, secret, err := c.webhooks.Create(middleware.CurrentUser(ctx).UserId, roomId, request.URL, request.Events)
+
+	if errors.Is(err, webhookService.ErrInvalidURL) || errors.Is(err, webhookService.ErrInternalTarget) ||
+		errors.Is(err, webhookService.ErrUnresolvedHost) || errors.Is(err, webhookService.ErrInvalidEvent) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message":          err.Error(),
+			"available_events": webhookService.Events,
+		})
+
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao criar webhook: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao criar o webhook",
+		})
+
+		return
+	}
+
+	data := present(webhook)
+	data["secret"] = secret // só aparece nesta resposta
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": data,
+	})
+}
+
+func (c *WebhookController) GetWebhooks(ctx *gin.Context) {
+	roomId, ok := c.scope(ctx)
+	if !ok {
+		return
+	}
+
+	webhooks, err := c.webhooks.List(roomId)
+
+	if err != nil {
+		log.Printf("Erro ao listar webhooks: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao listar os webhooks",
+		})
+
+		return
+	}
+
+	data := make([]gin.H, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		data = append(data, present(webhook))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": data,
+	})
+}
+
+func (c *WebhookController) DeleteWebhook(ctx *gin.Context) {
+	webhook, ok := c.findWebhook(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.webhooks.Delete(webhook.WebhookId, middleware.CurrentUser(ctx).UserId); err != nil {
+		log.Printf("Erro ao remover webhook %s: %v", webhook.WebhookId, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao remover o webhook",
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Webhook removido",
+	})
+}
+
+func (c *WebhookController) EnableWebhook(ctx *gin.Context) {
+	webhook, ok := c.findWebhook(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.webhooks.Enable(webhook.WebhookId); err != nil {
+		log.Printf("Erro ao reativar webhook %s: %v", webhook.WebhookId, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao reativar o webhook",
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Webhook reativado",
+	})
+}
+
+func (c *WebhookController) GetDeliveries(ctx *gin.Context) {
+	webhook, ok := c.findWebhook(ctx)
+	if !ok {
+		return
+	}
+
+	deliveries, err := c.webhooks.Deliveries(webhook.WebhookId)
+
+	if err != nil {
+		log.Printf("Erro ao listar entregas do webhook %s: %v", webhook.WebhookId, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Houve um erro ao listar as entregas",
+		})
+
+		return
+	}
+
+	if deliveries == nil {
+		deliveries = []models.WebhookDelivery{}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": deliveries,
+	})
+}
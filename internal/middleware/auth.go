@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"errors"
+	apiKeyService "go-web-socket/internal/services/APIKeyService"
+	authService "go-web-socket/internal/services/AuthService"
+	jwtService "go-web-socket/internal/services/JWTService"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const userKey = "user"
+
+type Authenticator interface {
+	Authenticate(tokenString string, meta authService.SessionMeta) (*jwtService.UserToken, error)
+}
+
+// BearerToken lê o token do header Authorization ou, para conexões
+// WebSocket abertas pelo navegador (que não enviam headers), do parâmetro
+// de query "token".
+func BearerToken(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimPrefix(header, "Bearer ")
+	}
+
+	return r.URL.Query().Get("token")
+}
+
+func Meta(ctx *gin.Context) authService.SessionMeta {
+	return authService.SessionMeta{
+		IP:        ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+	}
+}
+
+// Auth exige um token de acesso válido e não revogado e guarda as claims no
+// contexto, acessíveis por CurrentUser.
+func Auth(auth Authenticator) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authenticate(ctx, auth)
+	}
+}
+
+// AuthWithAPIKey é o Auth das rotas que também aceitam chaves de API. A
+// chave (no header X-API-Key ou no lugar do token) precisa ter o escopo
+// informado; tokens de acesso de usuários passam como no Auth.
+func AuthWithAPIKey(auth Authenticator, keys Authenticator, scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if key := APIKey(ctx.Request); key != "" {
+			if !authenticateWith(ctx, keys, key) {
+				return
+			}
+
+			if !CurrentUser(ctx).HasScope(scope) {
+				ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "A chave de API não tem o escopo " + scope})
+				return
+			}
+
+			ctx.Next()
+			return
+		}
+
+		authenticate(ctx, auth)
+	}
+}
+
+// APIKey devolve a chave de API da requisição, se houver.
+func APIKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+
+	if token := BearerToken(r); apiKeyService.IsAPIKey(token) {
+		return token
+	}
+
+	return ""
+}
+
+func authenticate(ctx *gin.Context, auth Authenticator) {
+	token := BearerToken(ctx.Request)
+	if token == "" {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Token não informado"})
+		return
+	}
+
+	if authenticateWith(ctx, auth, token) {
+		ctx.Next()
+	}
+}
+
+func authenticateWith(ctx *gin.Context, auth Authenticator, token string) bool {
+	user, err := auth.Authenticate(token, Meta(ctx))
+
+	if errors.Is(err, authService.ErrInvalidToken) || errors.Is(err, authService.ErrTokenRevoked) {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return false
+	}
+
+	if err != nil {
+		log.Printf("Erro ao autenticar requisição: %v", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "Erro ao validar o token"})
+		return false
+	}
+
+	ctx.Set(userKey, user)
+
+	return true
+}
+
+func CurrentUser(ctx *gin.Context) *jwtService.UserToken {
+	user, _ := ctx.Get(userKey)
+	claims, _ := user.(*jwtService.UserToken)
+
+	return claims
+}
+
+// RequireRole deve vir depois de Auth.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user := CurrentUser(ctx)
+
+		if user == nil || !user.HasRole(role) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "Acesso negado"})
+			return
+		}
+
+		ctx.Next()
+	}
+}
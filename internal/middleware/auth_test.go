@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	authService "go-web-socket/internal/services/AuthService"
+	jwtService "go-web-socket/internal/services/JWTService"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// staticAuth aceita só a credencial configurada e devolve a identidade dela.
+type staticAuth struct {
+	credential string
+	identity   *jwtService.UserToken
+}
+
+func (a staticAuth) Authenticate(credential string, meta authService.SessionMeta) (*jwtService.UserToken, error) {
+	if credential != a.credential {
+		return nil, authService.ErrInvalidToken
+	}
+
+	return a.identity, nil
+}
+
+func TestAuthWithAPIKeyChecksTheScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	users := staticAuth{credential: "user-token", identity: &jwtService.UserToken{UserId: "u1"}}
+	keys := staticAuth{credential: "gws_k_s", identity: &jwtService.UserToken{UserId: "u1", Scopes: []string{"rooms:read"}}}
+
+	router := gin.New()
+	router.GET("/rooms", AuthWithAPIKey(users, keys, "rooms:read"), func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+	router.GET("/users", AuthWithAPIKey(users, keys, "users:read"), func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	tests := []struct {
+		name, path, header, value string
+		want                      int
+	}{
+		{"chave com o escopo", "/rooms", "X-API-Key", "gws_k_s", http.StatusOK},
+		{"chave como bearer", "/rooms", "Authorization", "Bearer gws_k_s", http.StatusOK},
+		{"chave sem o escopo", "/users", "X-API-Key", "gws_k_s", http.StatusForbidden},
+		{"chave inválida", "/rooms", "X-API-Key", "gws_outra", http.StatusUnauthorized},
+		{"token de usuário", "/users", "Authorization", "Bearer user-token", http.StatusOK},
+		{"sem credencial", "/users", "", "", http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodGet, test.path, nil)
+		if test.header != "" {
+			request.Header.Set(test.header, test.value)
+		}
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		if recorder.Code != test.want {
+			t.Errorf("%s: esperava %d, veio %d", test.name, test.want, recorder.Code)
+		}
+	}
+}
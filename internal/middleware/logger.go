@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// hookPath casa o token dos webhooks de entrada: /hooks/<id>/<token>.
+var hookPath = regexp.MustCompile(`^(/hooks/[^/?]+/)[^/?]+`)
+
+// secretParams são os parâmetros de query que carregam credenciais: o token
+// das conexões WebSocket e o código do callback OIDC.
+var secretParams = []string{"token", "code"}
+
+const redacted = "REDACTED"
+
+// RedactPath esconde as credenciais que aparecem na URL, para que não fiquem
+// gravadas no log de acesso.
+func RedactPath(path string) string {
+	path = hookPath.ReplaceAllString(path, "${1}"+redacted)
+
+	rawPath, rawQuery, found := strings.Cut(path, "?")
+	if !found {
+		return path
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawPath + "?" + redacted
+	}
+
+	for _, name := range secretParams {
+		if query.Has(name) {
+			query.Set(name, redacted)
+		}
+	}
+
+	return rawPath + "?" + query.Encode()
+}
+
+// AccessLog é o logger padrão do gin com as credenciais da URL escondidas.
+func AccessLog() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+		var statusColor, methodColor, resetColor string
+		if param.IsOutputColor() {
+			statusColor = param.StatusCodeColor()
+			methodColor = param.MethodColor()
+			resetColor = param.ResetColor()
+		}
+
+		if param.Latency > time.Minute {
+			param.Latency = param.Latency.Truncate(time.Second)
+		}
+
+		return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			statusColor, param.StatusCode, resetColor,
+			param.Latency,
+			param.ClientIP,
+			methodColor, param.Method, resetColor,
+			RedactPath(param.Path),
+			param.ErrorMessage,
+		)
+	})
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import "testing"
+
+func TestRedactPath(t *testing.T) {
+	tests := []struct {
+		path, want string
+	}{
+		{"/hooks/abc123/s3cr3t", "/hooks/abc123/REDACTED"},
+		{"/hooks/abc123/s3cr3t?x=1", "/hooks/abc123/REDACTED?x=1"},
+		{"/ws/user/u1?token=eyJhbGci", "/ws/user/u1?token=REDACTED"},
+		{"/auth/oidc/callback?code=abc&state=xyz", "/auth/oidc/callback?code=REDACTED&state=xyz"},
+		{"/users?page=2", "/users?page=2"},
+		{"/rooms/r1/messages", "/rooms/r1/messages"},
+	}
+
+	for _, test := range tests {
+		if got := RedactPath(test.path); got != test.want {
+			t.Errorf("RedactPath(%q) = %q, esperado %q", test.path, got, test.want)
+		}
+	}
+}
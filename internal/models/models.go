@@ -7,20 +7,212 @@ import (
 )
 
 type User struct {
-	ID       uint      `gorm:"primaryKey" json:"id"`
-	Avatar   string    `gorm:"size:255" json:"avatar"`
-	UserId   string    `gorm:"size:255;unique" json:"user_id"`
-	Username string    `gorm:"size:255;unique" json:"username"`
-	Name     string    `gorm:"size:150" json:"name"`
-	Password string    `gorm:"size:150"`
-	Messages []Message `gorm:"foreignKey:UserID"`
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	Avatar   string `gorm:"size:255" json:"avatar"`
+	UserId   string `gorm:"size:255;unique" json:"user_id"`
+	Username string `gorm:"size:255;unique" json:"username"`
+	Name     string `gorm:"size:150" json:"name"`
+	// Email é obrigatório no cadastro e só vale depois de confirmado
+	// (Verified); é para ele que vão a verificação e a recuperação de senha.
+	// Fica nil (e não "", que violaria o índice único) só em bots, contas
+	// anteriores ao cadastro com e-mail e contas OIDC sem e-mail verificado.
+	Email    *string `gorm:"size:255;unique" json:"email,omitempty"`
+	Verified bool    `gorm:"not null;default:false" json:"verified"` // e-mail confirmado
+	Password string  `gorm:"size:150" json:"-"`
+	Role     string  `gorm:"size:20;not null;default:user" json:"role"`
+	Disabled bool    `gorm:"not null;default:false" json:"disabled"`
+	// Bot marca contas de integração: não têm senha e só se conectam com
+	// chave de API.
+	Bot bool `gorm:"not null;default:false" json:"bot"`
+	// TOTPSecret é preenchido no cadastro do 2FA e só passa a valer depois
+	// que TOTPEnabled é confirmado com o primeiro código.
+	TOTPSecret   string `gorm:"size:64" json:"-"`
+	TOTPEnabled  bool   `gorm:"not null;default:false" json:"totp_enabled"`
+	TOTPLastStep int64  `gorm:"not null;default:0" json:"-"` // impede reusar um código já aceito
+	// Status é o que o usuário escolheu (away, dnd ou invisible), com um texto
+	// opcional, até StatusExpiresAt. Vazio é o automático: online, ou ausente
+	// depois de um tempo sem atividade.
+	Status          string     `gorm:"size:16;not null;default:''" json:"-"`
+	StatusText      string     `gorm:"size:100" json:"-"`
+	StatusExpiresAt *time.Time `json:"-"`
+	LastSeenAt      *time.Time `json:"-"` // última desconexão visível para os outros
+	Messages        []Message  `gorm:"foreignKey:UserID"`
 }
 
 type Message struct {
-	ID        uint           `gorm:"primaryKey"`
-	UserID    uint           `gorm:"not null"`
-	User      User           `gorm:"constraint:OnDelete:CASCADE;"`
-	Content   string         `gorm:"type:text;not null"`
-	CreatedAt time.Time      `gorm:"autoCreateTime"`
-	DeletedAt gorm.DeletedAt `gorm:"index"`
+	ID          uint           `gorm:"primaryKey"`
+	UserID      uint           `gorm:"not null"`
+	User        User           `gorm:"constraint:OnDelete:CASCADE;"`
+	RecipientID *uint          `gorm:"index"`    // nil para mensagens de broadcast
+	RoomID      *uint          `gorm:"index"`    // preenchido nas mensagens de sala
+	Integration string         `gorm:"size:100"` // nome exibido nas mensagens de webhooks de entrada
+	Content     string         `gorm:"type:text;not null"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime"`
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+// StoredFile é o conteúdo físico de um arquivo, identificado pelo SHA-256.
+// RefCount conta quantos registros de File apontam para ele; em zero, o blob
+// está para ser apagado.
+type StoredFile struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Hash      string    `gorm:"size:64;unique;not null" json:"hash"`
+	Size      int64     `gorm:"not null" json:"size"`
+	MimeType  string    `gorm:"size:100" json:"mime_type"`
+	RefCount  int       `gorm:"not null;default:0" json:"ref_count"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// File é uma referência de um usuário para um StoredFile.
+type File struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	FileId    string    `gorm:"size:255;unique;not null" json:"file_id"`
+	UserId    string    `gorm:"size:255;index" json:"user_id"`
+	Filename  string    `gorm:"size:255" json:"filename"`
+	Hash      string    `gorm:"size:64;index;not null" json:"hash"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// RefreshToken guarda apenas o hash do token entregue ao cliente. Todos os
+// tokens gerados a partir de um mesmo login compartilham o FamilyId.
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey"`
+	TokenHash string     `gorm:"size:64;unique;not null"`
+	FamilyId  string     `gorm:"size:36;index;not null"`
+	UserId    string     `gorm:"size:255;index;not null"`
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time // preenchido quando o token é trocado por um novo
+	RevokedAt *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// Session é um login de um dispositivo. Tokens de acesso carregam o
+// SessionId, e os refresh tokens do mesmo login usam ele como FamilyId.
+type Session struct {
+	ID         uint       `gorm:"primaryKey" json:"-"`
+	SessionId  string     `gorm:"size:36;unique;not null" json:"id"`
+	UserId     string     `gorm:"size:255;index;not null" json:"-"`
+	UserAgent  string     `gorm:"size:255" json:"user_agent"`
+	IP         string     `gorm:"size:45" json:"ip"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	RevokedAt  *time.Time `json:"-"`
+}
+
+// RevokedToken é um token de acesso revogado antes de expirar, pelo jti.
+type RevokedToken struct {
+	Jti       string    `gorm:"primaryKey;size:36"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+}
+
+type RecoveryCode struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserId    string `gorm:"size:255;index;not null"`
+	CodeHash  string `gorm:"size:64;not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// ActionToken é um token de uso único enviado ao usuário por fora da API,
+// como o link de redefinição de senha. Só o hash é guardado.
+type ActionToken struct {
+	ID        uint       `gorm:"primaryKey"`
+	Purpose   string     `gorm:"size:32;not null"`
+	TokenHash string     `gorm:"size:64;unique;not null"`
+	UserId    string     `gorm:"size:255;index;not null"`
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time // preenchido quando o token é usado ou substituído
+	CreatedAt time.Time  `gorm:"autoCreateTime"`
+}
+
+// Identity liga um usuário a uma conta num provedor OpenID Connect, pelo
+// issuer e pelo subject (sub) do ID token.
+type Identity struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	Issuer    string    `gorm:"size:255;not null;uniqueIndex:idx_identities_issuer_subject" json:"issuer"`
+	Subject   string    `gorm:"size:255;not null;uniqueIndex:idx_identities_issuer_subject" json:"subject"`
+	UserId    string    `gorm:"size:255;index;not null" json:"-"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// APIKey é uma chave de integração de um usuário. A chave entregue tem o
+// formato gws_<KeyId>_<segredo>; só o hash do segredo é guardado.
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey" json:"-"`
+	KeyId      string     `gorm:"size:16;unique;not null" json:"id"`
+	SecretHash string     `gorm:"size:64;not null" json:"-"`
+	UserId     string     `gorm:"size:255;index;not null" json:"-"`
+	Name       string     `gorm:"size:100" json:"name"`
+	Scopes     string     `gorm:"size:255;not null" json:"-"` // separados por espaço
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `gorm:"size:45" json:"last_used_ip"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// Room é uma sala de conversa. Só os membros recebem as mensagens dela.
+type Room struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	RoomId    string    `gorm:"size:36;unique;not null" json:"id"`
+	Name      string    `gorm:"size:100;not null" json:"name"`
+	Topic     string    `gorm:"size:255" json:"topic"`
+	OwnerId   string    `gorm:"size:255;index;not null" json:"owner_id"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type RoomMember struct {
+	ID         uint       `gorm:"primaryKey" json:"-"`
+	RoomId     string     `gorm:"size:36;not null;uniqueIndex:idx_room_members_room_user" json:"room_id"`
+	UserId     string     `gorm:"size:255;not null;uniqueIndex:idx_room_members_room_user;index" json:"user_id"`
+	MutedUntil *time.Time `json:"muted_until,omitempty"` // silenciado pelo dono (/mute) até essa hora
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"joined_at"`
+}
+
+// Webhook é um endpoint externo que recebe eventos do chat. RoomId vazio
+// indica um webhook global, cadastrado por um administrador.
+type Webhook struct {
+	ID        uint   `gorm:"primaryKey" json:"-"`
+	WebhookId string `gorm:"size:36;unique;not null" json:"id"`
+	RoomId    string `gorm:"size:36;index" json:"room_id,omitempty"`
+	OwnerId   string `gorm:"size:255;not null" json:"owner_id"`
+	URL       string `gorm:"size:2048;not null" json:"url"`
+	Secret    string `gorm:"size:64;not null" json:"-"`  // chave do HMAC das entregas
+	Events    string `gorm:"size:255;not null" json:"-"` // separados por espaço
+	// Failures conta as entregas seguidas que esgotaram as tentativas.
+	Failures   int        `gorm:"not null;default:0" json:"failures"`
+	DisabledAt *time.Time `json:"disabled_at"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// WebhookDelivery é um evento a entregar a um webhook e o resultado da
+// última tentativa. Também serve de log das entregas.
+type WebhookDelivery struct {
+	ID            uint       `gorm:"primaryKey" json:"-"`
+	DeliveryId    string     `gorm:"size:36;unique;not null" json:"id"`
+	WebhookId     string     `gorm:"size:36;index;not null" json:"webhook_id"`
+	Event         string     `gorm:"size:50;not null" json:"event"`
+	Payload       string     `gorm:"type:text;not null" json:"-"`
+	Status        string     `gorm:"size:20;not null;index:idx_webhook_deliveries_due" json:"status"` // pending, succeeded ou failed
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"not null;index:idx_webhook_deliveries_due" json:"next_attempt_at"`
+	LastStatus    int        `json:"last_status_code,omitempty"`
+	LastError     string     `gorm:"size:255" json:"last_error,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// IncomingWebhook é uma URL secreta pela qual um sistema externo publica
+// mensagens numa sala ou, sem RoomId, para o próprio dono. As mensagens
+// saem em nome do dono, exibidas com o Name da integração.
+type IncomingWebhook struct {
+	ID         uint       `gorm:"primaryKey" json:"-"`
+	HookId     string     `gorm:"size:16;unique;not null" json:"id"`
+	TokenHash  string     `gorm:"size:64;not null" json:"-"`
+	RoomId     string     `gorm:"size:36;index" json:"room_id,omitempty"`
+	OwnerId    string     `gorm:"size:255;index;not null" json:"owner_id"`
+	Name       string     `gorm:"size:100;not null" json:"name"`
+	IconURL    string     `gorm:"size:2048" json:"icon_url,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
 }
@@ -0,0 +1,76 @@
+package apiKeyRepository
+
+import (
+	"errors"
+	"go-web-socket/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrAPIKeyNotFound = errors.New("chave de API não encontrada")
+
+type APIKeyRepository interface {
+	Create(key *models.APIKey) error
+	FindByKeyId(keyId string) (models.APIKey, error)
+	// ListByUser devolve as chaves do usuário, inclusive as revogadas.
+	ListByUser(userId string) ([]models.APIKey, error)
+	Revoke(keyId string, at time.Time) error
+	Touch(keyId, ip string, at time.Time) error
+}
+
+type GormAPIKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewGormAPIKeyRepository(db *gorm.DB) *GormAPIKeyRepository {
+	return &GormAPIKeyRepository{db: db}
+}
+
+func (r *GormAPIKeyRepository) Create(key *models.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *GormAPIKeyRepository) FindByKeyId(keyId string) (models.APIKey, error) {
+	var key models.APIKey
+
+	result := r.db.Where("key_id = ?", keyId).Limit(1).Find(&key)
+	if result.Error != nil {
+		return key, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return key, ErrAPIKeyNotFound
+	}
+
+	return key, nil
+}
+
+func (r *GormAPIKeyRepository) ListByUser(userId string) ([]models.APIKey, error) {
+	var keys []models.APIKey
+
+	err := r.db.Where("user_id = ?", userId).Order("id").Find(&keys).Error
+
+	return keys, err
+}
+
+func (r *GormAPIKeyRepository) Revoke(keyId string, at time.Time) error {
+	result := r.db.Model(&models.APIKey{}).
+		Where("key_id = ? AND revoked_at IS NULL", keyId).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+func (r *GormAPIKeyRepository) Touch(keyId, ip string, at time.Time) error {
+	return r.db.Model(&models.APIKey{}).
+		Where("key_id = ?", keyId).
+		Updates(map[string]interface{}{"last_used_at": at, "last_used_ip": ip}).Error
+}
@@ -0,0 +1,90 @@
+package apiKeyRepository
+
+import (
+	"go-web-socket/internal/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryAPIKeyRepository é a implementação em memória de APIKeyRepository.
+type MemoryAPIKeyRepository struct {
+	mu     sync.Mutex
+	nextId uint
+	keys   map[string]models.APIKey
+}
+
+func NewMemoryAPIKeyRepository() *MemoryAPIKeyRepository {
+	return &MemoryAPIKeyRepository{keys: make(map[string]models.APIKey)}
+}
+
+func (r *MemoryAPIKeyRepository) Create(key *models.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextId++
+	key.ID = r.nextId
+	key.CreatedAt = time.Now()
+	r.keys[key.KeyId] = *key
+
+	return nil
+}
+
+func (r *MemoryAPIKeyRepository) FindByKeyId(keyId string) (models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[keyId]
+	if !ok {
+		return key, ErrAPIKeyNotFound
+	}
+
+	return key, nil
+}
+
+func (r *MemoryAPIKeyRepository) ListByUser(userId string) ([]models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var keys []models.APIKey
+	for _, key := range r.keys {
+		if key.UserId == userId {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+
+	return keys, nil
+}
+
+func (r *MemoryAPIKeyRepository) Revoke(keyId string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[keyId]
+	if !ok || key.RevokedAt != nil {
+		return ErrAPIKeyNotFound
+	}
+
+	key.RevokedAt = &at
+	r.keys[keyId] = key
+
+	return nil
+}
+
+func (r *MemoryAPIKeyRepository) Touch(keyId, ip string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[keyId]
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+
+	key.LastUsedAt = &at
+	key.LastUsedIP = ip
+	r.keys[keyId] = key
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package actionTokenRepository
+
+import (
+	"errors"
+	"go-web-socket/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrActionTokenNotFound = errors.New("token não encontrado")
+
+type ActionTokenRepository interface {
+	Create(token *models.ActionToken) error
+	FindByHash(purpose, hash string) (models.ActionToken, error)
+	// FindLatest devolve o último token emitido para o usuário com esse propósito.
+	FindLatest(userId, purpose string) (models.ActionToken, error)
+	// MarkUsed marca o token como usado. Retorna false se ele já tinha sido
+	// usado, para que dois usos concorrentes não passem.
+	MarkUsed(id uint, at time.Time) (bool, error)
+	// InvalidateAll marca como usados os tokens pendentes do usuário com
+	// esse propósito.
+	InvalidateAll(userId, purpose string, at time.Time) error
+}
+
+type GormActionTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewGormActionTokenRepository(db *gorm.DB) *GormActionTokenRepository {
+	return &GormActionTokenRepository{db: db}
+}
+
+func (r *GormActionTokenRepository) Create(token *models.ActionToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *GormActionTokenRepository) FindByHash(purpose, hash string) (models.ActionToken, error) {
+	var token models.ActionToken
+
+	result := r.db.Where("purpose = ? AND token_hash = ?", purpose, hash).Limit(1).Find(&token)
+	if result.Error != nil {
+		return token, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return token, ErrActionTokenNotFound
+	}
+
+	return token, nil
+}
+
+func (r *GormActionTokenRepository) FindLatest(userId, purpose string) (models.ActionToken, error) {
+	var token models.ActionToken
+
+	result := r.db.Where("user_id = ? AND purpose = ?", userId, purpose).Order("id DESC").Limit(1).Find(&token)
+	if result.Error != nil {
+		return token, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return token, ErrActionTokenNotFound
+	}
+
+	return token, nil
+}
+
+func (r *GormActionTokenRepository) MarkUsed(id uint, at time.Time) (bool, error) {
+	result := r.db.Model(&models.ActionToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", at)
+
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *GormActionTokenRepository) InvalidateAll(userId, purpose string, at time.Time) error {
+	return r.db.Model(&models.ActionToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userId, purpose).
+		Update("used_at", at).Error
+}
@@ -0,0 +1,90 @@
+package actionTokenRepository
+
+import (
+	"go-web-socket/internal/models"
+	"sync"
+	"time"
+)
+
+// MemoryActionTokenRepository é a implementação em memória de ActionTokenRepository.
+type MemoryActionTokenRepository struct {
+	mu     sync.Mutex
+	nextId uint
+	tokens map[uint]models.ActionToken
+}
+
+func NewMemoryActionTokenRepository() *MemoryActionTokenRepository {
+	return &MemoryActionTokenRepository{tokens: make(map[uint]models.ActionToken)}
+}
+
+func (r *MemoryActionTokenRepository) Create(token *models.ActionToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextId++
+	token.ID = r.nextId
+	token.CreatedAt = time.Now()
+	r.tokens[token.ID] = *token
+
+	return nil
+}
+
+func (r *MemoryActionTokenRepository) FindByHash(purpose, hash string) (models.ActionToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.Purpose == purpose && token.TokenHash == hash {
+			return token, nil
+		}
+	}
+
+	return models.ActionToken{}, ErrActionTokenNotFound
+}
+
+func (r *MemoryActionTokenRepository) FindLatest(userId, purpose string) (models.ActionToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var latest models.ActionToken
+	for _, token := range r.tokens {
+		if token.UserId == userId && token.Purpose == purpose && token.ID > latest.ID {
+			latest = token
+		}
+	}
+
+	if latest.ID == 0 {
+		return latest, ErrActionTokenNotFound
+	}
+
+	return latest, nil
+}
+
+func (r *MemoryActionTokenRepository) MarkUsed(id uint, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok || token.UsedAt != nil {
+		return false, nil
+	}
+
+	token.UsedAt = &at
+	r.tokens[id] = token
+
+	return true, nil
+}
+
+func (r *MemoryActionTokenRepository) InvalidateAll(userId, purpose string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, token := range r.tokens {
+		if token.UserId == userId && token.Purpose == purpose && token.UsedAt == nil {
+			token.UsedAt = &at
+			r.tokens[id] = token
+		}
+	}
+
+	return nil
+}
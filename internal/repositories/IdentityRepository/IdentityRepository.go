@@ -0,0 +1,51 @@
+package identityRepository
+
+import (
+	"errors"
+	"go-web-socket/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrIdentityNotFound  = errors.New("identidade não encontrada")
+	ErrDuplicateIdentity = errors.New("identidade já vinculada")
+)
+
+type IdentityRepository interface {
+	FindBySubject(issuer, subject string) (models.Identity, error)
+	Create(identity *models.Identity) error
+}
+
+type GormIdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewGormIdentityRepository(db *gorm.DB) *GormIdentityRepository {
+	return &GormIdentityRepository{db: db}
+}
+
+func (r *GormIdentityRepository) FindBySubject(issuer, subject string) (models.Identity, error) {
+	var identity models.Identity
+
+	result := r.db.Where("issuer = ? AND subject = ?", issuer, subject).Limit(1).Find(&identity)
+	if result.Error != nil {
+		return identity, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return identity, ErrIdentityNotFound
+	}
+
+	return identity, nil
+}
+
+func (r *GormIdentityRepository) Create(identity *models.Identity) error {
+	err := r.db.Create(identity).Error
+
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrDuplicateIdentity
+	}
+
+	return err
+}
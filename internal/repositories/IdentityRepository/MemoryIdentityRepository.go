@@ -0,0 +1,49 @@
+package identityRepository
+
+import (
+	"go-web-socket/internal/models"
+	"sync"
+	"time"
+)
+
+// MemoryIdentityRepository é a implementação em memória de IdentityRepository.
+type MemoryIdentityRepository struct {
+	mu         sync.Mutex
+	nextId     uint
+	identities []models.Identity
+}
+
+func NewMemoryIdentityRepository() *MemoryIdentityRepository {
+	return &MemoryIdentityRepository{}
+}
+
+func (r *MemoryIdentityRepository) FindBySubject(issuer, subject string) (models.Identity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, identity := range r.identities {
+		if identity.Issuer == issuer && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+
+	return models.Identity{}, ErrIdentityNotFound
+}
+
+func (r *MemoryIdentityRepository) Create(identity *models.Identity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.identities {
+		if existing.Issuer == identity.Issuer && existing.Subject == identity.Subject {
+			return ErrDuplicateIdentity
+		}
+	}
+
+	r.nextId++
+	identity.ID = r.nextId
+	identity.CreatedAt = time.Now()
+	r.identities = append(r.identities, *identity)
+
+	return nil
+}
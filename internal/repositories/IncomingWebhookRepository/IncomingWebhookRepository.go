@@ -0,0 +1,81 @@
+package incomingWebhookRepository
+
+import (
+	"errors"
+	"go-web-socket/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrIncomingWebhookNotFound = errors.New("webhook de entrada não encontrado")
+
+type IncomingWebhookRepository interface {
+	Create(hook *models.IncomingWebhook) error
+	FindByHookId(hookId string) (models.IncomingWebhook, error)
+	ListByRoom(roomId string) ([]models.IncomingWebhook, error)
+	// ListPersonal devolve os webhooks do usuário que não são de sala.
+	ListPersonal(userId string) ([]models.IncomingWebhook, error)
+	Delete(hookId string) error
+	Touch(hookId string, at time.Time) error
+}
+
+type GormIncomingWebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewGormIncomingWebhookRepository(db *gorm.DB) *GormIncomingWebhookRepository {
+	return &GormIncomingWebhookRepository{db: db}
+}
+
+func (r *GormIncomingWebhookRepository) Create(hook *models.IncomingWebhook) error {
+	return r.db.Create(hook).Error
+}
+
+func (r *GormIncomingWebhookRepository) FindByHookId(hookId string) (models.IncomingWebhook, error) {
+	var hook models.IncomingWebhook
+
+	result := r.db.Where("hook_id = ?", hookId).Limit(1).Find(&hook)
+	if result.Error != nil {
+		return hook, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return hook, ErrIncomingWebhookNotFound
+	}
+
+	return hook, nil
+}
+
+func (r *GormIncomingWebhookRepository) ListByRoom(roomId string) ([]models.IncomingWebhook, error) {
+	var hooks []models.IncomingWebhook
+
+	err := r.db.Where("room_id = ?", roomId).Order("id").Find(&hooks).Error
+
+	return hooks, err
+}
+
+func (r *GormIncomingWebhookRepository) ListPersonal(userId string) ([]models.IncomingWebhook, error) {
+	var hooks []models.IncomingWebhook
+
+	err := r.db.Where("owner_id = ? AND room_id = ''", userId).Order("id").Find(&hooks).Error
+
+	return hooks, err
+}
+
+func (r *GormIncomingWebhookRepository) Delete(hookId string) error {
+	result := r.db.Where("hook_id = ?", hookId).Delete(&models.IncomingWebhook{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrIncomingWebhookNotFound
+	}
+
+	return nil
+}
+
+func (r *GormIncomingWebhookRepository) Touch(hookId string, at time.Time) error {
+	return r.db.Model(&models.IncomingWebhook{}).Where("hook_id = ?", hookId).Update("last_used_at", at).Error
+}
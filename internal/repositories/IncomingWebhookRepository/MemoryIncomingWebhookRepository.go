@@ -0,0 +1,92 @@
+package incomingWebhookRepository
+
+import (
+	"go-web-socket/internal/models"
+	"sync"
+	"time"
+)
+
+// MemoryIncomingWebhookRepository é a implementação em memória de IncomingWebhookRepository.
+type MemoryIncomingWebhookRepository struct {
+	mu     sync.Mutex
+	nextId uint
+	hooks  []models.IncomingWebhook
+}
+
+func NewMemoryIncomingWebhookRepository() *MemoryIncomingWebhookRepository {
+	return &MemoryIncomingWebhookRepository{}
+}
+
+func (r *MemoryIncomingWebhookRepository) Create(hook *models.IncomingWebhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextId++
+	hook.ID = r.nextId
+	hook.CreatedAt = time.Now()
+	r.hooks = append(r.hooks, *hook)
+
+	return nil
+}
+
+func (r *MemoryIncomingWebhookRepository) FindByHookId(hookId string) (models.IncomingWebhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, hook := range r.hooks {
+		if hook.HookId == hookId {
+			return hook, nil
+		}
+	}
+
+	return models.IncomingWebhook{}, ErrIncomingWebhookNotFound
+}
+
+func (r *MemoryIncomingWebhookRepository) filter(match func(models.IncomingWebhook) bool) []models.IncomingWebhook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var hooks []models.IncomingWebhook
+	for _, hook := range r.hooks {
+		if match(hook) {
+			hooks = append(hooks, hook)
+		}
+	}
+
+	return hooks
+}
+
+func (r *MemoryIncomingWebhookRepository) ListByRoom(roomId string) ([]models.IncomingWebhook, error) {
+	return r.filter(func(h models.IncomingWebhook) bool { return h.RoomId == roomId }), nil
+}
+
+func (r *MemoryIncomingWebhookRepository) ListPersonal(userId string) ([]models.IncomingWebhook, error) {
+	return r.filter(func(h models.IncomingWebhook) bool { return h.OwnerId == userId && h.RoomId == "" }), nil
+}
+
+func (r *MemoryIncomingWebhookRepository) Delete(hookId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, hook := range r.hooks {
+		if hook.HookId == hookId {
+			r.hooks = append(r.hooks[:i], r.hooks[i+1:]...)
+			return nil
+		}
+	}
+
+	return ErrIncomingWebhookNotFound
+}
+
+func (r *MemoryIncomingWebhookRepository) Touch(hookId string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.hooks {
+		if r.hooks[i].HookId == hookId {
+			r.hooks[i].LastUsedAt = &at
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package messageRepository
+
+import (
+	"go-web-socket/internal/models"
+	"sync"
+	"time"
+)
+
+// MemoryMessageRepository é a implementação em memória de MessageRepository.
+type MemoryMessageRepository struct {
+	mu       sync.RWMutex
+	messages []models.Message
+}
+
+func NewMemoryMessageRepository() *MemoryMessageRepository {
+	return &MemoryMessageRepository{}
+}
+
+func (r *MemoryMessageRepository) Create(message *models.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	message.ID = uint(len(r.messages) + 1)
+	if message.CreatedAt.IsZero() {
+		message.CreatedAt = time.Now()
+	}
+
+	r.messages = append(r.messages, *message)
+
+	return nil
+}
+
+func (r *MemoryMessageRepository) FindByID(id uint) (models.Message, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if id == 0 || int(id) > len(r.messages) || r.messages[id-1].DeletedAt.Valid {
+		return models.Message{}, ErrMessageNotFound
+	}
+
+	return r.messages[id-1], nil
+}
+
+func (r *MemoryMessageRepository) ListConversation(userA, userB uint, limit int) ([]models.Message, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var messages []models.Message
+
+	for i := len(r.messages) - 1; i >= 0 && len(messages) < limit; i-- {
+		message := r.messages[i]
+		if message.DeletedAt.Valid || message.RecipientID == nil {
+			continue
+		}
+
+		if (message.UserID == userA && *message.RecipientID == userB) ||
+			(message.UserID == userB && *message.RecipientID == userA) {
+			messages = append([]models.Message{message}, messages...)
+		}
+	}
+
+	return messages, nil
+}
+
+func (r *MemoryMessageRepository) ConversationPartners(userID uint) ([]uint, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var sent, received []uint
+
+	for _, message := range r.messages {
+		if message.DeletedAt.Valid || message.RecipientID == nil || message.UserID == *message.RecipientID {
+			continue
+		}
+
+		switch {
+		case message.UserID == userID:
+			sent = append(sent, *message.RecipientID)
+		case *message.RecipientID == userID:
+			received = append(received, message.UserID)
+		}
+	}
+
+	return mutualPartners(sent, received), nil
+}
+
+func (r *MemoryMessageRepository) RoomSenders(roomID uint) ([]uint, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[uint]bool)
+	senders := []uint{}
+
+	for _, message := range r.messages {
+		if message.DeletedAt.Valid || message.RoomID == nil || *message.RoomID != roomID || seen[message.UserID] {
+			continue
+		}
+
+		seen[message.UserID] = true
+		senders = append(senders, message.UserID)
+	}
+
+	return senders, nil
+}
+
+func (r *MemoryMessageRepository) Delete(id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if id == 0 || int(id) > len(r.messages) || r.messages[id-1].DeletedAt.Valid {
+		return ErrMessageNotFound
+	}
+
+	r.messages[id-1].DeletedAt.Time = time.Now()
+	r.messages[id-1].DeletedAt.Valid = true
+
+	return nil
+}
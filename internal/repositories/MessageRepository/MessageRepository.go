@@ -0,0 +1,130 @@
+package messageRepository
+
+import (
+	"errors"
+	"go-web-socket/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrMessageNotFound = errors.New("mensagem não encontrada")
+
+type MessageRepository interface {
+	Create(message *models.Message) error
+	FindByID(id uint) (models.Message, error)
+	// ListConversation devolve as últimas limit mensagens privadas trocadas
+	// entre os dois usuários, da mais antiga para a mais nova.
+	ListConversation(userA, userB uint, limit int) ([]models.Message, error)
+	// ConversationPartners devolve os ids de quem trocou mensagens privadas
+	// com o usuário nos dois sentidos: quem só enviou ou só recebeu não entra.
+	ConversationPartners(userID uint) ([]uint, error)
+	// RoomSenders devolve os ids de quem já enviou mensagens na sala.
+	RoomSenders(roomID uint) ([]uint, error)
+	Delete(id uint) error
+}
+
+type GormMessageRepository struct {
+	db *gorm.DB
+}
+
+func NewGormMessageRepository(db *gorm.DB) *GormMessageRepository {
+	return &GormMessageRepository{db: db}
+}
+
+func (r *GormMessageRepository) Create(message *models.Message) error {
+	return r.db.Omit("User").Create(message).Error
+}
+
+func (r *GormMessageRepository) FindByID(id uint) (models.Message, error) {
+	var message models.Message
+
+	err := r.db.First(&message, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return message, ErrMessageNotFound
+	}
+
+	return message, err
+}
+
+func (r *GormMessageRepository) ListConversation(userA, userB uint, limit int) ([]models.Message, error) {
+	var messages []models.Message
+
+	err := r.db.
+		Where("(user_id = ? AND recipient_id = ?) OR (user_id = ? AND recipient_id = ?)", userA, userB, userB, userA).
+		Order("id DESC").
+		Limit(limit).
+		Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+
+	return messages, nil
+}
+
+func (r *GormMessageRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.Message{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrMessageNotFound
+	}
+
+	return nil
+}
+
+func (r *GormMessageRepository) ConversationPartners(userID uint) ([]uint, error) {
+	var sent, received []uint
+
+	err := r.db.Model(&models.Message{}).
+		Distinct("recipient_id").
+		Where("user_id = ? AND recipient_id IS NOT NULL AND recipient_id <> ?", userID, userID).
+		Pluck("recipient_id", &sent).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.Model(&models.Message{}).
+		Distinct("user_id").
+		Where("recipient_id = ? AND user_id <> ?", userID, userID).
+		Pluck("user_id", &received).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return mutualPartners(sent, received), nil
+}
+
+func (r *GormMessageRepository) RoomSenders(roomID uint) ([]uint, error) {
+	var senders []uint
+
+	err := r.db.Model(&models.Message{}).
+		Distinct("user_id").
+		Where("room_id = ?", roomID).
+		Pluck("user_id", &senders).Error
+
+	return senders, err
+}
+
+// mutualPartners devolve, sem repetir, os ids que aparecem nas duas listas.
+func mutualPartners(sent, received []uint) []uint {
+	replied := make(map[uint]bool)
+	for _, id := range received {
+		replied[id] = true
+	}
+
+	partners := []uint{}
+	for _, id := range sent {
+		if replied[id] {
+			delete(replied, id)
+			partners = append(partners, id)
+		}
+	}
+
+	return partners
+}
@@ -0,0 +1,54 @@
+package recoveryCodeRepository
+
+import (
+	"go-web-socket/internal/models"
+	"sync"
+	"time"
+)
+
+// MemoryRecoveryCodeRepository é a implementação em memória de RecoveryCodeRepository.
+type MemoryRecoveryCodeRepository struct {
+	mu    sync.Mutex
+	codes map[string][]models.RecoveryCode
+}
+
+func NewMemoryRecoveryCodeRepository() *MemoryRecoveryCodeRepository {
+	return &MemoryRecoveryCodeRepository{codes: make(map[string][]models.RecoveryCode)}
+}
+
+func (r *MemoryRecoveryCodeRepository) Replace(userId string, hashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	codes := make([]models.RecoveryCode, 0, len(hashes))
+	for _, hash := range hashes {
+		codes = append(codes, models.RecoveryCode{UserId: userId, CodeHash: hash, CreatedAt: time.Now()})
+	}
+
+	r.codes[userId] = codes
+
+	return nil
+}
+
+func (r *MemoryRecoveryCodeRepository) Consume(userId, hash string, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, code := range r.codes[userId] {
+		if code.CodeHash == hash && code.UsedAt == nil {
+			r.codes[userId][i].UsedAt = &at
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *MemoryRecoveryCodeRepository) DeleteAll(userId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.codes, userId)
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package recoveryCodeRepository
+
+import (
+	"go-web-socket/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RecoveryCodeRepository interface {
+	// Replace apaga os códigos do usuário e grava os novos hashes.
+	Replace(userId string, hashes []string) error
+	// Consume marca o código como usado. Retorna false se ele não existe ou
+	// já foi usado.
+	Consume(userId, hash string, at time.Time) (bool, error)
+	DeleteAll(userId string) error
+}
+
+type GormRecoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewGormRecoveryCodeRepository(db *gorm.DB) *GormRecoveryCodeRepository {
+	return &GormRecoveryCodeRepository{db: db}
+}
+
+func (r *GormRecoveryCodeRepository) Replace(userId string, hashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userId).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]models.RecoveryCode, 0, len(hashes))
+		for _, hash := range hashes {
+			codes = append(codes, models.RecoveryCode{UserId: userId, CodeHash: hash})
+		}
+
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *GormRecoveryCodeRepository) Consume(userId, hash string, at time.Time) (bool, error) {
+	result := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userId, hash).
+		Update("used_at", at)
+
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *GormRecoveryCodeRepository) DeleteAll(userId string) error {
+	return r.db.Where("user_id = ?", userId).Delete(&models.RecoveryCode{}).Error
+}
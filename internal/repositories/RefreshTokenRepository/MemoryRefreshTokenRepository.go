@@ -0,0 +1,71 @@
+package refreshTokenRepository
+
+import (
+	"go-web-socket/internal/models"
+	"sync"
+	"time"
+)
+
+// MemoryRefreshTokenRepository é a implementação em memória de RefreshTokenRepository.
+type MemoryRefreshTokenRepository struct {
+	mu     sync.Mutex
+	tokens []models.RefreshToken
+}
+
+func NewMemoryRefreshTokenRepository() *MemoryRefreshTokenRepository {
+	return &MemoryRefreshTokenRepository{}
+}
+
+func (r *MemoryRefreshTokenRepository) Create(token *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token.ID = uint(len(r.tokens) + 1)
+	token.CreatedAt = time.Now()
+	r.tokens = append(r.tokens, *token)
+
+	return nil
+}
+
+func (r *MemoryRefreshTokenRepository) FindByHash(hash string) (models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.TokenHash == hash {
+			return token, nil
+		}
+	}
+
+	return models.RefreshToken{}, ErrRefreshTokenNotFound
+}
+
+func (r *MemoryRefreshTokenRepository) MarkUsed(id uint, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if id == 0 || int(id) > len(r.tokens) {
+		return false, ErrRefreshTokenNotFound
+	}
+
+	if r.tokens[id-1].UsedAt != nil {
+		return false, nil
+	}
+
+	r.tokens[id-1].UsedAt = &at
+
+	return true, nil
+}
+
+func (r *MemoryRefreshTokenRepository) RevokeFamily(familyId string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.tokens {
+		if r.tokens[i].FamilyId == familyId && r.tokens[i].RevokedAt == nil {
+			r.tokens[i].RevokedAt = &at
+		}
+	}
+
+	return nil
+}
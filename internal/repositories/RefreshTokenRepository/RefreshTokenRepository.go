@@ -0,0 +1,61 @@
+package refreshTokenRepository
+
+import (
+	"errors"
+	"go-web-socket/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrRefreshTokenNotFound = errors.New("refresh token não encontrado")
+
+type RefreshTokenRepository interface {
+	Create(token *models.RefreshToken) error
+	FindByHash(hash string) (models.RefreshToken, error)
+	// MarkUsed marca o token como trocado. Retorna false se ele já tinha sido
+	// usado, o que permite detectar duas trocas concorrentes do mesmo token.
+	MarkUsed(id uint, at time.Time) (bool, error)
+	RevokeFamily(familyId string, at time.Time) error
+}
+
+type GormRefreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewGormRefreshTokenRepository(db *gorm.DB) *GormRefreshTokenRepository {
+	return &GormRefreshTokenRepository{db: db}
+}
+
+func (r *GormRefreshTokenRepository) Create(token *models.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *GormRefreshTokenRepository) FindByHash(hash string) (models.RefreshToken, error) {
+	var token models.RefreshToken
+
+	result := r.db.Where("token_hash = ?", hash).Limit(1).Find(&token)
+	if result.Error != nil {
+		return token, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return token, ErrRefreshTokenNotFound
+	}
+
+	return token, nil
+}
+
+func (r *GormRefreshTokenRepository) MarkUsed(id uint, at time.Time) (bool, error) {
+	result := r.db.Model(&models.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", at)
+
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *GormRefreshTokenRepository) RevokeFamily(familyId string, at time.Time) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyId).
+		Update("revoked_at", at).Error
+}
@@ -0,0 +1,158 @@
+package roomRepository
+
+import (
+	"go-web-socket/internal/models"
+	"sync"
+	"time"
+)
+
+// MemoryRoomRepository é a implementação em memória de RoomRepository.
+type MemoryRoomRepository struct {
+	mu      sync.RWMutex
+	nextId  uint
+	rooms   []models.Room
+	members []models.RoomMember
+}
+
+func NewMemoryRoomRepository() *MemoryRoomRepository {
+	return &MemoryRoomRepository{}
+}
+
+func (r *MemoryRoomRepository) Create(room *models.Room) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextId++
+	room.ID = r.nextId
+	room.CreatedAt = time.Now()
+	r.rooms = append(r.rooms, *room)
+	r.members = append(r.members, models.RoomMember{RoomId: room.RoomId, UserId: room.OwnerId, CreatedAt: room.CreatedAt})
+
+	return nil
+}
+
+func (r *MemoryRoomRepository) FindByRoomId(roomId string) (models.Room, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, room := range r.rooms {
+		if room.RoomId == roomId {
+			return room, nil
+		}
+	}
+
+	return models.Room{}, ErrRoomNotFound
+}
+
+func (r *MemoryRoomRepository) ListByUser(userId string) ([]models.Room, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var rooms []models.Room
+	for _, room := range r.rooms {
+		if r.isMemberLocked(room.RoomId, userId) {
+			rooms = append(rooms, room)
+		}
+	}
+
+	return rooms, nil
+}
+
+func (r *MemoryRoomRepository) Members(roomId string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var members []string
+	for _, member := range r.members {
+		if member.RoomId == roomId {
+			members = append(members, member.UserId)
+		}
+	}
+
+	return members, nil
+}
+
+func (r *MemoryRoomRepository) isMemberLocked(roomId, userId string) bool {
+	for _, member := range r.members {
+		if member.RoomId == roomId && member.UserId == userId {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *MemoryRoomRepository) IsMember(roomId, userId string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.isMemberLocked(roomId, userId), nil
+}
+
+func (r *MemoryRoomRepository) FindMember(roomId, userId string) (models.RoomMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, member := range r.members {
+		if member.RoomId == roomId && member.UserId == userId {
+			return member, nil
+		}
+	}
+
+	return models.RoomMember{}, ErrNotMember
+}
+
+func (r *MemoryRoomRepository) AddMember(roomId, userId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.isMemberLocked(roomId, userId) {
+		return ErrAlreadyMember
+	}
+
+	r.members = append(r.members, models.RoomMember{RoomId: roomId, UserId: userId, CreatedAt: time.Now()})
+
+	return nil
+}
+
+func (r *MemoryRoomRepository) RemoveMember(roomId, userId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, member := range r.members {
+		if member.RoomId == roomId && member.UserId == userId {
+			r.members = append(r.members[:i], r.members[i+1:]...)
+			return nil
+		}
+	}
+
+	return ErrNotMember
+}
+
+func (r *MemoryRoomRepository) SetMutedUntil(roomId, userId string, until *time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, member := range r.members {
+		if member.RoomId == roomId && member.UserId == userId {
+			r.members[i].MutedUntil = until
+			return nil
+		}
+	}
+
+	return ErrNotMember
+}
+
+func (r *MemoryRoomRepository) SetTopic(roomId, topic string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, room := range r.rooms {
+		if room.RoomId == roomId {
+			r.rooms[i].Topic = topic
+			return nil
+		}
+	}
+
+	return ErrRoomNotFound
+}
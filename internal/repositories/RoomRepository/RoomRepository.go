@@ -0,0 +1,158 @@
+package roomRepository
+
+import (
+	"errors"
+	"go-web-socket/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRoomNotFound  = errors.New("sala não encontrada")
+	ErrAlreadyMember = errors.New("usuário já é membro da sala")
+	ErrNotMember     = errors.New("usuário não é membro da sala")
+)
+
+type RoomRepository interface {
+	// Create grava a sala e inclui o dono como primeiro membro.
+	Create(room *models.Room) error
+	FindByRoomId(roomId string) (models.Room, error)
+	// ListByUser devolve as salas das quais o usuário é membro.
+	ListByUser(userId string) ([]models.Room, error)
+	// Members devolve os user_id dos membros, na ordem de entrada.
+	Members(roomId string) ([]string, error)
+	IsMember(roomId, userId string) (bool, error)
+	FindMember(roomId, userId string) (models.RoomMember, error)
+	AddMember(roomId, userId string) error
+	RemoveMember(roomId, userId string) error
+	// SetMutedUntil silencia o membro até a hora informada; nil tira o silêncio.
+	SetMutedUntil(roomId, userId string, until *time.Time) error
+	SetTopic(roomId, topic string) error
+}
+
+type GormRoomRepository struct {
+	db *gorm.DB
+}
+
+func NewGormRoomRepository(db *gorm.DB) *GormRoomRepository {
+	return &GormRoomRepository{db: db}
+}
+
+func (r *GormRoomRepository) Create(room *models.Room) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(room).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.RoomMember{RoomId: room.RoomId, UserId: room.OwnerId}).Error
+	})
+}
+
+func (r *GormRoomRepository) FindByRoomId(roomId string) (models.Room, error) {
+	var room models.Room
+
+	result := r.db.Where("room_id = ?", roomId).Limit(1).Find(&room)
+	if result.Error != nil {
+		return room, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return room, ErrRoomNotFound
+	}
+
+	return room, nil
+}
+
+func (r *GormRoomRepository) ListByUser(userId string) ([]models.Room, error) {
+	var rooms []models.Room
+
+	err := r.db.
+		Where("room_id IN (?)", r.db.Model(&models.RoomMember{}).Select("room_id").Where("user_id = ?", userId)).
+		Order("id").
+		Find(&rooms).Error
+
+	return rooms, err
+}
+
+func (r *GormRoomRepository) Members(roomId string) ([]string, error) {
+	var members []string
+
+	err := r.db.Model(&models.RoomMember{}).Where("room_id = ?", roomId).Order("id").Pluck("user_id", &members).Error
+
+	return members, err
+}
+
+func (r *GormRoomRepository) IsMember(roomId, userId string) (bool, error) {
+	var count int64
+
+	err := r.db.Model(&models.RoomMember{}).Where("room_id = ? AND user_id = ?", roomId, userId).Count(&count).Error
+
+	return count > 0, err
+}
+
+func (r *GormRoomRepository) FindMember(roomId, userId string) (models.RoomMember, error) {
+	var member models.RoomMember
+
+	result := r.db.Where("room_id = ? AND user_id = ?", roomId, userId).Limit(1).Find(&member)
+	if result.Error != nil {
+		return member, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return member, ErrNotMember
+	}
+
+	return member, nil
+}
+
+func (r *GormRoomRepository) AddMember(roomId, userId string) error {
+	err := r.db.Create(&models.RoomMember{RoomId: roomId, UserId: userId}).Error
+
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrAlreadyMember
+	}
+
+	return err
+}
+
+func (r *GormRoomRepository) RemoveMember(roomId, userId string) error {
+	result := r.db.Where("room_id = ? AND user_id = ?", roomId, userId).Delete(&models.RoomMember{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrNotMember
+	}
+
+	return nil
+}
+
+func (r *GormRoomRepository) SetMutedUntil(roomId, userId string, until *time.Time) error {
+	result := r.db.Model(&models.RoomMember{}).
+		Where("room_id = ? AND user_id = ?", roomId, userId).
+		Update("muted_until", until)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrNotMember
+	}
+
+	return nil
+}
+
+func (r *GormRoomRepository) SetTopic(roomId, topic string) error {
+	result := r.db.Model(&models.Room{}).Where("room_id = ?", roomId).Update("topic", topic)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrRoomNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package sessionRepository
+
+import (
+	"go-web-socket/internal/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemorySessionRepository é a implementação em memória de SessionRepository.
+type MemorySessionRepository struct {
+	mu       sync.Mutex
+	sessions map[string]models.Session
+	revoked  map[string]time.Time
+}
+
+func NewMemorySessionRepository() *MemorySessionRepository {
+	return &MemorySessionRepository{
+		sessions: make(map[string]models.Session),
+		revoked:  make(map[string]time.Time),
+	}
+}
+
+func (r *MemorySessionRepository) Create(session *models.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session.ID = uint(len(r.sessions) + 1)
+	session.CreatedAt = time.Now()
+	r.sessions[session.SessionId] = *session
+
+	return nil
+}
+
+func (r *MemorySessionRepository) FindBySessionId(sessionId string) (models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[sessionId]
+	if !ok {
+		return session, ErrSessionNotFound
+	}
+
+	return session, nil
+}
+
+func (r *MemorySessionRepository) ListActive(userId string) ([]models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sessions []models.Session
+	for _, session := range r.sessions {
+		if session.UserId == userId && session.RevokedAt == nil {
+			sessions = append(sessions, session)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+
+	return sessions, nil
+}
+
+func (r *MemorySessionRepository) Touch(sessionId, ip, userAgent string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[sessionId]
+	if !ok {
+		return nil
+	}
+
+	session.IP = ip
+	session.UserAgent = userAgent
+	session.LastSeenAt = at
+	r.sessions[sessionId] = session
+
+	return nil
+}
+
+func (r *MemorySessionRepository) Revoke(sessionId string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[sessionId]
+	if !ok || session.RevokedAt != nil {
+		return ErrSessionNotFound
+	}
+
+	session.RevokedAt = &at
+	r.sessions[sessionId] = session
+
+	return nil
+}
+
+func (r *MemorySessionRepository) RevokeAllExcept(userId, keep string, at time.Time) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ids []string
+	for id, session := range r.sessions {
+		if session.UserId == userId && id != keep && session.RevokedAt == nil {
+			session.RevokedAt = &at
+			r.sessions[id] = session
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
+func (r *MemorySessionRepository) RevokeToken(jti string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.revoked[jti] = expiresAt
+
+	return nil
+}
+
+func (r *MemorySessionRepository) IsTokenRevoked(jti string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.revoked[jti]
+
+	return ok, nil
+}
+
+func (r *MemorySessionRepository) DeleteExpiredTokens(now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for jti, expiresAt := range r.revoked {
+		if expiresAt.Before(now) {
+			delete(r.revoked, jti)
+		}
+	}
+
+	return nil
+}
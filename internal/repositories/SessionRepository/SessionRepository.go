@@ -0,0 +1,128 @@
+package sessionRepository
+
+import (
+	"errors"
+	"go-web-socket/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrSessionNotFound = errors.New("sessão não encontrada")
+
+type SessionRepository interface {
+	Create(session *models.Session) error
+	FindBySessionId(sessionId string) (models.Session, error)
+	// ListActive devolve as sessões não revogadas do usuário, da mais recente
+	// para a mais antiga.
+	ListActive(userId string) ([]models.Session, error)
+	Touch(sessionId, ip, userAgent string, at time.Time) error
+	Revoke(sessionId string, at time.Time) error
+	// RevokeAllExcept revoga as sessões ativas do usuário, menos keep (que
+	// pode ser vazio), e devolve os ids revogados.
+	RevokeAllExcept(userId, keep string, at time.Time) ([]string, error)
+
+	RevokeToken(jti string, expiresAt time.Time) error
+	IsTokenRevoked(jti string) (bool, error)
+	DeleteExpiredTokens(now time.Time) error
+}
+
+type GormSessionRepository struct {
+	db *gorm.DB
+}
+
+func NewGormSessionRepository(db *gorm.DB) *GormSessionRepository {
+	return &GormSessionRepository{db: db}
+}
+
+func (r *GormSessionRepository) Create(session *models.Session) error {
+	return r.db.Create(session).Error
+}
+
+func (r *GormSessionRepository) FindBySessionId(sessionId string) (models.Session, error) {
+	var session models.Session
+
+	result := r.db.Where("session_id = ?", sessionId).Limit(1).Find(&session)
+	if result.Error != nil {
+		return session, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return session, ErrSessionNotFound
+	}
+
+	return session, nil
+}
+
+func (r *GormSessionRepository) ListActive(userId string) ([]models.Session, error) {
+	var sessions []models.Session
+
+	err := r.db.
+		Where("user_id = ? AND revoked_at IS NULL", userId).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+
+	return sessions, err
+}
+
+func (r *GormSessionRepository) Touch(sessionId, ip, userAgent string, at time.Time) error {
+	return r.db.Model(&models.Session{}).
+		Where("session_id = ?", sessionId).
+		Updates(map[string]interface{}{
+			"ip":           ip,
+			"user_agent":   userAgent,
+			"last_seen_at": at,
+		}).Error
+}
+
+func (r *GormSessionRepository) Revoke(sessionId string, at time.Time) error {
+	result := r.db.Model(&models.Session{}).
+		Where("session_id = ? AND revoked_at IS NULL", sessionId).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func (r *GormSessionRepository) RevokeAllExcept(userId, keep string, at time.Time) ([]string, error) {
+	var ids []string
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Session{}).
+			Where("user_id = ? AND session_id <> ? AND revoked_at IS NULL", userId, keep).
+			Pluck("session_id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		return tx.Model(&models.Session{}).
+			Where("session_id IN ?", ids).
+			Update("revoked_at", at).Error
+	})
+
+	return ids, err
+}
+
+func (r *GormSessionRepository) RevokeToken(jti string, expiresAt time.Time) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.RevokedToken{Jti: jti, ExpiresAt: expiresAt}).Error
+}
+
+func (r *GormSessionRepository) IsTokenRevoked(jti string) (bool, error) {
+	var count int64
+
+	err := r.db.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error
+
+	return count > 0, err
+}
+
+func (r *GormSessionRepository) DeleteExpiredTokens(now time.Time) error {
+	return r.db.Where("expires_at < ?", now).Delete(&models.RevokedToken{}).Error
+}
@@ -0,0 +1,274 @@
+package userRepository
+
+import (
+	"go-web-socket/internal/models"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryUserRepository é a implementação em memória de UserRepository.
+type MemoryUserRepository struct {
+	mu     sync.RWMutex
+	nextId uint
+	users  map[uint]models.User
+}
+
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{users: make(map[uint]models.User)}
+}
+
+func (r *MemoryUserRepository) findLocked(match func(models.User) bool) (models.User, error) {
+	for _, user := range r.users {
+		if match(user) {
+			return user, nil
+		}
+	}
+
+	return models.User{}, ErrUserNotFound
+}
+
+func (r *MemoryUserRepository) FindByUsername(username string) (models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.findLocked(func(u models.User) bool { return u.Username == username })
+}
+
+func (r *MemoryUserRepository) FindByUserId(userId string) (models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.findLocked(func(u models.User) bool { return u.UserId == userId })
+}
+
+func (r *MemoryUserRepository) FindByEmail(email string) (models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.findLocked(func(u models.User) bool { return u.Email != nil && *u.Email == email })
+}
+
+func (r *MemoryUserRepository) List() ([]models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]models.User, 0, len(r.users))
+	for _, user := range r.users {
+		users = append(users, user)
+	}
+
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	return users, nil
+}
+
+func (r *MemoryUserRepository) matches(filter UserFilter) []models.User {
+	users, _ := r.List()
+
+	now := time.Now()
+	search := strings.ToLower(filter.Search)
+
+	var matches []models.User
+	for _, user := range users {
+		if search != "" &&
+			!strings.Contains(strings.ToLower(user.Name), search) &&
+			!strings.Contains(strings.ToLower(user.Username), search) {
+			continue
+		}
+
+		if filter.Role != "" && user.Role != filter.Role {
+			continue
+		}
+
+		if filter.Disabled != nil && user.Disabled != *filter.Disabled {
+			continue
+		}
+
+		if filter.UserIds != nil && !slices.Contains(filter.UserIds, user.UserId) {
+			continue
+		}
+
+		if filter.IDs != nil && !slices.Contains(filter.IDs, user.ID) {
+			continue
+		}
+
+		if filter.HideStatus != "" && user.Status == filter.HideStatus &&
+			(user.StatusExpiresAt == nil || user.StatusExpiresAt.After(now)) {
+			continue
+		}
+
+		matches = append(matches, user)
+	}
+
+	return matches
+}
+
+func (r *MemoryUserRepository) Search(filter UserFilter) ([]models.User, error) {
+	matches := r.matches(filter)
+
+	if filter.Offset >= len(matches) {
+		return nil, nil
+	}
+
+	matches = matches[filter.Offset:]
+
+	if filter.Limit > 0 && filter.Limit < len(matches) {
+		matches = matches[:filter.Limit]
+	}
+
+	return matches, nil
+}
+
+func (r *MemoryUserRepository) Count(filter UserFilter) (int64, error) {
+	return int64(len(r.matches(filter))), nil
+}
+
+func (r *MemoryUserRepository) Create(user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Username == user.Username || existing.UserId == user.UserId ||
+			(user.Email != nil && existing.Email != nil && *existing.Email == *user.Email) {
+			return ErrDuplicateUser
+		}
+	}
+
+	if user.Role == "" {
+		user.Role = "user" // mesmo default da coluna no banco
+	}
+
+	r.nextId++
+	user.ID = r.nextId
+	r.users[user.ID] = *user
+
+	return nil
+}
+
+func (r *MemoryUserRepository) Update(userId string, data models.User) (models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, err := r.findLocked(func(u models.User) bool { return u.UserId == userId })
+	if err != nil {
+		return user, err
+	}
+
+	mergeNonZero(&user, data)
+	r.users[user.ID] = user
+
+	return user, nil
+}
+
+func (r *MemoryUserRepository) set(userId string, apply func(*models.User)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, err := r.findLocked(func(u models.User) bool { return u.UserId == userId })
+	if err != nil {
+		return err
+	}
+
+	apply(&user)
+	r.users[user.ID] = user
+
+	return nil
+}
+
+func (r *MemoryUserRepository) SetRole(userId, role string) error {
+	return r.set(userId, func(u *models.User) { u.Role = role })
+}
+
+func (r *MemoryUserRepository) SetPassword(userId, hash string) error {
+	return r.set(userId, func(u *models.User) { u.Password = hash })
+}
+
+func (r *MemoryUserRepository) ReplacePasswordHash(userId, oldHash, newHash string) (bool, error) {
+	replaced := false
+
+	err := r.set(userId, func(u *models.User) {
+		if u.Password == oldHash {
+			u.Password = newHash
+			replaced = true
+		}
+	})
+
+	return replaced, err
+}
+
+func (r *MemoryUserRepository) SetDisabled(userId string, disabled bool) error {
+	return r.set(userId, func(u *models.User) { u.Disabled = disabled })
+}
+
+func (r *MemoryUserRepository) SetVerified(userId string, verified bool) error {
+	return r.set(userId, func(u *models.User) { u.Verified = verified })
+}
+
+func (r *MemoryUserRepository) SetTOTP(userId, secret string, enabled bool) error {
+	return r.set(userId, func(u *models.User) {
+		u.TOTPSecret = secret
+		u.TOTPEnabled = enabled
+		u.TOTPLastStep = 0
+	})
+}
+
+func (r *MemoryUserRepository) AdvanceTOTPStep(userId string, step int64) (bool, error) {
+	advanced := false
+
+	err := r.set(userId, func(u *models.User) {
+		if u.TOTPLastStep < step {
+			u.TOTPLastStep = step
+			advanced = true
+		}
+	})
+
+	return advanced, err
+}
+
+func (r *MemoryUserRepository) SetStatus(userId, status, text string, expiresAt *time.Time) error {
+	return r.set(userId, func(u *models.User) {
+		u.Status = status
+		u.StatusText = text
+		u.StatusExpiresAt = expiresAt
+	})
+}
+
+func (r *MemoryUserRepository) ClearExpiredStatus(userId string, now time.Time) (bool, error) {
+	cleared := false
+
+	err := r.set(userId, func(u *models.User) {
+		if u.StatusExpiresAt != nil && !u.StatusExpiresAt.After(now) {
+			u.Status = ""
+			u.StatusText = ""
+			u.StatusExpiresAt = nil
+			cleared = true
+		}
+	})
+
+	return cleared, err
+}
+
+func (r *MemoryUserRepository) SetLastSeen(userId string, at time.Time) error {
+	return r.set(userId, func(u *models.User) { u.LastSeenAt = &at })
+}
+
+// mergeNonZero copia para dst os campos não vazios de src, imitando o
+// Updates do GORM com struct.
+func mergeNonZero(dst *models.User, src models.User) {
+	dstValue := reflect.ValueOf(dst).Elem()
+	srcValue := reflect.ValueOf(src)
+
+	for i := 0; i < srcValue.NumField(); i++ {
+		field := srcValue.Field(i)
+
+		if dstValue.Type().Field(i).Name == "ID" || field.IsZero() {
+			continue
+		}
+
+		dstValue.Field(i).Set(field)
+	}
+}
@@ -0,0 +1,281 @@
+package userRepository
+
+import (
+	"errors"
+	"go-web-socket/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUserNotFound  = errors.New("usuário não encontrado")
+	ErrDuplicateUser = errors.New("usuário já existe")
+)
+
+type UserFilter struct {
+	Search   string // trecho do nome ou do username
+	Role     string
+	Disabled *bool
+	UserIds  []string // só esses usuários, se não for nil
+	IDs      []uint   // só esses ids internos, se não for nil
+	// HideStatus deixa de fora quem escolheu esse status e ele ainda não
+	// venceu (ex.: invisible)
+	HideStatus string
+	Limit      int // 0 é sem limite
+	Offset     int
+}
+
+type UserRepository interface {
+	FindByUsername(username string) (models.User, error)
+	FindByUserId(userId string) (models.User, error)
+	FindByEmail(email string) (models.User, error)
+	List() ([]models.User, error)
+	Search(filter UserFilter) ([]models.User, error)
+	// Count conta os usuários do filtro, sem considerar Limit e Offset.
+	Count(filter UserFilter) (int64, error)
+	Create(user *models.User) error
+	// Update grava apenas os campos não vazios de data, como o Updates do GORM.
+	Update(userId string, data models.User) (models.User, error)
+	SetRole(userId, role string) error
+	SetPassword(userId, hash string) error
+	// ReplacePasswordHash troca o hash só se ele ainda for oldHash, para que
+	// um rehash no login não desfaça uma troca de senha concorrente.
+	ReplacePasswordHash(userId, oldHash, newHash string) (bool, error)
+	SetDisabled(userId string, disabled bool) error
+	SetVerified(userId string, verified bool) error
+	SetTOTP(userId, secret string, enabled bool) error
+	// AdvanceTOTPStep grava o passo do último código TOTP aceito. Retorna
+	// false se o passo não for maior que o último, ou seja, código reusado.
+	AdvanceTOTPStep(userId string, step int64) (bool, error)
+	SetStatus(userId, status, text string, expiresAt *time.Time) error
+	// ClearExpiredStatus volta ao status automático se o status escolhido
+	// venceu até now. Retorna false se não havia o que limpar, para que
+	// apenas um nó anuncie o vencimento.
+	ClearExpiredStatus(userId string, now time.Time) (bool, error)
+	SetLastSeen(userId string, at time.Time) error
+}
+
+type GormUserRepository struct {
+	db *gorm.DB
+}
+
+func NewGormUserRepository(db *gorm.DB) *GormUserRepository {
+	return &GormUserRepository{db: db}
+}
+
+func (r *GormUserRepository) find(query string, args ...interface{}) (models.User, error) {
+	var user models.User
+
+	result := r.db.Where(query, args...).Limit(1).Find(&user)
+	if result.Error != nil {
+		return user, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return user, ErrUserNotFound
+	}
+
+	return user, nil
+}
+
+func (r *GormUserRepository) FindByUsername(username string) (models.User, error) {
+	return r.find("username = ?", username)
+}
+
+func (r *GormUserRepository) FindByUserId(userId string) (models.User, error) {
+	return r.find("user_id = ?", userId)
+}
+
+func (r *GormUserRepository) FindByEmail(email string) (models.User, error) {
+	return r.find("email = ?", email)
+}
+
+func (r *GormUserRepository) List() ([]models.User, error) {
+	var users []models.User
+
+	err := r.db.Order("id").Find(&users).Error
+
+	return users, err
+}
+
+func (r *GormUserRepository) filtered(filter UserFilter) *gorm.DB {
+	query := r.db.Model(&models.User{})
+
+	if filter.Search != "" {
+		pattern := "%" + filter.Search + "%"
+		query = query.Where("name LIKE ? OR username LIKE ?", pattern, pattern)
+	}
+
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+
+	if filter.Disabled != nil {
+		query = query.Where("disabled = ?", *filter.Disabled)
+	}
+
+	if filter.UserIds != nil {
+		query = query.Where("user_id IN ?", filter.UserIds)
+	}
+
+	if filter.IDs != nil {
+		query = query.Where("id IN ?", filter.IDs)
+	}
+
+	if filter.HideStatus != "" {
+		query = query.Where("NOT (status = ? AND (status_expires_at IS NULL OR status_expires_at > ?))", filter.HideStatus, time.Now())
+	}
+
+	return query
+}
+
+func (r *GormUserRepository) Search(filter UserFilter) ([]models.User, error) {
+	var users []models.User
+
+	query := r.filtered(filter).Order("id")
+
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	err := query.Find(&users).Error
+
+	return users, err
+}
+
+func (r *GormUserRepository) Count(filter UserFilter) (int64, error) {
+	var total int64
+
+	err := r.filtered(filter).Count(&total).Error
+
+	return total, err
+}
+
+func (r *GormUserRepository) Create(user *models.User) error {
+	err := r.db.Create(user).Error
+
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrDuplicateUser
+	}
+
+	return err
+}
+
+func (r *GormUserRepository) Update(userId string, data models.User) (models.User, error) {
+	user, err := r.FindByUserId(userId)
+	if err != nil {
+		return user, err
+	}
+
+	if err := r.db.Model(&user).Updates(&data).Error; err != nil {
+		return user, err
+	}
+
+	return r.FindByUserId(userId)
+}
+
+func (r *GormUserRepository) updateColumn(userId, column string, value interface{}) error {
+	result := r.db.Model(&models.User{}).Where("user_id = ?", userId).Update(column, value)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		if _, err := r.FindByUserId(userId); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *GormUserRepository) SetRole(userId, role string) error {
+	return r.updateColumn(userId, "role", role)
+}
+
+func (r *GormUserRepository) SetPassword(userId, hash string) error {
+	return r.updateColumn(userId, "password", hash)
+}
+
+func (r *GormUserRepository) ReplacePasswordHash(userId, oldHash, newHash string) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("user_id = ? AND password = ?", userId, oldHash).
+		Update("password", newHash)
+
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *GormUserRepository) SetDisabled(userId string, disabled bool) error {
+	return r.updateColumn(userId, "disabled", disabled)
+}
+
+func (r *GormUserRepository) SetVerified(userId string, verified bool) error {
+	return r.updateColumn(userId, "verified", verified)
+}
+
+func (r *GormUserRepository) SetTOTP(userId, secret string, enabled bool) error {
+	result := r.db.Model(&models.User{}).Where("user_id = ?", userId).Updates(map[string]interface{}{
+		"totp_secret":    secret,
+		"totp_enabled":   enabled,
+		"totp_last_step": 0,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		if _, err := r.FindByUserId(userId); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *GormUserRepository) AdvanceTOTPStep(userId string, step int64) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("user_id = ? AND totp_last_step < ?", userId, step).
+		Update("totp_last_step", step)
+
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *GormUserRepository) SetStatus(userId, status, text string, expiresAt *time.Time) error {
+	result := r.db.Model(&models.User{}).Where("user_id = ?", userId).Updates(map[string]interface{}{
+		"status":            status,
+		"status_text":       text,
+		"status_expires_at": expiresAt,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		if _, err := r.FindByUserId(userId); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *GormUserRepository) ClearExpiredStatus(userId string, now time.Time) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("user_id = ? AND status_expires_at <= ?", userId, now).
+		Updates(map[string]interface{}{
+			"status":            "",
+			"status_text":       "",
+			"status_expires_at": nil,
+		})
+
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *GormUserRepository) SetLastSeen(userId string, at time.Time) error {
+	return r.updateColumn(userId, "last_seen_at", at)
+}
@@ -0,0 +1,226 @@
+package webhookRepository
+
+import (
+	"go-web-socket/internal/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryWebhookRepository é a implementação em memória de WebhookRepository.
+type MemoryWebhookRepository struct {
+	mu         sync.Mutex
+	nextId     uint
+	webhooks   []models.Webhook
+	deliveries []models.WebhookDelivery
+}
+
+func NewMemoryWebhookRepository() *MemoryWebhookRepository {
+	return &MemoryWebhookRepository{}
+}
+
+func (r *MemoryWebhookRepository) Create(webhook *models.Webhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextId++
+	webhook.ID = r.nextId
+	webhook.CreatedAt = time.Now()
+	r.webhooks = append(r.webhooks, *webhook)
+
+	return nil
+}
+
+func (r *MemoryWebhookRepository) indexLocked(webhookId string) int {
+	for i, webhook := range r.webhooks {
+		if webhook.WebhookId == webhookId {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (r *MemoryWebhookRepository) FindByWebhookId(webhookId string) (models.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexLocked(webhookId)
+	if i < 0 {
+		return models.Webhook{}, ErrWebhookNotFound
+	}
+
+	return r.webhooks[i], nil
+}
+
+func (r *MemoryWebhookRepository) List(roomId string) ([]models.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var webhooks []models.Webhook
+	for _, webhook := range r.webhooks {
+		if webhook.RoomId == roomId {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+
+	return webhooks, nil
+}
+
+func (r *MemoryWebhookRepository) ListActive(roomId string) ([]models.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var webhooks []models.Webhook
+	for _, webhook := range r.webhooks {
+		if webhook.DisabledAt == nil && (webhook.RoomId == "" || webhook.RoomId == roomId) {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+
+	return webhooks, nil
+}
+
+func (r *MemoryWebhookRepository) Delete(webhookId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexLocked(webhookId)
+	if i < 0 {
+		return ErrWebhookNotFound
+	}
+
+	r.webhooks = append(r.webhooks[:i], r.webhooks[i+1:]...)
+
+	deliveries := r.deliveries[:0]
+	for _, delivery := range r.deliveries {
+		if delivery.WebhookId != webhookId {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	r.deliveries = deliveries
+
+	return nil
+}
+
+func (r *MemoryWebhookRepository) Disable(webhookId string, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexLocked(webhookId)
+	if i < 0 || r.webhooks[i].DisabledAt != nil {
+		return false, nil
+	}
+
+	r.webhooks[i].DisabledAt = &at
+
+	return true, nil
+}
+
+func (r *MemoryWebhookRepository) Enable(webhookId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexLocked(webhookId)
+	if i < 0 {
+		return ErrWebhookNotFound
+	}
+
+	r.webhooks[i].DisabledAt = nil
+	r.webhooks[i].Failures = 0
+
+	return nil
+}
+
+func (r *MemoryWebhookRepository) RecordFailure(webhookId string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexLocked(webhookId)
+	if i < 0 {
+		return 0, ErrWebhookNotFound
+	}
+
+	r.webhooks[i].Failures++
+
+	return r.webhooks[i].Failures, nil
+}
+
+func (r *MemoryWebhookRepository) ResetFailures(webhookId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if i := r.indexLocked(webhookId); i >= 0 {
+		r.webhooks[i].Failures = 0
+	}
+
+	return nil
+}
+
+func (r *MemoryWebhookRepository) CreateDelivery(delivery *models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextId++
+	delivery.ID = r.nextId
+	delivery.CreatedAt = time.Now()
+	r.deliveries = append(r.deliveries, *delivery)
+
+	return nil
+}
+
+func (r *MemoryWebhookRepository) ClaimDue(now, until time.Time, limit int) ([]models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var due []int
+	for i, delivery := range r.deliveries {
+		if delivery.Status == StatusPending && !delivery.NextAttemptAt.After(now) {
+			due = append(due, i)
+		}
+	}
+
+	sort.Slice(due, func(a, b int) bool {
+		return r.deliveries[due[a]].NextAttemptAt.Before(r.deliveries[due[b]].NextAttemptAt)
+	})
+
+	var claimed []models.WebhookDelivery
+	for _, i := range due {
+		if len(claimed) == limit {
+			break
+		}
+
+		r.deliveries[i].NextAttemptAt = until
+		claimed = append(claimed, r.deliveries[i])
+	}
+
+	return claimed, nil
+}
+
+func (r *MemoryWebhookRepository) SaveDelivery(delivery *models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.deliveries {
+		if r.deliveries[i].ID == delivery.ID {
+			r.deliveries[i] = *delivery
+			return nil
+		}
+	}
+
+	return ErrWebhookNotFound
+}
+
+func (r *MemoryWebhookRepository) ListDeliveries(webhookId string, limit int) ([]models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deliveries []models.WebhookDelivery
+	for i := len(r.deliveries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		if r.deliveries[i].WebhookId == webhookId {
+			deliveries = append(deliveries, r.deliveries[i])
+		}
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,202 @@
+package webhookRepository
+
+import (
+	"errors"
+	"go-web-socket/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrWebhookNotFound = errors.New("webhook não encontrado")
+
+// Situação de uma entrega.
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+type WebhookRepository interface {
+	Create(webhook *models.Webhook) error
+	FindByWebhookId(webhookId string) (models.Webhook, error)
+	// List devolve os webhooks da sala, ou os globais com roomId vazio.
+	List(roomId string) ([]models.Webhook, error)
+	// ListActive devolve os webhooks ativos que recebem eventos da sala: os
+	// globais e os da própria sala.
+	ListActive(roomId string) ([]models.Webhook, error)
+	Delete(webhookId string) error
+	// Disable desativa o webhook; devolve false se ele já estava desativado.
+	Disable(webhookId string, at time.Time) (bool, error)
+	// Enable reativa o webhook e zera as falhas.
+	Enable(webhookId string) error
+	// RecordFailure soma uma falha seguida e devolve o total.
+	RecordFailure(webhookId string) (int, error)
+	ResetFailures(webhookId string) error
+
+	CreateDelivery(delivery *models.WebhookDelivery) error
+	// ClaimDue reserva até limit entregas pendentes vencidas, adiando a
+	// próxima tentativa delas para until, para que outro worker não as pegue.
+	ClaimDue(now, until time.Time, limit int) ([]models.WebhookDelivery, error)
+	SaveDelivery(delivery *models.WebhookDelivery) error
+	// ListDeliveries devolve as últimas entregas do webhook, da mais nova
+	// para a mais antiga.
+	ListDeliveries(webhookId string, limit int) ([]models.WebhookDelivery, error)
+}
+
+type GormWebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewGormWebhookRepository(db *gorm.DB) *GormWebhookRepository {
+	return &GormWebhookRepository{db: db}
+}
+
+func (r *GormWebhookRepository) Create(webhook *models.Webhook) error {
+	return r.db.Create(webhook).Error
+}
+
+func (r *GormWebhookRepository) FindByWebhookId(webhookId string) (models.Webhook, error) {
+	var webhook models.Webhook
+
+	result := r.db.Where("webhook_id = ?", webhookId).Limit(1).Find(&webhook)
+	if result.Error != nil {
+		return webhook, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return webhook, ErrWebhookNotFound
+	}
+
+	return webhook, nil
+}
+
+func (r *GormWebhookRepository) List(roomId string) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+
+	err := r.db.Where("room_id = ?", roomId).Order("id").Find(&webhooks).Error
+
+	return webhooks, err
+}
+
+func (r *GormWebhookRepository) ListActive(roomId string) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+
+	query := r.db.Where("disabled_at IS NULL")
+	if roomId == "" {
+		query = query.Where("room_id = ''")
+	} else {
+		query = query.Where("room_id IN ('', ?)", roomId)
+	}
+
+	err := query.Order("id").Find(&webhooks).Error
+
+	return webhooks, err
+}
+
+func (r *GormWebhookRepository) Delete(webhookId string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("webhook_id = ?", webhookId).Delete(&models.Webhook{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrWebhookNotFound
+		}
+
+		return tx.Where("webhook_id = ?", webhookId).Delete(&models.WebhookDelivery{}).Error
+	})
+}
+
+func (r *GormWebhookRepository) Disable(webhookId string, at time.Time) (bool, error) {
+	result := r.db.Model(&models.Webhook{}).
+		Where("webhook_id = ? AND disabled_at IS NULL", webhookId).
+		Update("disabled_at", at)
+
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *GormWebhookRepository) Enable(webhookId string) error {
+	result := r.db.Model(&models.Webhook{}).
+		Where("webhook_id = ?", webhookId).
+		Updates(map[string]interface{}{"disabled_at": nil, "failures": 0})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+func (r *GormWebhookRepository) RecordFailure(webhookId string) (int, error) {
+	var webhook models.Webhook
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Webhook{}).
+			Where("webhook_id = ?", webhookId).
+			Update("failures", gorm.Expr("failures + 1")).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("webhook_id = ?", webhookId).First(&webhook).Error
+	})
+
+	return webhook.Failures, err
+}
+
+func (r *GormWebhookRepository) ResetFailures(webhookId string) error {
+	return r.db.Model(&models.Webhook{}).
+		Where("webhook_id = ? AND failures > 0", webhookId).
+		Update("failures", 0).Error
+}
+
+func (r *GormWebhookRepository) CreateDelivery(delivery *models.WebhookDelivery) error {
+	return r.db.Create(delivery).Error
+}
+
+func (r *GormWebhookRepository) ClaimDue(now, until time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var due []models.WebhookDelivery
+
+	err := r.db.Where("status = ? AND next_attempt_at <= ?", StatusPending, now).
+		Order("next_attempt_at").
+		Limit(limit).
+		Find(&due).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var claimed []models.WebhookDelivery
+	for _, delivery := range due {
+		// Só fica com a entrega quem conseguir mudar o next_attempt_at lido.
+		result := r.db.Model(&models.WebhookDelivery{}).
+			Where("id = ? AND next_attempt_at = ?", delivery.ID, delivery.NextAttemptAt).
+			Update("next_attempt_at", until)
+		if result.Error != nil {
+			return claimed, result.Error
+		}
+
+		if result.RowsAffected == 1 {
+			delivery.NextAttemptAt = until
+			claimed = append(claimed, delivery)
+		}
+	}
+
+	return claimed, nil
+}
+
+func (r *GormWebhookRepository) SaveDelivery(delivery *models.WebhookDelivery) error {
+	return r.db.Save(delivery).Error
+}
+
+func (r *GormWebhookRepository) ListDeliveries(webhookId string, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+
+	err := r.db.Where("webhook_id = ?", webhookId).Order("id DESC").Limit(limit).Find(&deliveries).Error
+
+	return deliveries, err
+}
@@ -0,0 +1,237 @@
+package apiKeyService
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-web-socket/internal/models"
+	apiKeyRepository "go-web-socket/internal/repositories/APIKeyRepository"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	authService "go-web-socket/internal/services/AuthService"
+	jwtService "go-web-socket/internal/services/JWTService"
+	"go-web-socket/internal/utils/audit"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Prefix identifica uma chave de API no lugar de um token de acesso.
+const Prefix = "gws_"
+
+// Escopos que podem ser concedidos a uma chave. Uma chave só acessa as rotas
+// que aceitam chaves e exigem um dos seus escopos.
+const (
+	ScopeSocket       = "socket"        // abrir o WebSocket do dono da chave
+	ScopeMessagesSend = "messages:send" // POST /ws/send-private-message
+	ScopeUsersRead    = "users:read"    // GET /users
+	ScopeRoomsRead    = "rooms:read"    // GET /rooms
+)
+
+var Scopes = []string{ScopeSocket, ScopeMessagesSend, ScopeUsersRead, ScopeRoomsRead}
+
+// BotScopes são os escopos da chave criada junto com uma conta de bot.
+var BotScopes = []string{ScopeSocket, ScopeMessagesSend, ScopeRoomsRead}
+
+// touchInterval limita com que frequência o último uso é gravado.
+const touchInterval = time.Minute
+
+var (
+	ErrInvalidScope   = errors.New("escopo inválido")
+	ErrNoScopes       = errors.New("informe ao menos um escopo")
+	ErrInvalidExpiry  = errors.New("a validade deve estar no futuro")
+	ErrAPIKeyNotFound = errors.New("chave de API não encontrada")
+)
+
+type APIKeyService struct {
+	keys  apiKeyRepository.APIKeyRepository
+	users userRepository.UserRepository
+}
+
+func New(keys apiKeyRepository.APIKeyRepository, users userRepository.UserRepository) *APIKeyService {
+	return &APIKeyService{keys: keys, users: users}
+}
+
+// IsAPIKey diz se a credencial apresentada tem o formato de chave de API.
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, Prefix)
+}
+
+// SessionId é o id de "sessão" das conexões abertas com a chave, usado para
+// derrubá-las quando a chave é revogada.
+func SessionId(keyId string) string {
+	return "apikey:" + keyId
+}
+
+func ScopeList(key models.APIKey) []string {
+	return strings.Fields(key.Scopes)
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func random(size int) ([]byte, error) {
+	buf := make([]byte, size)
+
+	_, err := rand.Read(buf)
+
+	return buf, err
+}
+
+// Create gera uma chave para o usuário. A chave completa só é devolvida aqui.
+func (s *APIKeyService) Create(userId, name string, scopes []string, expiresAt *time.Time) (string, models.APIKey, error) {
+	if len(scopes) == 0 {
+		return "", models.APIKey{}, ErrNoScopes
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(Scopes, scope) {
+			return "", models.APIKey{}, fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+	}
+
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return "", models.APIKey{}, ErrInvalidExpiry
+	}
+
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+	scopes = slices.Compact(scopes)
+
+	id, err := random(8)
+	if err != nil {
+		return "", models.APIKey{}, err
+	}
+
+	secret, err := random(32)
+	if err != nil {
+		return "", models.APIKey{}, err
+	}
+
+	key := models.APIKey{
+		KeyId:      hex.EncodeToString(id),
+		SecretHash: hashSecret(base64.RawURLEncoding.EncodeToString(secret)),
+		UserId:     userId,
+		Name:       name,
+		Scopes:     strings.Join(scopes, " "),
+		ExpiresAt:  expiresAt,
+	}
+
+	if err := s.keys.Create(&key); err != nil {
+		return "", models.APIKey{}, fmt.Errorf("erro ao salvar chave de API: %v", err)
+	}
+
+	audit.Log("apikey.created", map[string]interface{}{"user_id": userId, "key_id": key.KeyId, "scopes": key.Scopes})
+
+	return Prefix + key.KeyId + "_" + base64.RawURLEncoding.EncodeToString(secret), key, nil
+}
+
+func (s *APIKeyService) List(userId string) ([]models.APIKey, error) {
+	return s.keys.ListByUser(userId)
+}
+
+// Revoke revoga uma chave do usuário. Chaves de outros usuários são
+// tratadas como inexistentes.
+func (s *APIKeyService) Revoke(userId, keyId string) error {
+	key, err := s.keys.FindByKeyId(keyId)
+	if errors.Is(err, apiKeyRepository.ErrAPIKeyNotFound) {
+		return ErrAPIKeyNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if key.UserId != userId {
+		return ErrAPIKeyNotFound
+	}
+
+	err = s.keys.Revoke(keyId, time.Now())
+	if errors.Is(err, apiKeyRepository.ErrAPIKeyNotFound) {
+		return ErrAPIKeyNotFound
+	}
+
+	if err == nil {
+		audit.Log("apikey.revoked", map[string]interface{}{"user_id": userId, "key_id": keyId})
+	}
+
+	return err
+}
+
+// Authenticate valida uma chave e devolve a identidade do dono com os
+// escopos da chave. Os erros são os mesmos do AuthService, para o middleware
+// tratar chaves e tokens do mesmo jeito.
+func (s *APIKeyService) Authenticate(credential string, meta authService.SessionMeta) (*jwtService.UserToken, error) {
+	keyId, secret, ok := strings.Cut(strings.TrimPrefix(credential, Prefix), "_")
+	if !IsAPIKey(credential) || !ok {
+		return nil, authService.ErrInvalidToken
+	}
+
+	key, err := s.keys.FindByKeyId(keyId)
+	if errors.Is(err, apiKeyRepository.ErrAPIKeyNotFound) {
+		return nil, authService.ErrInvalidToken
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(key.SecretHash)) != 1 {
+		return nil, authService.ErrInvalidToken
+	}
+
+	now := time.Now()
+
+	if key.RevokedAt != nil {
+		return nil, authService.ErrTokenRevoked
+	}
+
+	if key.ExpiresAt != nil && now.After(*key.ExpiresAt) {
+		return nil, authService.ErrInvalidToken
+	}
+
+	user, err := s.users.FindByUserId(key.UserId)
+	if errors.Is(err, userRepository.ErrUserNotFound) {
+		return nil, authService.ErrTokenRevoked
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Disabled {
+		return nil, authService.ErrTokenRevoked
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > touchInterval || key.LastUsedIP != meta.IP {
+		if err := s.keys.Touch(key.KeyId, meta.IP, now); err != nil {
+			log.Printf("Erro ao atualizar uso da chave %s: %v", key.KeyId, err)
+		}
+	}
+
+	identity := &jwtService.UserToken{
+		UserId:    user.UserId,
+		Name:      user.Name,
+		Username:  user.Username,
+		SessionId: SessionId(key.KeyId),
+		Roles:     authService.Roles(user),
+		Scopes:    ScopeList(key),
+		APIKeyId:  key.KeyId,
+	}
+
+	if user.Avatar != "" {
+		identity.Avatar = &user.Avatar
+	}
+
+	if key.ExpiresAt != nil {
+		identity.ExpiresAt = *key.ExpiresAt
+	}
+
+	return identity, nil
+}
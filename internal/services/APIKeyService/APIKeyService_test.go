@@ -0,0 +1,158 @@
+package apiKeyService
+
+import (
+	"errors"
+	"go-web-socket/internal/models"
+	apiKeyRepository "go-web-socket/internal/repositories/APIKeyRepository"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	authService "go-web-socket/internal/services/AuthService"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "apikey-test")
+	if err != nil {
+		panic(err)
+	}
+
+	os.Setenv("AUDIT_LOG_FILE", filepath.Join(dir, "audit.log"))
+
+	code := m.Run()
+
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+var meta = authService.SessionMeta{IP: "10.0.0.1", UserAgent: "test"}
+
+func newService(t *testing.T) (*APIKeyService, *userRepository.MemoryUserRepository) {
+	t.Helper()
+
+	users := userRepository.NewMemoryUserRepository()
+
+	user := models.User{UserId: "u1", Username: "alice", Name: "Alice"}
+	if err := users.Create(&user); err != nil {
+		t.Fatal(err)
+	}
+
+	return New(apiKeyRepository.NewMemoryAPIKeyRepository(), users), users
+}
+
+func TestCreateValidatesScopes(t *testing.T) {
+	service, _ := newService(t)
+
+	if _, _, err := service.Create("u1", "ci", nil, nil); !errors.Is(err, ErrNoScopes) {
+		t.Fatalf("esperava ErrNoScopes, veio %v", err)
+	}
+
+	if _, _, err := service.Create("u1", "ci", []string{ScopeUsersRead, "admin"}, nil); !errors.Is(err, ErrInvalidScope) {
+		t.Fatalf("esperava ErrInvalidScope, veio %v", err)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	if _, _, err := service.Create("u1", "ci", []string{ScopeUsersRead}, &past); !errors.Is(err, ErrInvalidExpiry) {
+		t.Fatalf("esperava ErrInvalidExpiry, veio %v", err)
+	}
+
+	_, key, err := service.Create("u1", "ci", []string{ScopeUsersRead, ScopeRoomsRead, ScopeUsersRead}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key.Scopes != ScopeRoomsRead+" "+ScopeUsersRead {
+		t.Fatalf("esperava os escopos ordenados e sem repetição, veio %q", key.Scopes)
+	}
+}
+
+func TestAuthenticateKeepsTheKeyScopes(t *testing.T) {
+	service, _ := newService(t)
+
+	secret, key, err := service.Create("u1", "bot", []string{ScopeMessagesSend}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identity, err := service.Authenticate(secret, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if identity.UserId != "u1" || identity.APIKeyId != key.KeyId || identity.SessionId != SessionId(key.KeyId) {
+		t.Fatalf("identidade inesperada: %+v", identity)
+	}
+
+	if !identity.HasScope(ScopeMessagesSend) {
+		t.Fatal("a chave deveria ter o escopo messages:send")
+	}
+
+	for _, scope := range []string{ScopeSocket, ScopeUsersRead, ScopeRoomsRead} {
+		if identity.HasScope(scope) {
+			t.Fatalf("a chave não deveria ter o escopo %s", scope)
+		}
+	}
+}
+
+func TestAuthenticateRejectsBadKeys(t *testing.T) {
+	service, _ := newService(t)
+
+	secret, _, err := service.Create("u1", "bot", []string{ScopeSocket}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, credential := range []string{
+		"gws_",
+		"gws_semsegredo",
+		secret + "x",
+		Prefix + "0000000000000000_" + secret[len(secret)-10:],
+	} {
+		if _, err := service.Authenticate(credential, meta); !errors.Is(err, authService.ErrInvalidToken) {
+			t.Fatalf("%q: esperava ErrInvalidToken, veio %v", credential, err)
+		}
+	}
+}
+
+func TestRevokedKeyIsRejected(t *testing.T) {
+	service, _ := newService(t)
+
+	secret, key, err := service.Create("u1", "bot", []string{ScopeSocket}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.Revoke("u2", key.KeyId); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Fatalf("outro usuário não pode revogar a chave, veio %v", err)
+	}
+
+	if _, err := service.Authenticate(secret, meta); err != nil {
+		t.Fatalf("a chave ainda deveria valer, veio %v", err)
+	}
+
+	if err := service.Revoke("u1", key.KeyId); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.Authenticate(secret, meta); !errors.Is(err, authService.ErrTokenRevoked) {
+		t.Fatalf("esperava ErrTokenRevoked, veio %v", err)
+	}
+}
+
+func TestKeyOfDisabledUserIsRejected(t *testing.T) {
+	service, users := newService(t)
+
+	secret, _, err := service.Create("u1", "bot", []string{ScopeSocket}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := users.SetDisabled("u1", true); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.Authenticate(secret, meta); !errors.Is(err, authService.ErrTokenRevoked) {
+		t.Fatalf("esperava ErrTokenRevoked, veio %v", err)
+	}
+}
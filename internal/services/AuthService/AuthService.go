@@ -0,0 +1,337 @@
+package authService
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-web-socket/config"
+	"go-web-socket/internal/models"
+	refreshTokenRepository "go-web-socket/internal/repositories/RefreshTokenRepository"
+	sessionRepository "go-web-socket/internal/repositories/SessionRepository"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	jwtService "go-web-socket/internal/services/JWTService"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("refresh token inválido ou expirado")
+	ErrRefreshTokenReused  = errors.New("refresh token reutilizado; sessão revogada")
+	ErrInvalidToken        = errors.New("token inválido ou expirado")
+	ErrTokenRevoked        = errors.New("token revogado")
+	ErrSessionNotFound     = errors.New("sessão não encontrada")
+	ErrUserDisabled        = errors.New("conta desativada")
+)
+
+// sessionTouchInterval limita com que frequência o last-seen de uma sessão é
+// gravado, para não escrever no banco a cada requisição.
+const sessionTouchInterval = time.Minute
+
+// SessionMeta identifica o dispositivo que fez a requisição.
+type SessionMeta struct {
+	IP        string
+	UserAgent string
+}
+
+type Tokens struct {
+	AccessToken      string `json:"token"`
+	ExpiresIn        int64  `json:"exp"` // segundos até o token de acesso expirar
+	ExpiresAt        int64  `json:"expires_at"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresAt int64  `json:"refresh_expires_at"`
+}
+
+type AuthService struct {
+	users         userRepository.UserRepository
+	refreshTokens refreshTokenRepository.RefreshTokenRepository
+	sessions      sessionRepository.SessionRepository
+}
+
+func New(users userRepository.UserRepository, refreshTokens refreshTokenRepository.RefreshTokenRepository, sessions sessionRepository.SessionRepository) *AuthService {
+	return &AuthService{users: users, refreshTokens: refreshTokens, sessions: sessions}
+}
+
+// RefreshTokenTTL é a validade dos refresh tokens (REFRESH_TOKEN_TTL, padrão 30 dias).
+func RefreshTokenTTL() time.Duration {
+	config.LoadEnv()
+
+	return config.GetEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newRefreshToken() (string, error) {
+	buf := make([]byte, 32)
+
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// Roles devolve os papéis do usuário que vão no token de acesso.
+func Roles(user models.User) []string {
+	if user.Role == jwtService.RoleAdmin {
+		return []string{jwtService.RoleUser, jwtService.RoleAdmin}
+	}
+
+	return []string{jwtService.RoleUser}
+}
+
+// IssueTokens abre uma nova sessão para o usuário. O id da sessão também é
+// a família dos refresh tokens.
+func (s *AuthService) IssueTokens(user models.User, meta SessionMeta) (Tokens, error) {
+	if user.Disabled {
+		return Tokens{}, ErrUserDisabled
+	}
+
+	session := models.Session{
+		SessionId:  uuid.New().String(),
+		UserId:     user.UserId,
+		IP:         meta.IP,
+		UserAgent:  meta.UserAgent,
+		LastSeenAt: time.Now(),
+	}
+
+	if err := s.sessions.Create(&session); err != nil {
+		return Tokens{}, fmt.Errorf("erro ao criar sessão: %v", err)
+	}
+
+	return s.issue(user, session.SessionId)
+}
+
+func (s *AuthService) issue(user models.User, familyId string) (Tokens, error) {
+	now := time.Now()
+
+	claims := &jwtService.UserToken{
+		UserId:    user.UserId,
+		Name:      user.Name,
+		Username:  user.Username,
+		SessionId: familyId,
+		Roles:     Roles(user),
+	}
+
+	if user.Avatar != "" {
+		claims.Avatar = &user.Avatar
+	}
+
+	accessToken, err := jwtService.CreateToken(claims)
+	if err != nil {
+		return Tokens{}, fmt.Errorf("erro ao gerar token de acesso: %v", err)
+	}
+
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return Tokens{}, fmt.Errorf("erro ao gerar refresh token: %v", err)
+	}
+
+	record := models.RefreshToken{
+		TokenHash: hashToken(refreshToken),
+		FamilyId:  familyId,
+		UserId:    user.UserId,
+		ExpiresAt: now.Add(RefreshTokenTTL()),
+	}
+
+	if err := s.refreshTokens.Create(&record); err != nil {
+		return Tokens{}, fmt.Errorf("erro ao salvar refresh token: %v", err)
+	}
+
+	ttl := jwtService.AccessTokenTTL()
+
+	return Tokens{
+		AccessToken:      accessToken,
+		ExpiresIn:        int64(ttl.Seconds()),
+		ExpiresAt:        now.Add(ttl).Unix(),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: record.ExpiresAt.Unix(),
+	}, nil
+}
+
+// Refresh troca um refresh token válido por um novo par de tokens. Um token
+// só pode ser trocado uma vez: apresentar um token já trocado indica que ele
+// vazou, e toda a família é revogada.
+func (s *AuthService) Refresh(refreshToken string, meta SessionMeta) (Tokens, error) {
+	now := time.Now()
+
+	record, err := s.refreshTokens.FindByHash(hashToken(refreshToken))
+	if errors.Is(err, refreshTokenRepository.ErrRefreshTokenNotFound) {
+		return Tokens{}, ErrInvalidRefreshToken
+	}
+
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	if record.RevokedAt != nil || now.After(record.ExpiresAt) {
+		return Tokens{}, ErrInvalidRefreshToken
+	}
+
+	firstUse := record.UsedAt == nil
+	if firstUse {
+		firstUse, err = s.refreshTokens.MarkUsed(record.ID, now)
+		if err != nil {
+			return Tokens{}, err
+		}
+	}
+
+	if !firstUse {
+		log.Printf("Refresh token reutilizado; revogando família %s do usuário %s", record.FamilyId, record.UserId)
+
+		if err := s.revokeSession(record.FamilyId, now); err != nil {
+			return Tokens{}, err
+		}
+
+		return Tokens{}, ErrRefreshTokenReused
+	}
+
+	session, err := s.sessions.FindBySessionId(record.FamilyId)
+	if errors.Is(err, sessionRepository.ErrSessionNotFound) {
+		return Tokens{}, ErrInvalidRefreshToken
+	}
+
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	if session.RevokedAt != nil {
+		return Tokens{}, ErrInvalidRefreshToken
+	}
+
+	if err := s.sessions.Touch(session.SessionId, meta.IP, meta.UserAgent, now); err != nil {
+		log.Printf("Erro ao atualizar sessão %s: %v", session.SessionId, err)
+	}
+
+	user, err := s.users.FindByUserId(record.UserId)
+	if errors.Is(err, userRepository.ErrUserNotFound) {
+		return Tokens{}, ErrInvalidRefreshToken
+	}
+
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	if user.Disabled {
+		return Tokens{}, ErrInvalidRefreshToken
+	}
+
+	return s.issue(user, record.FamilyId)
+}
+
+// Authenticate valida um token de acesso: assinatura, validade, revogação do
+// próprio token (jti) e da sessão a que ele pertence.
+func (s *AuthService) Authenticate(tokenString string, meta SessionMeta) (*jwtService.UserToken, error) {
+	claims, err := jwtService.DecodeToken(tokenString)
+	if err != nil || claims.SessionId == "" || claims.TokenId == "" {
+		return nil, ErrInvalidToken
+	}
+
+	revoked, err := s.sessions.IsTokenRevoked(claims.TokenId)
+	if err != nil {
+		return nil, err
+	}
+
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	session, err := s.sessions.FindBySessionId(claims.SessionId)
+	if errors.Is(err, sessionRepository.ErrSessionNotFound) {
+		return nil, ErrTokenRevoked
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if session.RevokedAt != nil || session.UserId != claims.UserId {
+		return nil, ErrTokenRevoked
+	}
+
+	now := time.Now()
+	if now.Sub(session.LastSeenAt) > sessionTouchInterval || session.IP != meta.IP {
+		if err := s.sessions.Touch(session.SessionId, meta.IP, meta.UserAgent, now); err != nil {
+			log.Printf("Erro ao atualizar sessão %s: %v", session.SessionId, err)
+		}
+	}
+
+	return claims, nil
+}
+
+// Logout revoga o token apresentado e encerra a sessão dele.
+func (s *AuthService) Logout(claims *jwtService.UserToken) error {
+	now := time.Now()
+
+	if err := s.sessions.RevokeToken(claims.TokenId, claims.ExpiresAt); err != nil {
+		return fmt.Errorf("erro ao revogar token: %v", err)
+	}
+
+	if err := s.sessions.DeleteExpiredTokens(now); err != nil {
+		log.Printf("Erro ao limpar tokens revogados: %v", err)
+	}
+
+	return s.revokeSession(claims.SessionId, now)
+}
+
+func (s *AuthService) ListSessions(userId string) ([]models.Session, error) {
+	return s.sessions.ListActive(userId)
+}
+
+// RevokeSession encerra uma sessão do usuário. Sessões de outros usuários são
+// tratadas como inexistentes.
+func (s *AuthService) RevokeSession(userId, sessionId string) error {
+	session, err := s.sessions.FindBySessionId(sessionId)
+	if errors.Is(err, sessionRepository.ErrSessionNotFound) {
+		return ErrSessionNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if session.UserId != userId || session.RevokedAt != nil {
+		return ErrSessionNotFound
+	}
+
+	return s.revokeSession(sessionId, time.Now())
+}
+
+// RevokeAllSessions encerra todas as sessões do usuário, menos except (que
+// pode ser vazio), e devolve os ids encerrados.
+func (s *AuthService) RevokeAllSessions(userId, except string) ([]string, error) {
+	now := time.Now()
+
+	ids, err := s.sessions.RevokeAllExcept(userId, except, now)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao encerrar sessões: %v", err)
+	}
+
+	for _, id := range ids {
+		if err := s.refreshTokens.RevokeFamily(id, now); err != nil {
+			return ids, fmt.Errorf("erro ao revogar refresh tokens: %v", err)
+		}
+	}
+
+	return ids, nil
+}
+
+func (s *AuthService) revokeSession(sessionId string, at time.Time) error {
+	if err := s.refreshTokens.RevokeFamily(sessionId, at); err != nil {
+		return fmt.Errorf("erro ao revogar refresh tokens: %v", err)
+	}
+
+	err := s.sessions.Revoke(sessionId, at)
+	if errors.Is(err, sessionRepository.ErrSessionNotFound) {
+		return nil
+	}
+
+	return err
+}
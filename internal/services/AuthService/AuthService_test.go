@@ -0,0 +1,247 @@
+package authService
+
+import (
+	"errors"
+	"go-web-socket/internal/models"
+	refreshTokenRepository "go-web-socket/internal/repositories/RefreshTokenRepository"
+	sessionRepository "go-web-socket/internal/repositories/SessionRepository"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "auth-test")
+	if err != nil {
+		panic(err)
+	}
+
+	os.Setenv("JWT_KEYS_DIR", dir)
+	os.Setenv("JWT_ALLOW_EPHEMERAL_KEY", "true")
+
+	code := m.Run()
+
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+var meta = SessionMeta{IP: "10.0.0.1", UserAgent: "test"}
+
+func newService(t *testing.T) (*AuthService, *userRepository.MemoryUserRepository, models.User) {
+	t.Helper()
+
+	users := userRepository.NewMemoryUserRepository()
+
+	user := models.User{UserId: "u1", Username: "alice", Name: "Alice"}
+	if err := users.Create(&user); err != nil {
+		t.Fatal(err)
+	}
+
+	service := New(users, refreshTokenRepository.NewMemoryRefreshTokenRepository(), sessionRepository.NewMemorySessionRepository())
+
+	return service, users, user
+}
+
+func TestRefreshRotatesTheToken(t *testing.T) {
+	service, _, user := newService(t)
+
+	tokens, err := service.IssueTokens(user, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next, err := service.Refresh(tokens.RefreshToken, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if next.RefreshToken == tokens.RefreshToken {
+		t.Fatal("o refresh token não foi trocado")
+	}
+
+	if _, err := service.Authenticate(next.AccessToken, meta); err != nil {
+		t.Fatalf("o novo token de acesso não vale: %v", err)
+	}
+
+	if _, err := service.Refresh("desconhecido", meta); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("esperava ErrInvalidRefreshToken, veio %v", err)
+	}
+}
+
+func TestRefreshTokenReuseRevokesTheSession(t *testing.T) {
+	service, _, user := newService(t)
+
+	tokens, err := service.IssueTokens(user, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next, err := service.Refresh(tokens.RefreshToken, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// O token antigo voltou: vazou, e a sessão inteira cai
+	if _, err := service.Refresh(tokens.RefreshToken, meta); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("esperava ErrRefreshTokenReused, veio %v", err)
+	}
+
+	if _, err := service.Refresh(next.RefreshToken, meta); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("o token mais novo da família deveria estar revogado, veio %v", err)
+	}
+
+	if _, err := service.Authenticate(next.AccessToken, meta); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("o token de acesso da sessão deveria estar revogado, veio %v", err)
+	}
+
+	// Outras sessões do usuário continuam valendo
+	other, err := service.IssueTokens(user, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.Refresh(other.RefreshToken, meta); err != nil {
+		t.Fatalf("outra sessão foi afetada: %v", err)
+	}
+}
+
+func TestLogoutRevokesTheTokenAndTheSession(t *testing.T) {
+	service, _, user := newService(t)
+
+	tokens, err := service.IssueTokens(user, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := service.Authenticate(tokens.AccessToken, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.Logout(claims); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.Authenticate(tokens.AccessToken, meta); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("esperava ErrTokenRevoked, veio %v", err)
+	}
+
+	if _, err := service.Refresh(tokens.RefreshToken, meta); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("esperava ErrInvalidRefreshToken, veio %v", err)
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	service, users, user := newService(t)
+
+	other := models.User{UserId: "u2", Username: "bob"}
+	if err := users.Create(&other); err != nil {
+		t.Fatal(err)
+	}
+
+	mine, err := service.IssueTokens(user, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	theirs, err := service.IssueTokens(other, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	theirClaims, err := service.Authenticate(theirs.AccessToken, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A sessão de outro usuário é tratada como inexistente
+	if err := service.RevokeSession(user.UserId, theirClaims.SessionId); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("esperava ErrSessionNotFound, veio %v", err)
+	}
+
+	myClaims, err := service.Authenticate(mine.AccessToken, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.RevokeSession(user.UserId, myClaims.SessionId); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.Authenticate(mine.AccessToken, meta); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("esperava ErrTokenRevoked, veio %v", err)
+	}
+
+	if err := service.RevokeSession(user.UserId, myClaims.SessionId); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("revogar de novo deveria dar ErrSessionNotFound, veio %v", err)
+	}
+
+	if _, err := service.Authenticate(theirs.AccessToken, meta); err != nil {
+		t.Fatalf("a sessão do outro usuário foi afetada: %v", err)
+	}
+}
+
+func TestRevokeAllSessionsKeepsTheCurrentOne(t *testing.T) {
+	service, _, user := newService(t)
+
+	var tokens []Tokens
+	for i := 0; i < 3; i++ {
+		issued, err := service.IssueTokens(user, meta)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tokens = append(tokens, issued)
+	}
+
+	current, err := service.Authenticate(tokens[0].AccessToken, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revoked, err := service.RevokeAllSessions(user.UserId, current.SessionId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(revoked) != 2 {
+		t.Fatalf("encerrou %d sessões, esperado 2", len(revoked))
+	}
+
+	sessions, err := service.ListSessions(user.UserId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sessions) != 1 || sessions[0].SessionId != current.SessionId {
+		t.Fatalf("sessões ativas inesperadas: %+v", sessions)
+	}
+
+	for _, issued := range tokens[1:] {
+		if _, err := service.Refresh(issued.RefreshToken, meta); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Fatalf("esperava ErrInvalidRefreshToken, veio %v", err)
+		}
+	}
+}
+
+func TestDisabledUsersCannotRefresh(t *testing.T) {
+	service, users, user := newService(t)
+
+	tokens, err := service.IssueTokens(user, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := users.SetDisabled(user.UserId, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.Refresh(tokens.RefreshToken, meta); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("esperava ErrInvalidRefreshToken, veio %v", err)
+	}
+
+	user.Disabled = true
+	if _, err := service.IssueTokens(user, meta); !errors.Is(err, ErrUserDisabled) {
+		t.Fatalf("esperava ErrUserDisabled, veio %v", err)
+	}
+}
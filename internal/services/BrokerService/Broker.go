@@ -0,0 +1,170 @@
+package brokerService
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go-web-socket/config"
+	"os"
+	"time"
+)
+
+// Tipos de envelope trocados entre os nós.
+const (
+	KindPrivate           = "private"            // Target: usuário
+	KindRoom              = "room"               // Target: sala
+	KindBroadcast         = "broadcast"          // todos os clientes
+	KindPresence          = "presence"           // Target: usuário; vai a quem acompanha
+	KindDisconnectSession = "disconnect-session" // Target: sessão
+	KindDisconnectUser    = "disconnect-user"    // Target: usuário
+	KindBotCommand        = "bot-command"        // Target: sala; Payload: o comando
+	KindBotCommandsGone   = "bot-commands-gone"  // Target: bot que saiu do cluster
+	KindBotCommandsSync   = "bot-commands-sync"  // pede os comandos dos bots de cada nó
+)
+
+// Envelope é o que trafega entre os nós do cluster. Payload é a mensagem já
+// serializada que cada nó escreve nas suas conexões.
+type Envelope struct {
+	Node    string          `json:"node"`
+	Kind    string          `json:"kind"`
+	Target  string          `json:"target,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Presence é um usuário no registro de presença.
+type Presence struct {
+	UserId string
+	Bot    bool
+	Idle   bool // sem atividade em todos os nós em que está conectado
+}
+
+// State é a situação de um usuário no cluster.
+type State struct {
+	Online bool
+	Idle   bool
+}
+
+// Transition é a situação do usuário antes e depois de uma mudança no
+// registro. Como as mudanças são atômicas, só o nó que fez a mudança vê cada
+// transição e pode anunciá-la.
+type Transition struct {
+	Before State
+	After  State
+}
+
+func (t Transition) Connected() bool {
+	return !t.Before.Online && t.After.Online
+}
+
+func (t Transition) Disconnected() bool {
+	return t.Before.Online && !t.After.Online
+}
+
+// Heartbeat é o resultado da renovação da concessão de um nó.
+type Heartbeat struct {
+	// Expired indica que a concessão deste nó tinha vencido e as presenças
+	// dele foram removidas por outro nó; quem chamou deve registrá-las de novo.
+	Expired bool
+
+	// Offline são os usuários que ficaram offline com os nós vencidos
+	// removidos nesta batida.
+	Offline []Presence
+}
+
+// Broker liga os nós que atendem o WebSocket: repassa as mensagens entre eles
+// e mantém o registro de presença, que diz em quais nós cada usuário está
+// conectado e se está ocioso em cada um. Cada nó só aparece no registro
+// enquanto renova a concessão com Heartbeat; os usuários de um nó que parou
+// de renovar saem com ele.
+type Broker interface {
+	// Node identifica este nó no cluster.
+	Node() string
+
+	// Publish envia o envelope a todos os outros nós.
+	Publish(envelope Envelope) error
+
+	// Send envia o envelope a um nó.
+	Send(node string, envelope Envelope) error
+
+	// Subscribe registra quem recebe os envelopes vindos dos outros nós.
+	Subscribe(handler func(Envelope))
+
+	// Claim registra que este nó tem conexões (ativas) do usuário.
+	Claim(userId string, bot bool) (Transition, error)
+
+	// Release remove o usuário deste nó.
+	Release(userId string) (Transition, error)
+
+	// SetIdle marca as conexões do usuário neste nó como ociosas ou ativas.
+	// O usuário só fica ocioso no cluster quando está ocioso em todos os nós.
+	SetIdle(userId string, idle bool) (Transition, error)
+
+	// States devolve a situação de cada usuário no cluster.
+	States(userIds []string) (map[string]State, error)
+
+	// Nodes lista os nós vivos com conexões do usuário.
+	Nodes(userId string) ([]string, error)
+
+	// Online lista os usuários conectados a algum nó vivo.
+	Online() ([]Presence, error)
+
+	// Heartbeat renova a concessão deste nó e remove os nós vencidos.
+	Heartbeat() (Heartbeat, error)
+
+	Close() error
+}
+
+type Config struct {
+	Driver   string // "memory" (padrão, um único nó) ou "redis"
+	RedisURL string
+	Prefix   string // separa as chaves de instalações que dividem o Redis
+	Node     string
+
+	// LeaseTTL é por quanto tempo um nó continua no registro sem renovar a
+	// concessão; HeartbeatInterval é de quanto em quanto tempo ele renova.
+	LeaseTTL          time.Duration
+	HeartbeatInterval time.Duration
+}
+
+func LoadConfig() Config {
+	config.LoadEnv()
+
+	return Config{
+		Driver:            config.GetEnv("BROKER_DRIVER", "memory"),
+		RedisURL:          config.GetEnv("REDIS_URL", "redis://localhost:6379/0"),
+		Prefix:            config.GetEnv("BROKER_PREFIX", "gws:"),
+		Node:              config.GetEnv("NODE_ID", randomNodeId()),
+		LeaseTTL:          config.GetEnvDuration("PRESENCE_LEASE_TTL", 30*time.Second),
+		HeartbeatInterval: config.GetEnvDuration("PRESENCE_HEARTBEAT_INTERVAL", 10*time.Second),
+	}
+}
+
+// randomNodeId gera um id com o nome da máquina, para quando NODE_ID não é
+// informado.
+func randomNodeId() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "node"
+	}
+
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+
+	return host + "-" + hex.EncodeToString(suffix)
+}
+
+func New(cfg Config) (Broker, error) {
+	if cfg.HeartbeatInterval >= cfg.LeaseTTL {
+		return nil, fmt.Errorf("PRESENCE_HEARTBEAT_INTERVAL (%s) deve ser menor que PRESENCE_LEASE_TTL (%s)", cfg.HeartbeatInterval, cfg.LeaseTTL)
+	}
+
+	switch cfg.Driver {
+	case "memory":
+		return NewMemoryBus(cfg.LeaseTTL).Node(cfg.Node), nil
+	case "redis":
+		return NewRedisBroker(cfg.Node, cfg.RedisURL, cfg.Prefix, cfg.LeaseTTL)
+	default:
+		return nil, fmt.Errorf("BROKER_DRIVER desconhecido: %q", cfg.Driver)
+	}
+}
@@ -0,0 +1,197 @@
+package brokerService
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// cluster são dois nós ligados pelo mesmo broker e um jeito de vencer a
+// concessão de um deles sem esperar o TTL.
+type cluster struct {
+	a, b   Broker
+	expire func(node string)
+}
+
+// testBroker roda os mesmos cenários nos dois drivers.
+func testBroker(t *testing.T, newCluster func(t *testing.T) cluster) {
+	t.Run("publish e send", func(t *testing.T) {
+		testDelivery(t, newCluster(t))
+	})
+
+	t.Run("claim, idle e release", func(t *testing.T) {
+		testTransitions(t, newCluster(t))
+	})
+
+	t.Run("heartbeat remove o nó vencido", func(t *testing.T) {
+		testReap(t, newCluster(t))
+	})
+}
+
+func receive(t *testing.T, envelopes <-chan Envelope) Envelope {
+	t.Helper()
+
+	select {
+	case envelope := <-envelopes:
+		return envelope
+	case <-time.After(2 * time.Second):
+		t.Fatal("nenhum envelope recebido")
+		return Envelope{}
+	}
+}
+
+func testDelivery(t *testing.T, c cluster) {
+	fromA := make(chan Envelope, 4)
+	fromB := make(chan Envelope, 4)
+	c.a.Subscribe(func(envelope Envelope) { fromB <- envelope })
+	c.b.Subscribe(func(envelope Envelope) { fromA <- envelope })
+
+	if err := c.a.Publish(Envelope{Kind: KindBroadcast, Payload: []byte(`"oi"`)}); err != nil {
+		t.Fatal(err)
+	}
+
+	envelope := receive(t, fromA)
+	if envelope.Node != c.a.Node() || envelope.Kind != KindBroadcast || string(envelope.Payload) != `"oi"` {
+		t.Fatalf("envelope inesperado: %+v", envelope)
+	}
+
+	if err := c.b.Send(c.a.Node(), Envelope{Kind: KindPrivate, Target: "u1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	envelope = receive(t, fromB)
+	if envelope.Node != c.b.Node() || envelope.Target != "u1" {
+		t.Fatalf("envelope inesperado: %+v", envelope)
+	}
+
+	// Nenhum nó recebe o que ele mesmo publicou.
+	select {
+	case envelope := <-fromB:
+		t.Fatalf("o nó a recebeu um envelope a mais: %+v", envelope)
+	case envelope := <-fromA:
+		t.Fatalf("o nó b recebeu um envelope a mais: %+v", envelope)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// transitions devolve um verificador que falha o teste quando a mudança no
+// registro dá erro.
+func transitions(t *testing.T) func(Transition, error) Transition {
+	return func(transition Transition, err error) Transition {
+		t.Helper()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return transition
+	}
+}
+
+func testTransitions(t *testing.T, c cluster) {
+	must := transitions(t)
+
+	if tr := must(c.a.Claim("u1", false)); !tr.Connected() {
+		t.Fatalf("primeiro claim deveria conectar: %+v", tr)
+	}
+
+	if tr := must(c.b.Claim("u1", false)); tr.Connected() || !tr.After.Online {
+		t.Fatalf("segundo nó não deveria anunciar a conexão: %+v", tr)
+	}
+
+	nodes, err := c.a.Nodes("u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{c.a.Node(), c.b.Node()}; !reflect.DeepEqual(nodes, sorted(want)) {
+		t.Fatalf("Nodes = %v, esperado %v", nodes, sorted(want))
+	}
+
+	if tr := must(c.a.SetIdle("u1", true)); tr.After.Idle {
+		t.Fatalf("ainda ativo no nó b, não pode ficar ocioso: %+v", tr)
+	}
+
+	if tr := must(c.b.SetIdle("u1", true)); tr.Before.Idle || !tr.After.Idle {
+		t.Fatalf("ocioso nos dois nós deveria ficar ocioso: %+v", tr)
+	}
+
+	states, err := c.b.States([]string{"u1", "u2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := map[string]State{"u1": {Online: true, Idle: true}, "u2": {}}; !reflect.DeepEqual(states, want) {
+		t.Fatalf("States = %+v, esperado %+v", states, want)
+	}
+
+	if tr := must(c.a.Release("u1")); tr.Disconnected() {
+		t.Fatalf("ainda conectado no nó b: %+v", tr)
+	}
+
+	if tr := must(c.b.Release("u1")); !tr.Disconnected() {
+		t.Fatalf("último release deveria desconectar: %+v", tr)
+	}
+
+	online, err := c.a.Online()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(online) != 0 {
+		t.Fatalf("Online = %+v, esperado vazio", online)
+	}
+}
+
+func testReap(t *testing.T, c cluster) {
+	must := transitions(t)
+
+	must(c.a.Claim("shared", false))
+	must(c.b.Claim("shared", false))
+	must(c.b.Claim("bot", true))
+
+	c.expire(c.b.Node())
+
+	result, err := c.a.Heartbeat()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Expired {
+		t.Fatal("a concessão do nó a não venceu")
+	}
+
+	if want := []Presence{{UserId: "bot", Bot: true}}; !reflect.DeepEqual(result.Offline, want) {
+		t.Fatalf("Offline = %+v, esperado %+v", result.Offline, want)
+	}
+
+	nodes, err := c.a.Nodes("shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{c.a.Node()}; !reflect.DeepEqual(nodes, want) {
+		t.Fatalf("Nodes = %v, esperado %v", nodes, want)
+	}
+
+	result, err = c.b.Heartbeat()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.Expired {
+		t.Fatal("o nó b deveria saber que foi removido")
+	}
+
+	// Depois de removido, o nó b volta com o registro vazio e precisa
+	// registrar os usuários de novo.
+	if tr := must(c.b.Claim("bot", true)); !tr.Connected() {
+		t.Fatalf("o bot deveria voltar a ficar online: %+v", tr)
+	}
+}
+
+func sorted(values []string) []string {
+	sort.Strings(values)
+	return values
+}
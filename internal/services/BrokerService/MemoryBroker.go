@@ -0,0 +1,288 @@
+package brokerService
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryBus liga os nós de um mesmo processo. Com um único nó (o padrão) não
+// há para quem repassar, mas vários hubs no mesmo processo, como nos testes,
+// se comportam como um cluster.
+type MemoryBus struct {
+	mu       sync.Mutex
+	leaseTTL time.Duration
+	brokers  map[string]*MemoryBroker
+	leases   map[string]time.Time        // nó → fim da concessão
+	users    map[string]map[string]entry // nó → usuário → entrada
+}
+
+// entry é um usuário no registro de um nó.
+type entry struct {
+	bot  bool
+	idle bool
+}
+
+func NewMemoryBus(leaseTTL time.Duration) *MemoryBus {
+	return &MemoryBus{
+		leaseTTL: leaseTTL,
+		brokers:  make(map[string]*MemoryBroker),
+		leases:   make(map[string]time.Time),
+		users:    make(map[string]map[string]entry),
+	}
+}
+
+// Node cria o broker de um nó ligado ao barramento.
+func (bus *MemoryBus) Node(node string) *MemoryBroker {
+	broker := &MemoryBroker{bus: bus, node: node}
+
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	bus.brokers[node] = broker
+	bus.leases[node] = time.Now().Add(bus.leaseTTL)
+	bus.users[node] = make(map[string]entry)
+
+	return broker
+}
+
+// live diz se o nó está com a concessão em dia; exige bus.mu.
+func (bus *MemoryBus) live(node string, now time.Time) bool {
+	expires, ok := bus.leases[node]
+
+	return ok && expires.After(now)
+}
+
+// holders lista os nós vivos com o usuário; exige bus.mu.
+func (bus *MemoryBus) holders(userId string, now time.Time) []string {
+	var nodes []string
+
+	for node, users := range bus.users {
+		if _, ok := users[userId]; ok && bus.live(node, now) {
+			nodes = append(nodes, node)
+		}
+	}
+
+	sort.Strings(nodes)
+
+	return nodes
+}
+
+// state calcula a situação do usuário no cluster; exige bus.mu.
+func (bus *MemoryBus) state(userId string, now time.Time) State {
+	var state State
+
+	idle := true
+	for _, node := range bus.holders(userId, now) {
+		state.Online = true
+		idle = idle && bus.users[node][userId].idle
+	}
+
+	state.Idle = state.Online && idle
+
+	return state
+}
+
+type MemoryBroker struct {
+	bus  *MemoryBus
+	node string
+
+	mu       sync.RWMutex
+	handlers []func(Envelope)
+}
+
+func (b *MemoryBroker) Node() string {
+	return b.node
+}
+
+func (b *MemoryBroker) deliver(envelope Envelope) {
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(envelope)
+	}
+}
+
+func (b *MemoryBroker) others() []*MemoryBroker {
+	b.bus.mu.Lock()
+	defer b.bus.mu.Unlock()
+
+	var brokers []*MemoryBroker
+	for node, broker := range b.bus.brokers {
+		if node != b.node {
+			brokers = append(brokers, broker)
+		}
+	}
+
+	return brokers
+}
+
+func (b *MemoryBroker) Publish(envelope Envelope) error {
+	envelope.Node = b.node
+
+	for _, other := range b.others() {
+		other.deliver(envelope)
+	}
+
+	return nil
+}
+
+func (b *MemoryBroker) Send(node string, envelope Envelope) error {
+	envelope.Node = b.node
+
+	b.bus.mu.Lock()
+	other, ok := b.bus.brokers[node]
+	b.bus.mu.Unlock()
+
+	if ok && other != b {
+		other.deliver(envelope)
+	}
+
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(handler func(Envelope)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = append(b.handlers, handler)
+}
+
+// change aplica a mudança nas entradas deste nó e devolve a transição.
+func (b *MemoryBroker) change(userId string, apply func(users map[string]entry)) Transition {
+	b.bus.mu.Lock()
+	defer b.bus.mu.Unlock()
+
+	now := time.Now()
+	before := b.bus.state(userId, now)
+
+	if b.bus.users[b.node] == nil {
+		b.bus.users[b.node] = make(map[string]entry)
+	}
+
+	apply(b.bus.users[b.node])
+
+	return Transition{Before: before, After: b.bus.state(userId, now)}
+}
+
+func (b *MemoryBroker) Claim(userId string, bot bool) (Transition, error) {
+	return b.change(userId, func(users map[string]entry) {
+		users[userId] = entry{bot: bot}
+	}), nil
+}
+
+func (b *MemoryBroker) Release(userId string) (Transition, error) {
+	return b.change(userId, func(users map[string]entry) {
+		delete(users, userId)
+	}), nil
+}
+
+func (b *MemoryBroker) SetIdle(userId string, idle bool) (Transition, error) {
+	return b.change(userId, func(users map[string]entry) {
+		if current, ok := users[userId]; ok {
+			current.idle = idle
+			users[userId] = current
+		}
+	}), nil
+}
+
+func (b *MemoryBroker) States(userIds []string) (map[string]State, error) {
+	b.bus.mu.Lock()
+	defer b.bus.mu.Unlock()
+
+	now := time.Now()
+
+	states := make(map[string]State, len(userIds))
+	for _, userId := range userIds {
+		states[userId] = b.bus.state(userId, now)
+	}
+
+	return states, nil
+}
+
+func (b *MemoryBroker) Nodes(userId string) ([]string, error) {
+	b.bus.mu.Lock()
+	defer b.bus.mu.Unlock()
+
+	return b.bus.holders(userId, time.Now()), nil
+}
+
+func (b *MemoryBroker) Online() ([]Presence, error) {
+	b.bus.mu.Lock()
+	defer b.bus.mu.Unlock()
+
+	now := time.Now()
+	online := make(map[string]Presence)
+
+	for node, users := range b.bus.users {
+		if !b.bus.live(node, now) {
+			continue
+		}
+
+		for userId, current := range users {
+			presence, seen := online[userId]
+			if !seen {
+				presence = Presence{UserId: userId, Idle: true}
+			}
+
+			presence.Bot = presence.Bot || current.bot
+			presence.Idle = presence.Idle && current.idle
+			online[userId] = presence
+		}
+	}
+
+	presences := make([]Presence, 0, len(online))
+	for _, presence := range online {
+		presences = append(presences, presence)
+	}
+
+	sort.Slice(presences, func(i, j int) bool { return presences[i].UserId < presences[j].UserId })
+
+	return presences, nil
+}
+
+func (b *MemoryBroker) Heartbeat() (Heartbeat, error) {
+	b.bus.mu.Lock()
+	defer b.bus.mu.Unlock()
+
+	var result Heartbeat
+	now := time.Now()
+
+	if _, ok := b.bus.leases[b.node]; !ok {
+		result.Expired = true
+		b.bus.users[b.node] = make(map[string]entry)
+	}
+
+	b.bus.leases[b.node] = now.Add(b.bus.leaseTTL)
+
+	for node, expires := range b.bus.leases {
+		if expires.After(now) {
+			continue
+		}
+
+		users := b.bus.users[node]
+		delete(b.bus.leases, node)
+		delete(b.bus.users, node)
+
+		for userId, current := range users {
+			if len(b.bus.holders(userId, now)) == 0 {
+				result.Offline = append(result.Offline, Presence{UserId: userId, Bot: current.bot})
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// Close desliga o nó do barramento. As presenças dele saem quando a
+// concessão vencer, como se o nó tivesse caído.
+func (b *MemoryBroker) Close() error {
+	b.bus.mu.Lock()
+	defer b.bus.mu.Unlock()
+
+	delete(b.bus.brokers, b.node)
+
+	return nil
+}
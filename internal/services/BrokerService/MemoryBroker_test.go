@@ -0,0 +1,23 @@
+package brokerService
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryBroker(t *testing.T) {
+	testBroker(t, func(t *testing.T) cluster {
+		bus := NewMemoryBus(time.Minute)
+
+		return cluster{
+			a: bus.Node("a"),
+			b: bus.Node("b"),
+			expire: func(node string) {
+				bus.mu.Lock()
+				defer bus.mu.Unlock()
+
+				bus.leases[node] = time.Now().Add(-time.Second)
+			},
+		}
+	})
+}
@@ -0,0 +1,480 @@
+package brokerService
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// requestTimeout limita cada comando enviado ao Redis.
+const requestTimeout = 5 * time.Second
+
+// O registro de presença no Redis usa três estruturas:
+//
+//	<prefixo>nodes        zset nó → fim da concessão (ms)
+//	<prefixo>node:<nó>    hash usuário → entrada: dois caracteres, "1" ou
+//	                      "0" para bot e para ocioso
+//	<prefixo>user:<id>    set dos nós com conexões do usuário
+//
+// Os scripts mantêm as três coerentes e só contam os nós com a concessão em
+// dia. Eles montam chaves a partir do prefixo, então pedem um Redis sem
+// cluster.
+
+// presenceLua tem as funções comuns aos scripts: os nós vivos de um usuário
+// e a situação dele no cluster ({online, ocioso}).
+const presenceLua = `
+local function live_holders(user_key, nodes_key, except, now)
+	local live = {}
+	for _, node in ipairs(redis.call('SMEMBERS', user_key)) do
+		local expires = redis.call('ZSCORE', nodes_key, node)
+		if node ~= except and expires and tonumber(expires) > now then
+			table.insert(live, node)
+		end
+	end
+	return live
+end
+
+local function user_state(user, user_key, nodes_key, prefix, now)
+	local online, idle = 0, 1
+	for _, node in ipairs(live_holders(user_key, nodes_key, '', now)) do
+		local entry = redis.call('HGET', prefix .. 'node:' .. node, user)
+		if entry then
+			online = 1
+			if string.sub(entry, 2, 2) ~= '1' then
+				idle = 0
+			end
+		end
+	end
+	if online == 0 then
+		idle = 0
+	end
+	return {online, idle}
+end
+`
+
+// changeLua envolve uma mudança na entrada do usuário neste nó e devolve a
+// transição {online antes, ocioso antes, online depois, ocioso depois}.
+//
+// KEYS: hash do nó, set do usuário, zset dos nós.
+// ARGV: nó, usuário, agora, prefixo, argumento da mudança.
+func changeLua(change string) *redis.Script {
+	return redis.NewScript(presenceLua + `
+local now = tonumber(ARGV[3])
+local before = user_state(ARGV[2], KEYS[2], KEYS[3], ARGV[4], now)
+` + change + `
+local after = user_state(ARGV[2], KEYS[2], KEYS[3], ARGV[4], now)
+return {before[1], before[2], after[1], after[2]}
+`)
+}
+
+// ARGV[5]: "1" se o usuário for bot.
+var claimScript = changeLua(`
+redis.call('HSET', KEYS[1], ARGV[2], ARGV[5] .. '0')
+redis.call('SADD', KEYS[2], ARGV[1])
+`)
+
+var releaseScript = changeLua(`
+redis.call('HDEL', KEYS[1], ARGV[2])
+redis.call('SREM', KEYS[2], ARGV[1])
+`)
+
+// ARGV[5]: "1" para ocioso, "0" para ativo.
+var idleScript = changeLua(`
+local entry = redis.call('HGET', KEYS[1], ARGV[2])
+if entry then
+	redis.call('HSET', KEYS[1], ARGV[2], string.sub(entry, 1, 1) .. ARGV[5])
+end
+`)
+
+// KEYS: set do usuário, zset dos nós. ARGV: usuário, agora, prefixo.
+var stateScript = redis.NewScript(presenceLua + `
+return user_state(ARGV[1], KEYS[1], KEYS[2], ARGV[3], tonumber(ARGV[2]))
+`)
+
+// KEYS: set do usuário, zset dos nós. ARGV: agora.
+var nodesScript = redis.NewScript(presenceLua + `
+return live_holders(KEYS[1], KEYS[2], '', tonumber(ARGV[1]))
+`)
+
+// reapScript remove um nó vencido e devolve, em pares usuário/entrada, os
+// usuários que não estão em nenhum outro nó vivo. Só um nó consegue remover
+// cada nó vencido, então só ele anuncia esses usuários como offline.
+//
+// KEYS: zset dos nós, hash do nó. ARGV: nó, agora, prefixo.
+var reapScript = redis.NewScript(presenceLua + `
+local expires = redis.call('ZSCORE', KEYS[1], ARGV[1])
+local now = tonumber(ARGV[2])
+if expires and tonumber(expires) > now then
+	return {}
+end
+redis.call('ZREM', KEYS[1], ARGV[1])
+local offline = {}
+local entries = redis.call('HGETALL', KEYS[2])
+for i = 1, #entries, 2 do
+	local user_key = ARGV[3] .. 'user:' .. entries[i]
+	redis.call('SREM', user_key, ARGV[1])
+	if #live_holders(user_key, KEYS[1], '', now) == 0 then
+		table.insert(offline, entries[i])
+		table.insert(offline, entries[i + 1])
+	end
+end
+redis.call('DEL', KEYS[2])
+return offline
+`)
+
+// RedisBroker repassa os envelopes por Pub/Sub, em um canal comum a todos os
+// nós e em um canal de cada nó, e guarda o registro de presença.
+type RedisBroker struct {
+	client   *redis.Client
+	node     string
+	prefix   string
+	leaseTTL time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.RWMutex
+	handlers []func(Envelope)
+	pubsub   *redis.PubSub
+}
+
+func NewRedisBroker(node, url, prefix string, leaseTTL time.Duration) (*RedisBroker, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("REDIS_URL inválida: %v", err)
+	}
+
+	b := &RedisBroker{client: redis.NewClient(options), node: node, prefix: prefix, leaseTTL: leaseTTL}
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+
+	ctx, cancel := b.timeout()
+	defer cancel()
+
+	if err := b.client.Ping(ctx).Err(); err != nil {
+		b.Close()
+		return nil, fmt.Errorf("erro ao conectar ao Redis: %v", err)
+	}
+
+	// Um nó que reinicia com o mesmo NODE_ID não herda as presenças antigas
+	if offline, err := b.reap(node, math.MaxInt64); err != nil {
+		b.Close()
+		return nil, fmt.Errorf("erro ao limpar a presença anterior do nó: %v", err)
+	} else if len(offline) > 0 {
+		log.Printf("Removidas %d presenças de uma execução anterior do nó %s", len(offline), node)
+	}
+
+	if err := b.client.ZAdd(ctx, b.key("nodes"), redis.Z{Score: float64(b.expiry()), Member: node}).Err(); err != nil {
+		b.Close()
+		return nil, fmt.Errorf("erro ao registrar o nó: %v", err)
+	}
+
+	b.pubsub = b.client.Subscribe(b.ctx, b.key("events"), b.key("events:"+node))
+	if _, err := b.pubsub.Receive(ctx); err != nil {
+		b.Close()
+		return nil, fmt.Errorf("erro ao assinar os canais do broker: %v", err)
+	}
+
+	go b.listen()
+
+	return b, nil
+}
+
+func (b *RedisBroker) key(name string) string {
+	return b.prefix + name
+}
+
+func (b *RedisBroker) timeout() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(b.ctx, requestTimeout)
+}
+
+func now() int64 {
+	return time.Now().UnixMilli()
+}
+
+func (b *RedisBroker) expiry() int64 {
+	return time.Now().Add(b.leaseTTL).UnixMilli()
+}
+
+// listen entrega aos handlers os envelopes publicados pelos outros nós. O
+// canal do go-redis reconecta sozinho se a conexão cair.
+func (b *RedisBroker) listen() {
+	for message := range b.pubsub.Channel() {
+		var envelope Envelope
+		if err := json.Unmarshal([]byte(message.Payload), &envelope); err != nil {
+			log.Printf("Envelope inválido no broker: %v", err)
+			continue
+		}
+
+		if envelope.Node == b.node {
+			continue
+		}
+
+		b.mu.RLock()
+		handlers := b.handlers
+		b.mu.RUnlock()
+
+		for _, handler := range handlers {
+			handler(envelope)
+		}
+	}
+}
+
+func (b *RedisBroker) Node() string {
+	return b.node
+}
+
+func (b *RedisBroker) publish(channel string, envelope Envelope) error {
+	envelope.Node = b.node
+
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := b.timeout()
+	defer cancel()
+
+	return b.client.Publish(ctx, channel, data).Err()
+}
+
+func (b *RedisBroker) Publish(envelope Envelope) error {
+	return b.publish(b.key("events"), envelope)
+}
+
+func (b *RedisBroker) Send(node string, envelope Envelope) error {
+	return b.publish(b.key("events:"+node), envelope)
+}
+
+func (b *RedisBroker) Subscribe(handler func(Envelope)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = append(b.handlers, handler)
+}
+
+// change roda um script de mudança na entrada do usuário neste nó.
+func (b *RedisBroker) change(script *redis.Script, userId, arg string) (Transition, error) {
+	ctx, cancel := b.timeout()
+	defer cancel()
+
+	keys := []string{b.key("node:" + b.node), b.key("user:" + userId), b.key("nodes")}
+
+	values, err := script.Run(ctx, b.client, keys, b.node, userId, now(), b.prefix, arg).Int64Slice()
+	if err != nil {
+		return Transition{}, err
+	}
+
+	if len(values) != 4 {
+		return Transition{}, fmt.Errorf("resposta inesperada do registro de presença: %v", values)
+	}
+
+	return Transition{
+		Before: State{Online: values[0] == 1, Idle: values[1] == 1},
+		After:  State{Online: values[2] == 1, Idle: values[3] == 1},
+	}, nil
+}
+
+func flag(value bool) string {
+	if value {
+		return "1"
+	}
+
+	return "0"
+}
+
+func (b *RedisBroker) Claim(userId string, bot bool) (Transition, error) {
+	return b.change(claimScript, userId, flag(bot))
+}
+
+func (b *RedisBroker) Release(userId string) (Transition, error) {
+	return b.change(releaseScript, userId, "")
+}
+
+func (b *RedisBroker) SetIdle(userId string, idle bool) (Transition, error) {
+	return b.change(idleScript, userId, flag(idle))
+}
+
+func (b *RedisBroker) States(userIds []string) (map[string]State, error) {
+	states := make(map[string]State, len(userIds))
+	if len(userIds) == 0 {
+		return states, nil
+	}
+
+	ctx, cancel := b.timeout()
+	defer cancel()
+
+	// Garante o script no cache do Redis para usar EVALSHA no pipeline
+	if err := stateScript.Load(ctx, b.client).Err(); err != nil {
+		return nil, err
+	}
+
+	at := now()
+	pipe := b.client.Pipeline()
+
+	cmds := make([]*redis.Cmd, len(userIds))
+	for i, userId := range userIds {
+		cmds[i] = stateScript.EvalSha(ctx, pipe, []string{b.key("user:" + userId), b.key("nodes")}, userId, at, b.prefix)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	for i, cmd := range cmds {
+		values, err := cmd.Int64Slice()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(values) != 2 {
+			return nil, fmt.Errorf("resposta inesperada do registro de presença: %v", values)
+		}
+
+		states[userIds[i]] = State{Online: values[0] == 1, Idle: values[1] == 1}
+	}
+
+	return states, nil
+}
+
+func (b *RedisBroker) Nodes(userId string) ([]string, error) {
+	ctx, cancel := b.timeout()
+	defer cancel()
+
+	nodes, err := nodesScript.Run(ctx, b.client, []string{b.key("user:" + userId), b.key("nodes")}, now()).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(nodes)
+
+	return nodes, nil
+}
+
+func (b *RedisBroker) Online() ([]Presence, error) {
+	ctx, cancel := b.timeout()
+	defer cancel()
+
+	nodes, err := b.client.ZRangeByScore(ctx, b.key("nodes"), &redis.ZRangeBy{
+		Min: "(" + strconv.FormatInt(now(), 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := b.client.Pipeline()
+
+	entries := make([]*redis.MapStringStringCmd, len(nodes))
+	for i, node := range nodes {
+		entries[i] = pipe.HGetAll(ctx, b.key("node:"+node))
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil && len(nodes) > 0 {
+		return nil, err
+	}
+
+	online := make(map[string]Presence)
+	for _, cmd := range entries {
+		for userId, value := range cmd.Val() {
+			presence, seen := online[userId]
+			if !seen {
+				presence = Presence{UserId: userId, Idle: true}
+			}
+
+			presence.Bot = presence.Bot || strings.HasPrefix(value, "1")
+			presence.Idle = presence.Idle && strings.HasSuffix(value, "1") && len(value) == 2
+			online[userId] = presence
+		}
+	}
+
+	presences := make([]Presence, 0, len(online))
+	for _, presence := range online {
+		presences = append(presences, presence)
+	}
+
+	sort.Slice(presences, func(i, j int) bool { return presences[i].UserId < presences[j].UserId })
+
+	return presences, nil
+}
+
+// reap remove o nó se a concessão dele venceu antes de at (ms) e devolve os
+// usuários que ficaram offline.
+func (b *RedisBroker) reap(node string, at int64) ([]Presence, error) {
+	ctx, cancel := b.timeout()
+	defer cancel()
+
+	keys := []string{b.key("nodes"), b.key("node:" + node)}
+
+	pairs, err := reapScript.Run(ctx, b.client, keys, node, at, b.prefix).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	var offline []Presence
+	for i := 0; i+1 < len(pairs); i += 2 {
+		offline = append(offline, Presence{UserId: pairs[i], Bot: strings.HasPrefix(pairs[i+1], "1")})
+	}
+
+	return offline, nil
+}
+
+func (b *RedisBroker) Heartbeat() (Heartbeat, error) {
+	var result Heartbeat
+
+	ctx, cancel := b.timeout()
+	defer cancel()
+
+	// ZADD devolve 1 quando o nó não estava no zset: outro nó o removeu
+	added, err := b.client.ZAdd(ctx, b.key("nodes"), redis.Z{Score: float64(b.expiry()), Member: b.node}).Result()
+	if err != nil {
+		return result, err
+	}
+
+	result.Expired = added == 1
+
+	at := now()
+
+	expired, err := b.client.ZRangeByScore(ctx, b.key("nodes"), &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(at, 10),
+	}).Result()
+	if err != nil {
+		return result, err
+	}
+
+	for _, node := range expired {
+		offline, err := b.reap(node, at)
+		if err != nil {
+			return result, err
+		}
+
+		if len(offline) > 0 {
+			log.Printf("Nó %s sem concessão removido do registro de presença", node)
+		}
+
+		result.Offline = append(result.Offline, offline...)
+	}
+
+	return result, nil
+}
+
+// Close desliga o nó do Redis. As presenças dele saem quando a concessão
+// vencer, como se o nó tivesse caído.
+func (b *RedisBroker) Close() error {
+	b.cancel()
+
+	if b.pubsub != nil {
+		b.pubsub.Close()
+	}
+
+	return b.client.Close()
+}
@@ -0,0 +1,68 @@
+package brokerService
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestRedisBroker(t *testing.T) {
+	testBroker(t, func(t *testing.T) cluster {
+		server := miniredis.RunT(t)
+		url := "redis://" + server.Addr() + "/0"
+
+		newNode := func(node string) *RedisBroker {
+			broker, err := NewRedisBroker(node, url, "test:", time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			t.Cleanup(func() { broker.Close() })
+
+			return broker
+		}
+
+		return cluster{
+			a: newNode("a"),
+			b: newNode("b"),
+			expire: func(node string) {
+				if _, err := server.ZAdd("test:nodes", 0, node); err != nil {
+					t.Fatal(err)
+				}
+			},
+		}
+	})
+}
+
+func TestRedisBrokerRestartDropsStalePresence(t *testing.T) {
+	server := miniredis.RunT(t)
+	url := "redis://" + server.Addr() + "/0"
+
+	first, err := NewRedisBroker("a", url, "test:", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := first.Claim("u1", false); err != nil {
+		t.Fatal(err)
+	}
+
+	first.Close()
+
+	// Mesmo NODE_ID: a presença da execução anterior não pode sobreviver.
+	second, err := NewRedisBroker("a", url, "test:", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+
+	states, err := second.States([]string{"u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if states["u1"].Online {
+		t.Fatal("presença da execução anterior continuou no registro")
+	}
+}
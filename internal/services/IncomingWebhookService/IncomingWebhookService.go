@@ -0,0 +1,174 @@
+package incomingWebhookService
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"go-web-socket/config"
+	"go-web-socket/internal/models"
+	incomingWebhookRepository "go-web-socket/internal/repositories/IncomingWebhookRepository"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	"go-web-socket/internal/utils/audit"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidHook    = errors.New("webhook de entrada inválido")
+	ErrInvalidIconURL = errors.New("icon_url deve ser uma URL http(s)")
+	ErrHookNotFound   = incomingWebhookRepository.ErrIncomingWebhookNotFound
+)
+
+// touchInterval limita com que frequência o último uso é gravado.
+const touchInterval = time.Minute
+
+type Config struct {
+	// BaseURL é o endereço público da rota POST /hooks, usado para montar a
+	// URL entregue a quem cria o webhook.
+	BaseURL string
+}
+
+func LoadConfig() Config {
+	config.LoadEnv()
+
+	return Config{
+		BaseURL: strings.TrimRight(config.GetEnv("INCOMING_WEBHOOK_BASE_URL", "http://localhost:8080/hooks"), "/"),
+	}
+}
+
+type IncomingWebhookService struct {
+	cfg   Config
+	hooks incomingWebhookRepository.IncomingWebhookRepository
+	users userRepository.UserRepository
+}
+
+func New(cfg Config, hooks incomingWebhookRepository.IncomingWebhookRepository, users userRepository.UserRepository) *IncomingWebhookService {
+	return &IncomingWebhookService{cfg: cfg, hooks: hooks, users: users}
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func random(size int) ([]byte, error) {
+	raw := make([]byte, size)
+	_, err := rand.Read(raw)
+
+	return raw, err
+}
+
+func validIconURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+
+	return err == nil && parsed.Host != "" && (parsed.Scheme == "https" || parsed.Scheme == "http")
+}
+
+// Create cadastra um webhook de entrada da sala, ou pessoal com roomId vazio,
+// e devolve a URL secreta, que só aparece aqui.
+func (s *IncomingWebhookService) Create(ownerId, roomId, name, iconURL string) (models.IncomingWebhook, string, error) {
+	if iconURL != "" && !validIconURL(iconURL) {
+		return models.IncomingWebhook{}, "", ErrInvalidIconURL
+	}
+
+	id, err := random(8)
+	if err != nil {
+		return models.IncomingWebhook{}, "", err
+	}
+
+	secret, err := random(24)
+	if err != nil {
+		return models.IncomingWebhook{}, "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	hook := models.IncomingWebhook{
+		HookId:    hex.EncodeToString(id),
+		TokenHash: hashToken(token),
+		RoomId:    roomId,
+		OwnerId:   ownerId,
+		Name:      name,
+		IconURL:   iconURL,
+	}
+
+	if err := s.hooks.Create(&hook); err != nil {
+		return models.IncomingWebhook{}, "", err
+	}
+
+	audit.Log("incoming_webhook.created", map[string]interface{}{
+		"hook_id": hook.HookId,
+		"room_id": roomId,
+		"user_id": ownerId,
+		"name":    name,
+	})
+
+	return hook, s.cfg.BaseURL + "/" + hook.HookId + "/" + token, nil
+}
+
+func (s *IncomingWebhookService) ListByRoom(roomId string) ([]models.IncomingWebhook, error) {
+	return s.hooks.ListByRoom(roomId)
+}
+
+func (s *IncomingWebhookService) ListPersonal(userId string) ([]models.IncomingWebhook, error) {
+	return s.hooks.ListPersonal(userId)
+}
+
+func (s *IncomingWebhookService) Find(hookId string) (models.IncomingWebhook, error) {
+	return s.hooks.FindByHookId(hookId)
+}
+
+func (s *IncomingWebhookService) Delete(hookId, userId string) error {
+	if err := s.hooks.Delete(hookId); err != nil {
+		return err
+	}
+
+	audit.Log("incoming_webhook.deleted", map[string]interface{}{"hook_id": hookId, "user_id": userId})
+
+	return nil
+}
+
+// Authenticate confere o token da URL. Webhook inexistente, token errado e
+// dono desativado dão o mesmo erro.
+func (s *IncomingWebhookService) Authenticate(hookId, token string) (models.IncomingWebhook, error) {
+	hook, err := s.hooks.FindByHookId(hookId)
+	if errors.Is(err, incomingWebhookRepository.ErrIncomingWebhookNotFound) {
+		return hook, ErrInvalidHook
+	}
+
+	if err != nil {
+		return hook, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashToken(token)), []byte(hook.TokenHash)) != 1 {
+		return hook, ErrInvalidHook
+	}
+
+	owner, err := s.users.FindByUserId(hook.OwnerId)
+	if errors.Is(err, userRepository.ErrUserNotFound) {
+		return hook, ErrInvalidHook
+	}
+
+	if err != nil {
+		return hook, err
+	}
+
+	if owner.Disabled {
+		return hook, ErrInvalidHook
+	}
+
+	now := time.Now()
+
+	if hook.LastUsedAt == nil || now.Sub(*hook.LastUsedAt) > touchInterval {
+		if err := s.hooks.Touch(hook.HookId, now); err != nil {
+			log.Printf("Erro ao registrar uso do webhook de entrada %s: %v", hook.HookId, err)
+		}
+	}
+
+	return hook, nil
+}
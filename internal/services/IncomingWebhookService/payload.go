@@ -0,0 +1,195 @@
+package incomingWebhookService
+
+import (
+	"encoding/json"
+	"errors"
+	"go-web-socket/internal/models"
+	"html"
+	"mime"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxTextLength limita o tamanho da mensagem gerada a partir do payload.
+const MaxTextLength = 4000
+
+var (
+	ErrInvalidPayload = errors.New("payload inválido")
+	ErrNoText         = errors.New("o payload não tem texto")
+	ErrTextTooLong    = errors.New("o texto passa do limite de 4000 caracteres")
+)
+
+// Payload aceita o formato simples ({"text": ...} ou {"message": ...}) e o
+// subconjunto mais usado dos webhooks de entrada do Slack.
+type Payload struct {
+	Text        string       `json:"text"`
+	Message     string       `json:"message"`
+	Username    string       `json:"username"`
+	IconURL     string       `json:"icon_url"`
+	Attachments []Attachment `json:"attachments"`
+	Blocks      []Block      `json:"blocks"`
+}
+
+type Attachment struct {
+	Fallback  string            `json:"fallback"`
+	Pretext   string            `json:"pretext"`
+	Title     string            `json:"title"`
+	TitleLink string            `json:"title_link"`
+	Text      string            `json:"text"`
+	Fields    []AttachmentField `json:"fields"`
+	Footer    string            `json:"footer"`
+}
+
+type AttachmentField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+type Block struct {
+	Type     string      `json:"type"`
+	Text     *BlockText  `json:"text"`
+	Fields   []BlockText `json:"fields"`
+	Elements []BlockText `json:"elements"`
+}
+
+type BlockText struct {
+	Text string `json:"text"`
+}
+
+// ParsePayload lê o corpo como JSON ou, como o Slack também aceita, como
+// formulário com o JSON no campo "payload". Formulário sem esse campo é lido
+// como JSON, já que é o que "curl -d" envia.
+func ParsePayload(contentType string, body []byte) (Payload, error) {
+	var payload Payload
+
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "application/x-www-form-urlencoded" {
+		if form, err := url.ParseQuery(string(body)); err == nil && form.Has("payload") {
+			body = []byte(form.Get("payload"))
+		}
+	}
+
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return payload, ErrInvalidPayload
+	}
+
+	return payload, nil
+}
+
+// Render monta o texto da mensagem a partir do payload.
+func Render(payload Payload) (string, error) {
+	var parts []string
+
+	add := func(texts ...string) {
+		for _, text := range texts {
+			if text = strings.TrimSpace(text); text != "" {
+				parts = append(parts, text)
+			}
+		}
+	}
+
+	add(payload.Text)
+	if payload.Text == "" {
+		add(payload.Message)
+	}
+
+	for _, block := range payload.Blocks {
+		if block.Text != nil {
+			add(block.Text.Text)
+		}
+
+		for _, field := range block.Fields {
+			add(field.Text)
+		}
+
+		var elements []string
+		for _, element := range block.Elements {
+			if element.Text != "" {
+				elements = append(elements, element.Text)
+			}
+		}
+		add(strings.Join(elements, " "))
+	}
+
+	for _, attachment := range payload.Attachments {
+		before := len(parts)
+
+		title := attachment.Title
+		if title != "" && attachment.TitleLink != "" {
+			title += " (" + attachment.TitleLink + ")"
+		}
+
+		add(attachment.Pretext, title, attachment.Text)
+
+		for _, field := range attachment.Fields {
+			if field.Title == "" {
+				add(field.Value)
+			} else {
+				add(field.Title + ": " + field.Value)
+			}
+		}
+
+		add(attachment.Footer)
+
+		if len(parts) == before {
+			add(attachment.Fallback)
+		}
+	}
+
+	text := formatSlackMarkup(strings.Join(parts, "\n"))
+
+	if text == "" {
+		return "", ErrNoText
+	}
+
+	if utf8.RuneCountInString(text) > MaxTextLength {
+		return "", ErrTextTooLong
+	}
+
+	return text, nil
+}
+
+var slackLinkPattern = regexp.MustCompile(`<([^<>|]+)(?:\|([^<>]*))?>`)
+
+// formatSlackMarkup troca os links e menções do Slack (<url|texto>, <!here>,
+// <@U123>) por texto simples e desfaz os escapes &amp; &lt; &gt;.
+func formatSlackMarkup(text string) string {
+	text = slackLinkPattern.ReplaceAllStringFunc(text, func(match string) string {
+		groups := slackLinkPattern.FindStringSubmatch(match)
+		target, label := groups[1], groups[2]
+
+		switch {
+		case strings.HasPrefix(target, "!"):
+			return "@" + strings.TrimPrefix(target, "!")
+		case strings.HasPrefix(target, "@"), strings.HasPrefix(target, "#"):
+			if label != "" {
+				return target[:1] + label
+			}
+
+			return target
+		case label != "":
+			return label + " (" + target + ")"
+		default:
+			return target
+		}
+	})
+
+	return html.UnescapeString(text)
+}
+
+// Sender devolve o nome e o ícone exibidos na mensagem: os do payload, quando
+// informados, ou os cadastrados no webhook.
+func Sender(hook models.IncomingWebhook, payload Payload) (string, string) {
+	name, iconURL := hook.Name, hook.IconURL
+
+	if username := strings.TrimSpace(payload.Username); username != "" && utf8.RuneCountInString(username) <= 100 {
+		name = username
+	}
+
+	if payload.IconURL != "" && validIconURL(payload.IconURL) {
+		iconURL = payload.IconURL
+	}
+
+	return name, iconURL
+}
@@ -0,0 +1,125 @@
+package incomingWebhookService
+
+import (
+	"errors"
+	"go-web-socket/internal/models"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParsePayload(t *testing.T) {
+	form := url.Values{"payload": {`{"text":"deploy ok","username":"ci"}`}}.Encode()
+
+	tests := []struct {
+		name, contentType, body string
+		want                    string
+	}{
+		{"json", "application/json", `{"text":"deploy ok","username":"ci"}`, "deploy ok"},
+		{"formulário do Slack", "application/x-www-form-urlencoded; charset=utf-8", form, "deploy ok"},
+		{"curl -d sem o campo payload", "application/x-www-form-urlencoded", `{"message":"deploy ok"}`, "deploy ok"},
+	}
+
+	for _, test := range tests {
+		payload, err := ParsePayload(test.contentType, []byte(test.body))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if text, _ := Render(payload); text != test.want {
+			t.Errorf("%s: esperava %q, veio %q", test.name, test.want, text)
+		}
+	}
+
+	if _, err := ParsePayload("application/json", []byte("texto solto")); !errors.Is(err, ErrInvalidPayload) {
+		t.Fatalf("esperava ErrInvalidPayload, veio %v", err)
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			"text tem prioridade sobre message",
+			`{"text":"a","message":"b"}`,
+			"a",
+		},
+		{
+			"links, menções e escapes do Slack",
+			`{"text":"<!here> <@U123|ana> veja <https://ci.example.com/42|o build> em <#C1|deploys>: 1 &lt; 2 &amp;&amp; <https://x.example.com>"}`,
+			"@here @ana veja o build (https://ci.example.com/42) em #deploys: 1 < 2 && https://x.example.com",
+		},
+		{
+			"blocks",
+			`{"blocks":[{"type":"header","text":{"text":"Deploy"}},{"type":"section","fields":[{"text":"*Env:* prod"},{"text":"*Versão:* 1.2"}]},{"type":"context","elements":[{"text":"por"},{"text":"ci"}]}]}`,
+			"Deploy\n*Env:* prod\n*Versão:* 1.2\npor ci",
+		},
+		{
+			"attachments",
+			`{"text":"Alerta","attachments":[{"pretext":"CPU alta","title":"web-1","title_link":"https://grafana.example.com","text":"95%","fields":[{"title":"Região","value":"sa-east-1"},{"value":"sem título"}],"footer":"grafana"}]}`,
+			"Alerta\nCPU alta\nweb-1 (https://grafana.example.com)\n95%\nRegião: sa-east-1\nsem título\ngrafana",
+		},
+		{
+			"fallback só sem outro conteúdo",
+			`{"attachments":[{"fallback":"resumo"},{"fallback":"ignorado","text":"detalhe"}]}`,
+			"resumo\ndetalhe",
+		},
+	}
+
+	for _, test := range tests {
+		payload, err := ParsePayload("application/json", []byte(test.body))
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		text, err := Render(payload)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if text != test.want {
+			t.Errorf("%s: esperava %q, veio %q", test.name, test.want, text)
+		}
+	}
+}
+
+func TestRenderRejectsEmptyAndLongTexts(t *testing.T) {
+	if _, err := Render(Payload{Text: "  ", Attachments: []Attachment{{}}}); !errors.Is(err, ErrNoText) {
+		t.Fatalf("esperava ErrNoText, veio %v", err)
+	}
+
+	if _, err := Render(Payload{Text: strings.Repeat("é", MaxTextLength)}); err != nil {
+		t.Fatalf("o limite é em caracteres, não em bytes: %v", err)
+	}
+
+	if _, err := Render(Payload{Text: strings.Repeat("a", MaxTextLength+1)}); !errors.Is(err, ErrTextTooLong) {
+		t.Fatalf("esperava ErrTextTooLong, veio %v", err)
+	}
+}
+
+func TestSender(t *testing.T) {
+	hook := models.IncomingWebhook{Name: "Alertas", IconURL: "https://example.com/hook.png"}
+
+	tests := []struct {
+		name               string
+		payload            Payload
+		wantName, wantIcon string
+	}{
+		{"sem personalização", Payload{}, "Alertas", "https://example.com/hook.png"},
+		{"nome e ícone do payload", Payload{Username: " ci ", IconURL: "https://example.com/ci.png"}, "ci", "https://example.com/ci.png"},
+		{"ícone inválido", Payload{IconURL: "javascript:alert(1)"}, "Alertas", "https://example.com/hook.png"},
+		{"nome longo demais", Payload{Username: strings.Repeat("a", 101)}, "Alertas", "https://example.com/hook.png"},
+	}
+
+	for _, test := range tests {
+		name, icon := Sender(hook, test.payload)
+		if name != test.wantName || icon != test.wantIcon {
+			t.Errorf("%s: esperava %q e %q, veio %q e %q", test.name, test.wantName, test.wantIcon, name, icon)
+		}
+	}
+}
@@ -2,76 +2,184 @@ package jwtService
 
 import (
 	"fmt"
-	"os"
+	"go-web-socket/config"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-var secretKey = []byte(os.Getenv("SECRET_KEY"))
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
 
 type UserToken struct {
-	UserId   string
-	Name     string
-	Username string
-	Avatar   *string
+	UserId    string
+	Name      string
+	Username  string
+	Avatar    *string
+	SessionId string
+	TokenId   string // jti, usado para revogar um token específico
+	ExpiresAt time.Time
+	Roles     []string
+	// Scopes vazio significa acesso completo do usuário; tokens de
+	// integração recebem apenas os escopos concedidos.
+	Scopes []string
+	// APIKeyId é preenchido quando a requisição foi autenticada por uma
+	// chave de API em vez de um token de acesso.
+	APIKeyId string
+}
+
+func (u *UserToken) HasRole(role string) bool {
+	return slices.Contains(u.Roles, role)
+}
+
+func (u *UserToken) HasScope(scope string) bool {
+	return len(u.Scopes) == 0 || slices.Contains(u.Scopes, scope)
+}
+
+// Claims é o payload dos tokens de acesso. O subject é o user_id; user_id
+// continua no payload porque o frontend lê esse campo.
+type Claims struct {
+	UserId    string   `json:"user_id"`
+	Name      string   `json:"name"`
+	Username  string   `json:"username"`
+	Avatar    string   `json:"avatar,omitempty"`
+	SessionId string   `json:"sid"`
+	Roles     []string `json:"roles,omitempty"`
+	Scopes    []string `json:"scopes,omitempty"`
+	jwt.RegisteredClaims
+}
+
+func issuer() string {
+	config.LoadEnv()
+
+	return config.GetEnv("JWT_ISSUER", "go-web-socket")
+}
+
+func audience() string {
+	config.LoadEnv()
+
+	return config.GetEnv("JWT_AUDIENCE", "go-web-socket")
+}
+
+func parserOptions(aud string) []jwt.ParserOption {
+	return []jwt.ParserOption{
+		jwt.WithValidMethods([]string{
+			jwt.SigningMethodRS256.Alg(),
+			jwt.SigningMethodEdDSA.Alg(),
+		}),
+		jwt.WithIssuer(issuer()),
+		jwt.WithAudience(aud),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(30 * time.Second),
+	}
+}
+
+func parse(tokenString string) (*Claims, error) {
+	ks, err := LoadKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+
+	_, err = jwt.ParseWithClaims(tokenString, &claims, ks.keyFunc, parserOptions(audience())...)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Subject == "" || claims.Subject != claims.UserId {
+		return nil, fmt.Errorf("subject inválido")
+	}
+
+	if claims.ID == "" {
+		return nil, fmt.Errorf("jti ausente")
+	}
+
+	return &claims, nil
 }
 
 func DecodeToken(tokenString string) (*UserToken, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return secretKey, nil
-	})
+	claims, err := parse(tokenString)
 
 	if err != nil {
 		return nil, fmt.Errorf("erro ao analisar o token: %v", err)
 	}
 
-	if !token.Valid {
-		return nil, fmt.Errorf("token inválido")
+	user := &UserToken{
+		UserId:    claims.Subject,
+		Name:      claims.Name,
+		Username:  claims.Username,
+		SessionId: claims.SessionId,
+		TokenId:   claims.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+		Roles:     claims.Roles,
+		Scopes:    claims.Scopes,
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		user := &UserToken{
-			UserId:   claims["user_id"].(string),
-			Name:     claims["name"].(string),
-			Username: claims["username"].(string),
-		}
-		return user, nil
+	if claims.Avatar != "" {
+		user.Avatar = &claims.Avatar
 	}
 
-	return nil, fmt.Errorf("não foi possível extrair dados do token")
+	return user, nil
 }
 
-func CreateToken(user *UserToken) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256,
-		jwt.MapClaims{
-			"user_id":  user.UserId,
-			"name":     user.Name,
-			"username": user.Username,
-			"exp":      time.Now().Add((time.Hour * 48)).Unix(), //two days
-		})
+// AccessTokenTTL é a validade dos tokens de acesso (ACCESS_TOKEN_TTL, padrão
+// 15 minutos). Sessões mais longas usam refresh tokens.
+func AccessTokenTTL() time.Duration {
+	config.LoadEnv()
 
-	tokeString, err := token.SignedString(secretKey)
+	return config.GetEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute)
+}
 
+func CreateToken(user *UserToken) (string, error) {
+	ks, err := LoadKeys()
 	if err != nil {
 		return "", err
 	}
 
-	return tokeString, err
-}
+	now := time.Now()
+
+	claims := Claims{
+		UserId:    user.UserId,
+		Name:      user.Name,
+		Username:  user.Username,
+		SessionId: user.SessionId,
+		Roles:     user.Roles,
+		Scopes:    user.Scopes,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Issuer:    issuer(),
+			Subject:   user.UserId,
+			Audience:  jwt.ClaimStrings{audience()},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL())),
+		},
+	}
 
-func VerifyToken(tokenString string) error {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return secretKey, nil
-	})
+	if user.Avatar != nil {
+		claims.Avatar = *user.Avatar
+	}
+
+	token := jwt.NewWithClaims(ks.signing.Method, claims)
+	token.Header["kid"] = ks.signing.Kid
+
+	tokeString, err := token.SignedString(ks.signing.Private)
 
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	if !token.Valid {
-		return fmt.Errorf("invalid token")
-	}
+	return tokeString, err
+}
+
+func VerifyToken(tokenString string) error {
+	_, err := parse(tokenString)
 
-	return nil
+	return err
 }
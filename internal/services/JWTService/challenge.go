@@ -0,0 +1,90 @@
+package jwtService
+
+import (
+	"fmt"
+	"go-web-socket/config"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Challenge é o token curto devolvido pelo login quando o usuário tem 2FA.
+// Ele só serve para ser trocado, junto com um código válido, pelos tokens
+// de acesso: a audiência é diferente, então o middleware de autenticação o
+// recusa.
+type Challenge struct {
+	UserId    string
+	TokenId   string
+	ExpiresAt time.Time
+}
+
+func challengeAudience() string {
+	return audience() + ":mfa"
+}
+
+// ChallengeTTL é a validade do token de desafio (MFA_CHALLENGE_TTL, padrão 5 minutos).
+func ChallengeTTL() time.Duration {
+	config.LoadEnv()
+
+	return config.GetEnvDuration("MFA_CHALLENGE_TTL", 5*time.Minute)
+}
+
+func CreateChallengeToken(userId string) (string, *Challenge, error) {
+	ks, err := LoadKeys()
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+
+	challenge := &Challenge{
+		UserId:    userId,
+		TokenId:   uuid.New().String(),
+		ExpiresAt: now.Add(ChallengeTTL()),
+	}
+
+	claims := jwt.RegisteredClaims{
+		ID:        challenge.TokenId,
+		Issuer:    issuer(),
+		Subject:   userId,
+		Audience:  jwt.ClaimStrings{challengeAudience()},
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(challenge.ExpiresAt),
+	}
+
+	token := jwt.NewWithClaims(ks.signing.Method, claims)
+	token.Header["kid"] = ks.signing.Kid
+
+	tokenString, err := token.SignedString(ks.signing.Private)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return tokenString, challenge, nil
+}
+
+func DecodeChallengeToken(tokenString string) (*Challenge, error) {
+	ks, err := LoadKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	var claims jwt.RegisteredClaims
+
+	_, err = jwt.ParseWithClaims(tokenString, &claims, ks.keyFunc, parserOptions(challengeAudience())...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao analisar o token de desafio: %v", err)
+	}
+
+	if claims.Subject == "" || claims.ID == "" {
+		return nil, fmt.Errorf("token de desafio incompleto")
+	}
+
+	return &Challenge{
+		UserId:    claims.Subject,
+		TokenId:   claims.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
@@ -0,0 +1,283 @@
+package jwtService
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"go-web-socket/config"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key é uma chave de assinatura identificada pelo kid. Private é nil para
+// chaves que só servem para verificar tokens (ex.: chaves antigas em rotação).
+type Key struct {
+	Kid     string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+var (
+	keySet     *KeySet
+	keySetErr  error
+	keySetOnce sync.Once
+)
+
+// LoadKeys carrega as chaves de JWT_KEYS_DIR (padrão "keys"). Cada arquivo
+// <kid>.pem é uma chave privada RSA ou Ed25519 e <kid>.pub.pem uma chave
+// pública usada só para verificação. JWT_SIGNING_KID escolhe a chave que
+// assina; sem ela, é usada a última chave privada em ordem alfabética.
+//
+// Para rotacionar: adicione a nova chave, aponte JWT_SIGNING_KID para ela e
+// remova a antiga só depois que os tokens assinados por ela expirarem.
+//
+// Sem nenhuma chave privada é um erro: uma chave gerada na hora invalidaria
+// os tokens a cada reinício e seria diferente em cada nó. Só em
+// desenvolvimento, JWT_ALLOW_EPHEMERAL_KEY=true aceita uma chave temporária.
+func LoadKeys() (*KeySet, error) {
+	keySetOnce.Do(func() {
+		config.LoadEnv()
+
+		keySet, keySetErr = LoadKeysFromDir(
+			config.GetEnv("JWT_KEYS_DIR", "keys"),
+			os.Getenv("JWT_SIGNING_KID"),
+		)
+
+		if keySetErr != nil || keySet.signing != nil {
+			return
+		}
+
+		if !config.GetEnvBool("JWT_ALLOW_EPHEMERAL_KEY", false) {
+			keySetErr = fmt.Errorf("nenhuma chave privada em %s; gere uma com o comando keygen", config.GetEnv("JWT_KEYS_DIR", "keys"))
+			return
+		}
+
+		log.Printf("Nenhuma chave JWT encontrada; usando uma chave Ed25519 temporária (JWT_ALLOW_EPHEMERAL_KEY)")
+		keySetErr = keySet.addEphemeral()
+	})
+
+	return keySet, keySetErr
+}
+
+func LoadKeysFromDir(dir, signingKid string) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]*Key)}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		key, err := readKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler chave %s: %v", path, err)
+		}
+
+		if _, exists := ks.keys[key.Kid]; exists && key.Private == nil {
+			continue
+		}
+
+		ks.keys[key.Kid] = key
+
+		if key.Private != nil && signingKid == "" {
+			ks.signing = key
+		}
+	}
+
+	if signingKid != "" {
+		key, ok := ks.keys[signingKid]
+		if !ok || key.Private == nil {
+			return nil, fmt.Errorf("chave privada %q (JWT_SIGNING_KID) não encontrada em %s", signingKid, dir)
+		}
+
+		ks.signing = key
+	}
+
+	return ks, nil
+}
+
+func readKey(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("arquivo PEM inválido")
+	}
+
+	name := filepath.Base(path)
+	kid := strings.TrimSuffix(strings.TrimSuffix(name, ".pem"), ".pub")
+
+	var parsed interface{}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("tipo de PEM não suportado: %s", block.Type)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newKey(kid, parsed)
+}
+
+func newKey(kid string, parsed interface{}) (*Key, error) {
+	key := &Key{Kid: kid}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("algoritmo de chave não suportado: %T", parsed)
+	}
+
+	if rsaKey, ok := key.Public.(*rsa.PublicKey); ok && rsaKey.N.BitLen() < 2048 {
+		return nil, fmt.Errorf("chaves RSA precisam ter pelo menos 2048 bits")
+	}
+
+	return key, nil
+}
+
+func (ks *KeySet) addEphemeral() error {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	key, _ := newKey("ephemeral", private)
+	ks.keys[key.Kid] = key
+	ks.signing = key
+
+	return nil
+}
+
+// keyFunc só aceita tokens cujo alg é o mesmo da chave indicada pelo kid.
+func (ks *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("kid desconhecido: %q", kid)
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("algoritmo %s não permitido para a chave %s", token.Method.Alg(), kid)
+	}
+
+	return key.Public, nil
+}
+
+// GenerateKey gera uma nova chave privada em PEM (PKCS#8) para o algoritmo
+// "EdDSA" ou "RS256".
+func GenerateKey(alg string) ([]byte, error) {
+	var (
+		private interface{}
+		err     error
+	)
+
+	switch alg {
+	case "EdDSA":
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	case "RS256":
+		private, err = rsa.GenerateKey(rand.Reader, 3072)
+	default:
+		return nil, fmt.Errorf("algoritmo não suportado: %s", alg)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS devolve as chaves públicas de verificação no formato JSON Web Key Set.
+func JWKS() ([]JWK, error) {
+	ks, err := LoadKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	kids := make([]string, 0, len(ks.keys))
+	for kid := range ks.keys {
+		kids = append(kids, kid)
+	}
+
+	sort.Strings(kids)
+
+	encode := base64.RawURLEncoding.EncodeToString
+
+	jwks := make([]JWK, 0, len(kids))
+	for _, kid := range kids {
+		key := ks.keys[kid]
+		jwk := JWK{Kid: kid, Use: "sig", Alg: key.Method.Alg()}
+
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = encode(public.N.Bytes())
+			jwk.E = encode(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = encode(public)
+		}
+
+		jwks = append(jwks, jwk)
+	}
+
+	return jwks, nil
+}
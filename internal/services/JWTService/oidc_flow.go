@@ -0,0 +1,80 @@
+package jwtService
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OIDCFlow é um login OIDC iniciado e ainda não concluído. Ele vai assinado
+// num cookie do navegador que começou o login, e não na memória do nó: o
+// callback pode cair em qualquer nó e só vale no mesmo navegador.
+type OIDCFlow struct {
+	State     string
+	Nonce     string
+	Verifier  string // verificador PKCE
+	ExpiresAt time.Time
+}
+
+type oidcFlowClaims struct {
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"cv"`
+	jwt.RegisteredClaims
+}
+
+func oidcFlowAudience() string {
+	return audience() + ":oidc"
+}
+
+func CreateOIDCFlowToken(flow OIDCFlow) (string, error) {
+	ks, err := LoadKeys()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	claims := oidcFlowClaims{
+		Nonce:    flow.Nonce,
+		Verifier: flow.Verifier,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        flow.State,
+			Issuer:    issuer(),
+			Audience:  jwt.ClaimStrings{oidcFlowAudience()},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(flow.ExpiresAt),
+		},
+	}
+
+	token := jwt.NewWithClaims(ks.signing.Method, claims)
+	token.Header["kid"] = ks.signing.Kid
+
+	return token.SignedString(ks.signing.Private)
+}
+
+func DecodeOIDCFlowToken(tokenString string) (*OIDCFlow, error) {
+	ks, err := LoadKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	var claims oidcFlowClaims
+
+	_, err = jwt.ParseWithClaims(tokenString, &claims, ks.keyFunc, parserOptions(oidcFlowAudience())...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao analisar o login OIDC em andamento: %v", err)
+	}
+
+	if claims.ID == "" || claims.Nonce == "" || claims.Verifier == "" {
+		return nil, fmt.Errorf("login OIDC em andamento incompleto")
+	}
+
+	return &OIDCFlow{
+		State:     claims.ID,
+		Nonce:     claims.Nonce,
+		Verifier:  claims.Verifier,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
@@ -0,0 +1,244 @@
+package loginGuardService
+
+import (
+	"go-web-socket/config"
+	"go-web-socket/internal/utils/audit"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	// Falhas toleradas antes de começar a atrasar novas tentativas.
+	FreeAttempts int
+	BaseDelay    time.Duration
+	// Falhas seguidas que bloqueiam o username (ou o IP) por LockoutDuration.
+	MaxAttempts     int
+	IPMaxAttempts   int
+	LockoutDuration time.Duration
+	// Sem falhas por esse tempo, o contador é zerado.
+	Window time.Duration
+}
+
+func LoadConfig() Config {
+	config.LoadEnv()
+
+	return Config{
+		FreeAttempts:    config.GetEnvInt("LOGIN_FREE_ATTEMPTS", 3),
+		BaseDelay:       config.GetEnvDuration("LOGIN_BASE_DELAY", time.Second),
+		MaxAttempts:     config.GetEnvInt("LOGIN_MAX_ATTEMPTS", 10),
+		IPMaxAttempts:   config.GetEnvInt("LOGIN_IP_MAX_ATTEMPTS", 50),
+		LockoutDuration: config.GetEnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		Window:          config.GetEnvDuration("LOGIN_ATTEMPT_WINDOW", time.Hour),
+	}
+}
+
+type entry struct {
+	failures     int
+	pending      int // tentativas em andamento, ainda sem resultado
+	lastFailure  time.Time
+	blockedUntil time.Time
+}
+
+// LoginGuard conta falhas de login por username e por IP, atrasando novas
+// tentativas de forma exponencial e bloqueando temporariamente quando o
+// limite é atingido.
+type LoginGuard struct {
+	cfg       Config
+	mu        sync.Mutex
+	entries   map[string]*entry
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+func New(cfg Config) *LoginGuard {
+	return &LoginGuard{
+		cfg:     cfg,
+		entries: make(map[string]*entry),
+		now:     time.Now,
+	}
+}
+
+func userKey(username string) string {
+	return "user:" + strings.ToLower(strings.TrimSpace(username))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Attempt é uma tentativa de login reservada por Begin. Termina com Fail,
+// Succeed ou Release; o que vier depois do primeiro é ignorado, então
+// Release pode ficar num defer.
+type Attempt struct {
+	guard    *LoginGuard
+	username string
+	ip       string
+	done     bool
+}
+
+// Begin reserva uma tentativa para o username e o IP. Se não for a vez dela,
+// devolve quanto tempo esperar e nenhuma tentativa. A verificação e a
+// reserva acontecem juntas: depois das tentativas livres, só uma tentativa
+// por username (ou IP) fica em andamento, então requisições em paralelo não
+// passam do limite antes de as falhas serem contadas.
+func (g *LoginGuard) Begin(username, ip string) (*Attempt, time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	g.prune(now)
+
+	keys := []string{userKey(username), ipKey(ip)}
+	var wait time.Duration
+
+	for _, key := range keys {
+		e, ok := g.entries[key]
+		if !ok {
+			continue
+		}
+
+		remaining := e.blockedUntil.Sub(now)
+		if e.pending > 0 && g.failures(e, now)+e.pending >= g.cfg.FreeAttempts && remaining < g.cfg.BaseDelay {
+			remaining = g.cfg.BaseDelay
+		}
+
+		if remaining > wait {
+			wait = remaining
+		}
+	}
+
+	if wait > 0 {
+		return nil, wait
+	}
+
+	for _, key := range keys {
+		e, ok := g.entries[key]
+		if !ok {
+			e = &entry{}
+			g.entries[key] = e
+		}
+
+		e.pending++
+	}
+
+	return &Attempt{guard: g, username: username, ip: ip}, 0
+}
+
+// Fail conta a tentativa como falha.
+func (a *Attempt) Fail() {
+	g := a.guard
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !a.finish() {
+		return
+	}
+
+	now := g.now()
+
+	g.fail(userKey(a.username), g.cfg.MaxAttempts, now, map[string]interface{}{"username": a.username, "ip": a.ip})
+	g.fail(ipKey(a.ip), g.cfg.IPMaxAttempts, now, map[string]interface{}{"ip": a.ip})
+}
+
+// Succeed zera o contador do username. O do IP não é zerado, para que um
+// atacante não possa limpá-lo entrando na própria conta.
+func (a *Attempt) Succeed() {
+	g := a.guard
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !a.finish() {
+		return
+	}
+
+	if e, ok := g.entries[userKey(a.username)]; ok {
+		e.failures = 0
+		e.blockedUntil = time.Time{}
+	}
+}
+
+// Release devolve a tentativa sem contar falha nem sucesso, como quando a
+// senha está certa mas o login ainda depende do segundo fator.
+func (a *Attempt) Release() {
+	g := a.guard
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	a.finish()
+}
+
+// finish libera a reserva e diz se a tentativa ainda estava em andamento.
+// Deve ser chamado com o mutex travado.
+func (a *Attempt) finish() bool {
+	if a.done {
+		return false
+	}
+
+	a.done = true
+
+	for _, key := range []string{userKey(a.username), ipKey(a.ip)} {
+		if e, ok := a.guard.entries[key]; ok && e.pending > 0 {
+			e.pending--
+		}
+	}
+
+	return true
+}
+
+// failures devolve as falhas que ainda contam, dentro da janela.
+func (g *LoginGuard) failures(e *entry, now time.Time) int {
+	if now.Sub(e.lastFailure) > g.cfg.Window {
+		return 0
+	}
+
+	return e.failures
+}
+
+func (g *LoginGuard) fail(key string, maxAttempts int, now time.Time, fields map[string]interface{}) {
+	e, ok := g.entries[key]
+	if !ok {
+		e = &entry{}
+		g.entries[key] = e
+	}
+
+	e.failures = g.failures(e, now) + 1
+	e.lastFailure = now
+
+	if e.failures >= maxAttempts {
+		e.blockedUntil = now.Add(g.cfg.LockoutDuration)
+
+		fields["failures"] = e.failures
+		fields["until"] = e.blockedUntil.UTC().Format(time.RFC3339)
+		audit.Log("login.lockout", fields)
+
+		return
+	}
+
+	if extra := e.failures - g.cfg.FreeAttempts; extra > 0 {
+		delay := time.Duration(float64(g.cfg.BaseDelay) * math.Pow(2, float64(extra-1)))
+		if delay > g.cfg.LockoutDuration {
+			delay = g.cfg.LockoutDuration
+		}
+
+		e.blockedUntil = now.Add(delay)
+	}
+}
+
+func (g *LoginGuard) prune(now time.Time) {
+	if now.Sub(g.lastPrune) < time.Minute {
+		return
+	}
+
+	g.lastPrune = now
+
+	for key, e := range g.entries {
+		if e.pending == 0 && g.failures(e, now) == 0 && !e.blockedUntil.After(now) {
+			delete(g.entries, key)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package loginGuardService
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "login-guard-test")
+	if err != nil {
+		panic(err)
+	}
+
+	os.Setenv("AUDIT_LOG_FILE", filepath.Join(dir, "audit.log"))
+
+	code := m.Run()
+
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func newGuard() (*LoginGuard, *time.Time) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	guard := New(Config{
+		FreeAttempts:    2,
+		BaseDelay:       time.Second,
+		MaxAttempts:     4,
+		IPMaxAttempts:   100,
+		LockoutDuration: 15 * time.Minute,
+		Window:          time.Hour,
+	})
+	guard.now = func() time.Time { return now }
+
+	return guard, &now
+}
+
+func fail(t *testing.T, guard *LoginGuard, username, ip string) {
+	t.Helper()
+
+	attempt, wait := guard.Begin(username, ip)
+	if wait > 0 {
+		t.Fatalf("tentativa recusada, espera de %v", wait)
+	}
+
+	attempt.Fail()
+}
+
+func TestLockoutAfterMaxAttempts(t *testing.T) {
+	guard, now := newGuard()
+
+	fail(t, guard, "alice", "10.0.0.1")
+	fail(t, guard, "alice", "10.0.0.1")
+	fail(t, guard, "alice", "10.0.0.1")
+
+	// Depois das tentativas livres, cada falha atrasa a próxima
+	if _, wait := guard.Begin("alice", "10.0.0.1"); wait != time.Second {
+		t.Fatalf("espera = %v, esperado 1s", wait)
+	}
+
+	*now = now.Add(time.Second)
+	fail(t, guard, "Alice ", "10.0.0.1") // o username é normalizado
+
+	if _, wait := guard.Begin("alice", "10.0.0.2"); wait != 15*time.Minute {
+		t.Fatalf("espera = %v, esperado o bloqueio de 15m", wait)
+	}
+
+	*now = now.Add(15 * time.Minute)
+
+	// O contador do IP continua valendo, então o resto vem de outro IP
+	attempt, wait := guard.Begin("alice", "10.0.0.2")
+	if wait > 0 {
+		t.Fatalf("o bloqueio deveria ter acabado, espera de %v", wait)
+	}
+
+	attempt.Succeed()
+	fail(t, guard, "alice", "10.0.0.2")
+	fail(t, guard, "alice", "10.0.0.2")
+
+	if _, wait := guard.Begin("alice", "10.0.0.2"); wait > 0 {
+		t.Fatalf("o sucesso deveria ter zerado o contador, espera de %v", wait)
+	}
+}
+
+func TestParallelAttemptsCannotPassTheLimit(t *testing.T) {
+	guard, _ := newGuard()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		attempts []*Attempt
+	)
+
+	// Todas as tentativas começam antes de qualquer falha ser contada
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if attempt, wait := guard.Begin("alice", "10.0.0.1"); wait == 0 {
+				mu.Lock()
+				attempts = append(attempts, attempt)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(attempts) != 2 {
+		t.Fatalf("%d tentativas em paralelo passaram, esperado 2 (as livres)", len(attempts))
+	}
+
+	for _, attempt := range attempts {
+		attempt.Fail()
+	}
+
+	// Daqui em diante, uma de cada vez
+	attempt, wait := guard.Begin("alice", "10.0.0.1")
+	if wait > 0 {
+		t.Fatalf("tentativa recusada, espera de %v", wait)
+	}
+
+	if _, wait := guard.Begin("alice", "10.0.0.2"); wait == 0 {
+		t.Fatal("uma segunda tentativa passou com outra em andamento")
+	}
+
+	attempt.Release()
+	attempt.Fail() // ignorado: a tentativa já terminou
+
+	if _, wait := guard.Begin("alice", "10.0.0.1"); wait > 0 {
+		t.Fatalf("Release não devolveu a tentativa, espera de %v", wait)
+	}
+}
@@ -0,0 +1,124 @@
+package mailService
+
+import (
+	"fmt"
+	"go-web-socket/config"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Mail struct {
+	To      string
+	Subject string
+	Body    string // texto puro
+}
+
+// Sender entrega e-mails. Os serviços dependem só dessa interface, para que
+// o SMTP possa ser trocado por um arquivo em desenvolvimento e nos testes.
+type Sender interface {
+	Send(mail Mail) error
+}
+
+// SMTPSender envia pelo servidor configurado, usando STARTTLS quando o
+// servidor oferece.
+type SMTPSender struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (s SMTPSender) Send(mail Mail) error {
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+
+	if err := smtp.SendMail(addr, auth, s.From, []string{mail.To}, format(s.From, mail)); err != nil {
+		return fmt.Errorf("erro ao enviar e-mail para %s: %v", mail.To, err)
+	}
+
+	return nil
+}
+
+// FileSender grava os e-mails num arquivo em vez de enviar. Serve para
+// desenvolvimento: o link de redefinição, por exemplo, é lido do arquivo.
+type FileSender struct {
+	Path string
+	From string
+
+	mu sync.Mutex
+}
+
+func (s *FileSender) Send(mail Mail) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir arquivo de e-mails: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(format(s.From, mail), "\r\n.\r\n"...)); err != nil {
+		return fmt.Errorf("erro ao gravar e-mail: %v", err)
+	}
+
+	return nil
+}
+
+// LogSender só escreve o e-mail no log da aplicação.
+type LogSender struct{}
+
+func (LogSender) Send(mail Mail) error {
+	log.Printf("E-mail para %s (%s):\n%s", mail.To, mail.Subject, mail.Body)
+	return nil
+}
+
+func format(from string, mail Mail) []byte {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mail.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(mail.Body, "\n", "\r\n"))
+
+	return []byte(b.String())
+}
+
+// NewSenderFromEnv escolhe o envio pela variável MAIL_DRIVER: "smtp"
+// (SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD), "file" (MAIL_FILE,
+// padrão mail.log) ou "log", o padrão.
+func NewSenderFromEnv() Sender {
+	config.LoadEnv()
+
+	from := config.GetEnv("MAIL_FROM", "no-reply@localhost")
+
+	switch os.Getenv("MAIL_DRIVER") {
+	case "smtp":
+		return SMTPSender{
+			Host:     config.GetEnv("SMTP_HOST", "localhost"),
+			Port:     config.GetEnvInt("SMTP_PORT", 587),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}
+	case "file":
+		return &FileSender{Path: config.GetEnv("MAIL_FILE", "mail.log"), From: from}
+	default:
+		return LogSender{}
+	}
+}
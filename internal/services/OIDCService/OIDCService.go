@@ -0,0 +1,361 @@
+package oidcService
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-web-socket/config"
+	"go-web-socket/internal/models"
+	identityRepository "go-web-socket/internal/repositories/IdentityRepository"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	jwtService "go-web-socket/internal/services/JWTService"
+	"go-web-socket/internal/utils/audit"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrDisabled     = errors.New("login via OIDC não está configurado")
+	ErrInvalidState = errors.New("state inválido ou expirado")
+	ErrNotLinked    = errors.New("nenhuma conta vinculada a esta identidade")
+)
+
+// FlowTTL é quanto tempo o usuário tem para voltar do provedor.
+const FlowTTL = 10 * time.Minute
+
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string // vazio para clientes públicos, que dependem só do PKCE
+	// RedirectURL é a URL cadastrada no provedor. Ela pode apontar para o
+	// callback da API ou para uma página do frontend que repassa code e state.
+	RedirectURL string
+	Scopes      []string
+	// AutoProvision cria um usuário no primeiro login de uma identidade nova.
+	AutoProvision bool
+	// LinkByEmail vincula a identidade a uma conta existente com o mesmo
+	// e-mail, desde que o provedor diga que o e-mail foi verificado. Fica
+	// desligado por padrão: só é seguro com um provedor que não deixa o
+	// usuário escolher um e-mail qualquer.
+	LinkByEmail bool
+}
+
+func LoadConfig() Config {
+	config.LoadEnv()
+
+	return Config{
+		Issuer:        config.GetEnv("OIDC_ISSUER", ""),
+		ClientID:      config.GetEnv("OIDC_CLIENT_ID", ""),
+		ClientSecret:  config.GetEnv("OIDC_CLIENT_SECRET", ""),
+		RedirectURL:   config.GetEnv("OIDC_REDIRECT_URL", "http://localhost:8080/auth/oidc/callback"),
+		Scopes:        strings.Fields(config.GetEnv("OIDC_SCOPES", "openid profile email")),
+		AutoProvision: config.GetEnvBool("OIDC_AUTO_PROVISION", true),
+		LinkByEmail:   config.GetEnvBool("OIDC_LINK_BY_EMAIL", false),
+	}
+}
+
+// IDClaims são os campos do ID token usados para vincular ou criar o usuário.
+type IDClaims struct {
+	Nonce             string `json:"nonce"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+	jwt.RegisteredClaims
+}
+
+type OIDCService struct {
+	cfg        Config
+	provider   *provider
+	users      userRepository.UserRepository
+	identities identityRepository.IdentityRepository
+}
+
+func New(cfg Config, users userRepository.UserRepository, identities identityRepository.IdentityRepository) *OIDCService {
+	return &OIDCService{
+		cfg:        cfg,
+		provider:   &provider{issuer: cfg.Issuer, client: &http.Client{Timeout: 10 * time.Second}},
+		users:      users,
+		identities: identities,
+	}
+}
+
+func (s *OIDCService) Enabled() bool {
+	return s.cfg.Issuer != "" && s.cfg.ClientID != ""
+}
+
+// SecureCookie diz se o cookie do login em andamento deve ser só HTTPS,
+// o que vale quando o provedor volta para uma URL HTTPS.
+func (s *OIDCService) SecureCookie() bool {
+	return strings.HasPrefix(s.cfg.RedirectURL, "https://")
+}
+
+func randomString(size int) (string, error) {
+	buf := make([]byte, size)
+
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// AuthURL inicia um login: gera state, nonce e o verificador PKCE e devolve
+// a URL de autorização do provedor e o token do login em andamento, que o
+// navegador guarda num cookie até o callback.
+func (s *OIDCService) AuthURL() (string, string, error) {
+	if !s.Enabled() {
+		return "", "", ErrDisabled
+	}
+
+	meta, err := s.provider.discover()
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := randomString(24)
+	if err != nil {
+		return "", "", err
+	}
+
+	nonce, err := randomString(24)
+	if err != nil {
+		return "", "", err
+	}
+
+	verifier, err := randomString(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	flow := jwtService.OIDCFlow{State: state, Nonce: nonce, Verifier: verifier, ExpiresAt: time.Now().Add(FlowTTL)}
+
+	flowToken, err := jwtService.CreateOIDCFlowToken(flow)
+	if err != nil {
+		return "", "", err
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {s.cfg.ClientID},
+		"redirect_uri":          {s.cfg.RedirectURL},
+		"scope":                 {strings.Join(s.cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return meta.AuthorizationEndpoint + separator + query.Encode(), flowToken, nil
+}
+
+// Exchange troca o código de autorização pelo ID token e devolve as claims
+// já validadas (assinatura, issuer, audiência, validade e nonce). flowToken
+// é o que AuthURL devolveu, vindo do cookie: o state tem que ser o dele.
+func (s *OIDCService) Exchange(code, state, flowToken string) (*IDClaims, error) {
+	if !s.Enabled() {
+		return nil, ErrDisabled
+	}
+
+	f, err := jwtService.DecodeOIDCFlowToken(flowToken)
+	if err != nil || subtle.ConstantTimeCompare([]byte(f.State), []byte(state)) != 1 {
+		return nil, ErrInvalidState
+	}
+
+	meta, err := s.provider.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {s.cfg.RedirectURL},
+		"client_id":     {s.cfg.ClientID},
+		"code_verifier": {f.Verifier},
+	}
+
+	request, err := http.NewRequest(http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+
+	if s.cfg.ClientSecret != "" {
+		request.SetBasicAuth(url.QueryEscape(s.cfg.ClientID), url.QueryEscape(s.cfg.ClientSecret))
+	}
+
+	resp, err := s.provider.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao trocar o código: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("resposta inválida do provedor: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK || body.IDToken == "" {
+		return nil, fmt.Errorf("provedor recusou o código: %s %s", body.Error, body.ErrorDescription)
+	}
+
+	var claims IDClaims
+
+	_, err = jwt.ParseWithClaims(body.IDToken, &claims, s.provider.keyFunc,
+		jwt.WithValidMethods([]string{"RS256", "ES256", "EdDSA"}),
+		jwt.WithIssuer(s.cfg.Issuer),
+		jwt.WithAudience(s.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(30*time.Second),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ID token inválido: %v", err)
+	}
+
+	if claims.Subject == "" {
+		return nil, errors.New("ID token sem subject")
+	}
+
+	if claims.Nonce != f.Nonce {
+		return nil, errors.New("nonce do ID token não confere")
+	}
+
+	return &claims, nil
+}
+
+// Resolve encontra o usuário da identidade: pelo vínculo existente, pelo
+// e-mail verificado ou criando uma conta nova, conforme a configuração.
+func (s *OIDCService) Resolve(claims *IDClaims) (models.User, error) {
+	identity, err := s.identities.FindBySubject(s.cfg.Issuer, claims.Subject)
+	if err == nil {
+		return s.users.FindByUserId(identity.UserId)
+	}
+
+	if !errors.Is(err, identityRepository.ErrIdentityNotFound) {
+		return models.User{}, err
+	}
+
+	email := strings.ToLower(claims.Email)
+
+	if s.cfg.LinkByEmail && email != "" && claims.EmailVerified {
+		user, err := s.users.FindByEmail(email)
+		if err == nil {
+			return user, s.link(user, claims, "oidc.linked")
+		}
+
+		if !errors.Is(err, userRepository.ErrUserNotFound) {
+			return models.User{}, err
+		}
+	}
+
+	if !s.cfg.AutoProvision {
+		return models.User{}, ErrNotLinked
+	}
+
+	user, err := s.provision(claims, email)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	return user, s.link(user, claims, "oidc.provisioned")
+}
+
+func (s *OIDCService) link(user models.User, claims *IDClaims, event string) error {
+	identity := models.Identity{
+		Issuer:  s.cfg.Issuer,
+		Subject: claims.Subject,
+		UserId:  user.UserId,
+	}
+
+	if err := s.identities.Create(&identity); err != nil {
+		return fmt.Errorf("erro ao vincular identidade: %v", err)
+	}
+
+	audit.Log(event, map[string]interface{}{"user_id": user.UserId, "issuer": s.cfg.Issuer, "subject": claims.Subject})
+
+	return nil
+}
+
+var invalidUsernameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// provision cria a conta de uma identidade nova. A conta não tem senha: o
+// login é sempre pelo provedor, até o usuário definir uma pela redefinição.
+func (s *OIDCService) provision(claims *IDClaims, email string) (models.User, error) {
+	base := claims.PreferredUsername
+	if at := strings.Index(email, "@"); base == "" && at > 0 {
+		base = email[:at]
+	}
+
+	base = invalidUsernameChars.ReplaceAllString(base, "")
+	if base == "" {
+		base = invalidUsernameChars.ReplaceAllString(claims.Subject, "")
+	}
+
+	if base == "" {
+		base = "user"
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = base
+	}
+
+	// A conta nasce verificada: o e-mail só é gravado se o provedor o
+	// verificou, senão qualquer um poderia reservar o e-mail de outra pessoa.
+	user := models.User{
+		UserId:   uuid.New().String(),
+		Name:     name,
+		Verified: true,
+	}
+
+	if email != "" && claims.EmailVerified {
+		if _, err := s.users.FindByEmail(email); errors.Is(err, userRepository.ErrUserNotFound) {
+			user.Email = &email
+		}
+	}
+
+	for attempt := 0; attempt < 20; attempt++ {
+		user.Username = base
+		if attempt > 0 {
+			user.Username = base + strconv.Itoa(attempt+1)
+		}
+
+		err := s.users.Create(&user)
+		if err == nil {
+			return user, nil
+		}
+
+		if !errors.Is(err, userRepository.ErrDuplicateUser) {
+			return models.User{}, err
+		}
+	}
+
+	return models.User{}, fmt.Errorf("não foi possível escolher um username para %q", base)
+}
@@ -0,0 +1,258 @@
+package oidcService
+
+import (
+	"errors"
+	"go-web-socket/internal/models"
+	identityRepository "go-web-socket/internal/repositories/IdentityRepository"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oauth2-proxy/mockoidc"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "oidc-test")
+	if err != nil {
+		panic(err)
+	}
+
+	os.Setenv("AUDIT_LOG_FILE", filepath.Join(dir, "audit.log"))
+	os.Setenv("JWT_KEYS_DIR", dir)
+	os.Setenv("JWT_ALLOW_EPHEMERAL_KEY", "true")
+
+	code := m.Run()
+
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+type fixture struct {
+	mock       *mockoidc.MockOIDC
+	cfg        Config
+	service    *OIDCService
+	users      *userRepository.MemoryUserRepository
+	identities *identityRepository.MemoryIdentityRepository
+}
+
+func newFixture(t *testing.T, configure func(*Config)) *fixture {
+	t.Helper()
+
+	mock, err := mockoidc.NewServer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// O mockoidc anuncia client_secret_basic, mas só lê o segredo do corpo.
+	mock.AddMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if id, secret, ok := r.BasicAuth(); ok {
+				r.ParseForm()
+				r.Form.Set("client_id", id)
+				r.Form.Set("client_secret", secret)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mock.Start(ln, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { mock.Shutdown() })
+
+	cfg := Config{
+		Issuer:        mock.Issuer(),
+		ClientID:      mock.ClientID,
+		ClientSecret:  mock.ClientSecret,
+		RedirectURL:   "http://localhost/auth/oidc/callback",
+		Scopes:        []string{"openid", "profile", "email"},
+		AutoProvision: true,
+	}
+
+	if configure != nil {
+		configure(&cfg)
+	}
+
+	f := &fixture{
+		mock:       mock,
+		cfg:        cfg,
+		users:      userRepository.NewMemoryUserRepository(),
+		identities: identityRepository.NewMemoryIdentityRepository(),
+	}
+
+	f.service = New(cfg, f.users, f.identities)
+
+	return f
+}
+
+// authorize inicia o login e segue o redirecionamento do provedor. Devolve
+// o code e o state do callback e o token que iria no cookie.
+func (f *fixture) authorize(t *testing.T, user mockoidc.User) (string, string, string) {
+	t.Helper()
+
+	if user != nil {
+		f.mock.QueueUser(user)
+	}
+
+	authURL, flowToken, err := f.service.AuthURL()
+	if err != nil {
+		t.Fatalf("AuthURL: %v", err)
+	}
+
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize: status %d", resp.StatusCode)
+	}
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return callback.Query().Get("code"), callback.Query().Get("state"), flowToken
+}
+
+// login percorre o fluxo inteiro: a URL de autorização, o redirecionamento
+// do provedor e o callback com code, state e o cookie.
+func (f *fixture) login(t *testing.T, user mockoidc.User) (models.User, error) {
+	t.Helper()
+
+	code, state, flowToken := f.authorize(t, user)
+
+	claims, err := f.service.Exchange(code, state, flowToken)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+
+	return f.service.Resolve(claims)
+}
+
+func TestLoginProvisionsAndThenReusesTheAccount(t *testing.T) {
+	f := newFixture(t, nil)
+
+	user, err := f.login(t, mockoidc.DefaultUser())
+	if err != nil {
+		t.Fatalf("primeiro login: %v", err)
+	}
+
+	if user.Username != "jane.doe" || !user.Verified || user.Email == nil || *user.Email != "jane.doe@example.com" {
+		t.Fatalf("conta criada inesperada: %+v", user)
+	}
+
+	again, err := f.login(t, mockoidc.DefaultUser())
+	if err != nil {
+		t.Fatalf("segundo login: %v", err)
+	}
+
+	if again.UserId != user.UserId {
+		t.Fatalf("segundo login criou outra conta: %s != %s", again.UserId, user.UserId)
+	}
+}
+
+func TestLoginWithoutAutoProvision(t *testing.T) {
+	f := newFixture(t, func(cfg *Config) { cfg.AutoProvision = false })
+
+	if _, err := f.login(t, mockoidc.DefaultUser()); !errors.Is(err, ErrNotLinked) {
+		t.Fatalf("esperava ErrNotLinked, veio %v", err)
+	}
+}
+
+func TestLinkByEmail(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		verified bool
+		linked   bool
+	}{
+		{"desligado por padrão", false, true, false},
+		{"e-mail não verificado", true, false, false},
+		{"e-mail verificado", true, true, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t, func(cfg *Config) { cfg.LinkByEmail = test.enabled })
+
+			email := "jane.doe@example.com"
+			local := models.User{UserId: "local", Username: "jane", Email: &email}
+			if err := f.users.Create(&local); err != nil {
+				t.Fatal(err)
+			}
+
+			idpUser := mockoidc.DefaultUser()
+			idpUser.EmailVerified = test.verified
+
+			user, err := f.login(t, idpUser)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if linked := user.UserId == local.UserId; linked != test.linked {
+				t.Fatalf("vinculou à conta local: %v, esperado %v", linked, test.linked)
+			}
+
+			if !test.linked && user.Email != nil {
+				t.Fatalf("a conta nova não pode ficar com o e-mail de outra: %v", *user.Email)
+			}
+		})
+	}
+}
+
+func TestProvisionWithoutUsableEmail(t *testing.T) {
+	f := newFixture(t, nil)
+
+	user, err := f.login(t, &mockoidc.MockUser{Subject: "sub-42", Email: "no-at-sign", EmailVerified: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user.Username != "sub-42" {
+		t.Fatalf("username = %q, esperado o subject", user.Username)
+	}
+}
+
+func TestExchangeRequiresTheFlowCookie(t *testing.T) {
+	f := newFixture(t, nil)
+
+	code, state, _ := f.authorize(t, mockoidc.DefaultUser())
+	_, _, otherBrowser := f.authorize(t, mockoidc.DefaultUser())
+
+	for _, flowToken := range []string{"", "não-é-um-token", otherBrowser} {
+		if _, err := f.service.Exchange(code, state, flowToken); !errors.Is(err, ErrInvalidState) {
+			t.Fatalf("cookie %q: esperava ErrInvalidState, veio %v", flowToken, err)
+		}
+	}
+}
+
+func TestCallbackOnAnotherNode(t *testing.T) {
+	f := newFixture(t, nil)
+
+	code, state, flowToken := f.authorize(t, mockoidc.DefaultUser())
+
+	// Outro nó não tem nada do login em memória, só o cookie
+	other := New(f.cfg, f.users, f.identities)
+
+	if _, err := other.Exchange(code, state, flowToken); err != nil {
+		t.Fatalf("o callback em outro nó falhou: %v", err)
+	}
+}
@@ -0,0 +1,240 @@
+package passwordService
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-web-socket/config"
+	"go-web-socket/internal/models"
+	actionTokenRepository "go-web-socket/internal/repositories/ActionTokenRepository"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	authService "go-web-socket/internal/services/AuthService"
+	mailService "go-web-socket/internal/services/MailService"
+	"go-web-socket/internal/utils/audit"
+	useHash "go-web-socket/internal/utils/hash"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const PurposePasswordReset = "password_reset"
+
+var (
+	ErrWrongPassword     = errors.New("senha atual incorreta")
+	ErrInvalidResetToken = errors.New("link de redefinição inválido ou expirado")
+)
+
+type PasswordService struct {
+	users  userRepository.UserRepository
+	tokens actionTokenRepository.ActionTokenRepository
+	auth   *authService.AuthService
+	mailer mailService.Sender
+	policy Policy
+}
+
+func New(users userRepository.UserRepository, tokens actionTokenRepository.ActionTokenRepository, auth *authService.AuthService, mailer mailService.Sender, policy Policy) *PasswordService {
+	return &PasswordService{users: users, tokens: tokens, auth: auth, mailer: mailer, policy: policy}
+}
+
+// ResetTokenTTL é a validade do link de redefinição (PASSWORD_RESET_TTL, padrão 1 hora).
+func ResetTokenTTL() time.Duration {
+	config.LoadEnv()
+
+	return config.GetEnvDuration("PASSWORD_RESET_TTL", time.Hour)
+}
+
+// resetURL monta o link enviado por e-mail a partir de PASSWORD_RESET_URL,
+// a página do frontend que recebe o token.
+func resetURL(token string) string {
+	config.LoadEnv()
+
+	base := config.GetEnv("PASSWORD_RESET_URL", "http://localhost:3000/reset-password")
+
+	return base + "?token=" + url.QueryEscape(token)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newToken() (string, error) {
+	buf := make([]byte, 32)
+
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// Validate confere uma senha nova contra a política configurada.
+func (s *PasswordService) Validate(password, username string) error {
+	return s.policy.Validate(password, username)
+}
+
+func (s *PasswordService) setPassword(user models.User, password string) error {
+	if err := s.policy.Validate(password, user.Username); err != nil {
+		return err
+	}
+
+	hash, err := useHash.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("erro ao gerar hash da senha: %v", err)
+	}
+
+	return s.users.SetPassword(user.UserId, hash)
+}
+
+// Change troca a senha do usuário logado e encerra as outras sessões dele.
+// Devolve os ids das sessões encerradas.
+func (s *PasswordService) Change(userId, sessionId, current, next string) ([]string, error) {
+	user, err := s.users.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !useHash.CheckPasswordHash(current, user.Password) {
+		return nil, ErrWrongPassword
+	}
+
+	if err := s.setPassword(user, next); err != nil {
+		return nil, err
+	}
+
+	audit.Log("password.changed", map[string]interface{}{"user_id": userId})
+
+	return s.auth.RevokeAllSessions(userId, sessionId)
+}
+
+// RequestReset envia o link de redefinição para o e-mail do usuário,
+// procurado pelo e-mail ou pelo username. Usuário inexistente, sem e-mail ou
+// com o e-mail ainda não confirmado não gera erro, para não revelar quais
+// contas existem.
+func (s *PasswordService) RequestReset(identifier string) error {
+	identifier = strings.TrimSpace(identifier)
+
+	var (
+		user models.User
+		err  error
+	)
+
+	if strings.Contains(identifier, "@") {
+		user, err = s.users.FindByEmail(strings.ToLower(identifier))
+	} else {
+		user, err = s.users.FindByUsername(identifier)
+	}
+
+	if errors.Is(err, userRepository.ErrUserNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if user.Email == nil || !user.Verified || user.Disabled || user.Bot {
+		return nil
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	// Só o último link pedido vale.
+	if err := s.tokens.InvalidateAll(user.UserId, PurposePasswordReset, now); err != nil {
+		return err
+	}
+
+	record := models.ActionToken{
+		Purpose:   PurposePasswordReset,
+		TokenHash: hashToken(token),
+		UserId:    user.UserId,
+		ExpiresAt: now.Add(ResetTokenTTL()),
+	}
+
+	if err := s.tokens.Create(&record); err != nil {
+		return fmt.Errorf("erro ao salvar token de redefinição: %v", err)
+	}
+
+	mail := mailService.Mail{
+		To:      *user.Email,
+		Subject: "Redefinição de senha",
+		Body: fmt.Sprintf("Olá, %s.\n\nPara criar uma nova senha, acesse:\n%s\n\nO link expira em %s. Se você não pediu a redefinição, ignore este e-mail.\n",
+			user.Name, resetURL(token), ResetTokenTTL()),
+	}
+
+	// O envio fica fora da requisição: um SMTP lento não segura a resposta
+	// nem deixa o tempo dela revelar se a conta existe.
+	go func() {
+		if err := s.mailer.Send(mail); err != nil {
+			log.Printf("Erro ao enviar e-mail de redefinição: %v", err)
+		}
+	}()
+
+	audit.Log("password.reset_requested", map[string]interface{}{"user_id": user.UserId})
+
+	return nil
+}
+
+// Reset troca a senha usando o token do e-mail e encerra todas as sessões
+// do usuário. Devolve o user_id, para que as conexões dele sejam fechadas.
+func (s *PasswordService) Reset(token, next string) (string, error) {
+	now := time.Now()
+
+	record, err := s.tokens.FindByHash(PurposePasswordReset, hashToken(token))
+	if errors.Is(err, actionTokenRepository.ErrActionTokenNotFound) {
+		return "", ErrInvalidResetToken
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if record.UsedAt != nil || now.After(record.ExpiresAt) {
+		return "", ErrInvalidResetToken
+	}
+
+	user, err := s.users.FindByUserId(record.UserId)
+	if errors.Is(err, userRepository.ErrUserNotFound) {
+		return "", ErrInvalidResetToken
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	// A política é conferida antes de gastar o token, para o usuário poder
+	// tentar outra senha com o mesmo link.
+	if err := s.policy.Validate(next, user.Username); err != nil {
+		return "", err
+	}
+
+	used, err := s.tokens.MarkUsed(record.ID, now)
+	if err != nil {
+		return "", err
+	}
+
+	if !used {
+		return "", ErrInvalidResetToken
+	}
+
+	if err := s.setPassword(user, next); err != nil {
+		return "", err
+	}
+
+	audit.Log("password.reset", map[string]interface{}{"user_id": user.UserId})
+
+	if _, err := s.auth.RevokeAllSessions(user.UserId, ""); err != nil {
+		return user.UserId, err
+	}
+
+	return user.UserId, nil
+}
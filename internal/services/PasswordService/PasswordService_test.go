@@ -0,0 +1,230 @@
+package passwordService
+
+import (
+	"errors"
+	"go-web-socket/internal/models"
+	actionTokenRepository "go-web-socket/internal/repositories/ActionTokenRepository"
+	refreshTokenRepository "go-web-socket/internal/repositories/RefreshTokenRepository"
+	sessionRepository "go-web-socket/internal/repositories/SessionRepository"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	authService "go-web-socket/internal/services/AuthService"
+	mailService "go-web-socket/internal/services/MailService"
+	useHash "go-web-socket/internal/utils/hash"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "password-test")
+	if err != nil {
+		panic(err)
+	}
+
+	os.Setenv("AUDIT_LOG_FILE", filepath.Join(dir, "audit.log"))
+
+	code := m.Run()
+
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// mailbox guarda os e-mails enviados, para o teste ler o link.
+type mailbox chan mailService.Mail
+
+func (m mailbox) Send(mail mailService.Mail) error {
+	m <- mail
+	return nil
+}
+
+var resetLink = regexp.MustCompile(`token=(\S+)`)
+
+type fixture struct {
+	service *PasswordService
+	users   *userRepository.MemoryUserRepository
+	tokens  *actionTokenRepository.MemoryActionTokenRepository
+	mails   mailbox
+}
+
+func newFixture(t *testing.T, verified bool) *fixture {
+	t.Helper()
+
+	f := &fixture{
+		users:  userRepository.NewMemoryUserRepository(),
+		tokens: actionTokenRepository.NewMemoryActionTokenRepository(),
+		mails:  make(mailbox, 10),
+	}
+
+	auth := authService.New(f.users, refreshTokenRepository.NewMemoryRefreshTokenRepository(), sessionRepository.NewMemorySessionRepository())
+	policy := Policy{MinLength: 8, MaxLength: 72, RequireUpper: true, RequireLower: true, RequireDigit: true}
+
+	f.service = New(f.users, f.tokens, auth, f.mails, policy)
+
+	email := "alice@example.com"
+	user := models.User{UserId: "u1", Username: "alice", Email: &email, Verified: verified}
+	if err := f.users.Create(&user); err != nil {
+		t.Fatal(err)
+	}
+
+	return f
+}
+
+// resetToken pede a redefinição e devolve o token do link enviado.
+func (f *fixture) resetToken(t *testing.T, login string) string {
+	t.Helper()
+
+	if err := f.service.RequestReset(login); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case mail := <-f.mails:
+		match := resetLink.FindStringSubmatch(mail.Body)
+		if match == nil {
+			t.Fatalf("e-mail sem link: %q", mail.Body)
+		}
+
+		token, err := url.QueryUnescape(match[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return token
+	case <-time.After(time.Second):
+		t.Fatal("o e-mail de redefinição não foi enviado")
+		return ""
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	policy := Policy{MinLength: 8, MaxLength: 72, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true}
+
+	tests := []struct {
+		password string
+		problems int
+	}{
+		{"Correta1!", 0},
+		{"curta1!", 2}, // tamanho e maiúscula
+		{"SemNumero!", 1},
+		{"Alice123!x", 1}, // contém o username
+		{"tudominusculo", 3},
+		{strings.Repeat("Aa1!", 19), 1}, // 76 bytes
+	}
+
+	for _, test := range tests {
+		err := policy.Validate(test.password, "alice")
+
+		var policyErr *PolicyError
+		if test.problems == 0 {
+			if err != nil {
+				t.Errorf("%q: %v", test.password, err)
+			}
+
+			continue
+		}
+
+		if !errors.As(err, &policyErr) || len(policyErr.Problems) != test.problems {
+			t.Errorf("%q: esperava %d problemas, veio %v", test.password, test.problems, err)
+		}
+	}
+}
+
+func TestReset(t *testing.T) {
+	f := newFixture(t, true)
+
+	token := f.resetToken(t, "ALICE@example.com")
+
+	// A senha fraca não gasta o link
+	var policyErr *PolicyError
+	if _, err := f.service.Reset(token, "fraca"); !errors.As(err, &policyErr) {
+		t.Fatalf("esperava PolicyError, veio %v", err)
+	}
+
+	userId, err := f.service.Reset(token, "NovaSenha123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := f.users.FindByUserId(userId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !useHash.CheckPasswordHash("NovaSenha123", user.Password) {
+		t.Fatal("a senha não foi trocada")
+	}
+
+	if _, err := f.service.Reset(token, "OutraSenha123"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Fatalf("o link não pode ser usado duas vezes, veio %v", err)
+	}
+}
+
+func TestOnlyTheLatestResetLinkIsValid(t *testing.T) {
+	f := newFixture(t, true)
+
+	first := f.resetToken(t, "alice")
+	second := f.resetToken(t, "alice")
+
+	if _, err := f.service.Reset(first, "NovaSenha123"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Fatalf("esperava ErrInvalidResetToken, veio %v", err)
+	}
+
+	if _, err := f.service.Reset(second, "NovaSenha123"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResetLinkExpires(t *testing.T) {
+	t.Setenv("PASSWORD_RESET_TTL", "1ms")
+
+	f := newFixture(t, true)
+	token := f.resetToken(t, "alice")
+
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := f.service.Reset(token, "NovaSenha123"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Fatalf("esperava ErrInvalidResetToken, veio %v", err)
+	}
+}
+
+func TestRequestResetRequiresAVerifiedEmail(t *testing.T) {
+	f := newFixture(t, false)
+
+	if err := f.service.RequestReset("alice@example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.tokens.FindLatest("u1", PurposePasswordReset); !errors.Is(err, actionTokenRepository.ErrActionTokenNotFound) {
+		t.Fatalf("um e-mail não confirmado recebeu link de redefinição: %v", err)
+	}
+
+	// Conta inexistente também não gera erro
+	if err := f.service.RequestReset("ninguem@example.com"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChangeChecksTheCurrentPassword(t *testing.T) {
+	f := newFixture(t, true)
+
+	hash, err := useHash.HashPassword("Atual12345")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.users.SetPassword("u1", hash); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.service.Change("u1", "", "errada", "NovaSenha123"); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("esperava ErrWrongPassword, veio %v", err)
+	}
+
+	if _, err := f.service.Change("u1", "", "Atual12345", "NovaSenha123"); err != nil {
+		t.Fatal(err)
+	}
+}
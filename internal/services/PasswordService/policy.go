@@ -0,0 +1,100 @@
+package passwordService
+
+import (
+	"fmt"
+	"go-web-socket/config"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Policy define as regras de senha aplicadas no cadastro, na troca e na
+// redefinição.
+type Policy struct {
+	MinLength int
+	// MaxLength existe porque o bcrypt ignora o que passa de 72 bytes.
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+func LoadPolicy() Policy {
+	config.LoadEnv()
+
+	return Policy{
+		MinLength:     config.GetEnvInt("PASSWORD_MIN_LENGTH", 8),
+		MaxLength:     config.GetEnvInt("PASSWORD_MAX_LENGTH", 72),
+		RequireUpper:  config.GetEnvBool("PASSWORD_REQUIRE_UPPER", true),
+		RequireLower:  config.GetEnvBool("PASSWORD_REQUIRE_LOWER", true),
+		RequireDigit:  config.GetEnvBool("PASSWORD_REQUIRE_DIGIT", true),
+		RequireSymbol: config.GetEnvBool("PASSWORD_REQUIRE_SYMBOL", false),
+	}
+}
+
+// PolicyError lista todas as regras que a senha não cumpre, para o cliente
+// mostrar de uma vez.
+type PolicyError struct {
+	Problems []string
+}
+
+func (e *PolicyError) Error() string {
+	return "senha fraca: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate confere a senha contra a política. username é usado para recusar
+// senhas que contêm o próprio login.
+func (p Policy) Validate(password, username string) error {
+	var problems []string
+
+	length := utf8.RuneCountInString(password)
+
+	if length < p.MinLength {
+		problems = append(problems, fmt.Sprintf("deve ter pelo menos %d caracteres", p.MinLength))
+	}
+
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		problems = append(problems, fmt.Sprintf("deve ter no máximo %d bytes", p.MaxLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+
+	if p.RequireUpper && !upper {
+		problems = append(problems, "deve ter uma letra maiúscula")
+	}
+
+	if p.RequireLower && !lower {
+		problems = append(problems, "deve ter uma letra minúscula")
+	}
+
+	if p.RequireDigit && !digit {
+		problems = append(problems, "deve ter um número")
+	}
+
+	if p.RequireSymbol && !symbol {
+		problems = append(problems, "deve ter um símbolo")
+	}
+
+	if username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		problems = append(problems, "não pode conter o nome de usuário")
+	}
+
+	if len(problems) > 0 {
+		return &PolicyError{Problems: problems}
+	}
+
+	return nil
+}
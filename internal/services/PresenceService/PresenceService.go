@@ -0,0 +1,242 @@
+package presenceService
+
+import (
+	"errors"
+	"fmt"
+	"go-web-socket/config"
+	"go-web-socket/internal/models"
+	messageRepository "go-web-socket/internal/repositories/MessageRepository"
+	roomRepository "go-web-socket/internal/repositories/RoomRepository"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	"slices"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+// Status que o usuário pode escolher. "online" volta ao automático: online
+// enquanto conectado e ausente depois de um tempo sem atividade.
+const (
+	StatusOnline    = "online"
+	StatusAway      = "away"
+	StatusDND       = "dnd"
+	StatusInvisible = "invisible" // aparece offline para os outros
+)
+
+// StatusOffline é como os outros veem quem não está conectado ou está
+// invisível; não pode ser escolhido.
+const StatusOffline = "offline"
+
+var Statuses = []string{StatusOnline, StatusAway, StatusDND, StatusInvisible}
+
+// MaxTextLength limita o texto do status.
+const MaxTextLength = 100
+
+var (
+	ErrInvalidStatus = errors.New("status inválido")
+	ErrTextTooLong   = fmt.Errorf("o texto do status passa de %d caracteres", MaxTextLength)
+	ErrInvalidExpiry = errors.New("a validade deve estar no futuro")
+)
+
+type Config struct {
+	// IdleAfter é o tempo sem atividade em todas as conexões até o usuário
+	// aparecer como ausente.
+	IdleAfter time.Duration
+}
+
+func LoadConfig() Config {
+	config.LoadEnv()
+
+	return Config{
+		IdleAfter: config.GetEnvDuration("PRESENCE_IDLE_AFTER", 5*time.Minute),
+	}
+}
+
+// Presence é como um usuário aparece para os contatos.
+type Presence struct {
+	UserId     string     `json:"user_id"`
+	Status     string     `json:"status"` // online, away, dnd ou offline
+	Text       string     `json:"text,omitempty"`
+	Until      *time.Time `json:"until,omitempty"`        // fim do status escolhido
+	Idle       bool       `json:"idle,omitempty"`         // ausente por falta de atividade
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"` // só para quem está offline
+}
+
+// Same diz se as duas presenças aparecem iguais para os contatos.
+func (p Presence) Same(other Presence) bool {
+	sameUntil := (p.Until == nil && other.Until == nil) ||
+		(p.Until != nil && other.Until != nil && p.Until.Equal(*other.Until))
+
+	return p.Status == other.Status && p.Text == other.Text && p.Idle == other.Idle && sameUntil
+}
+
+// Chosen devolve o status escolhido pelo usuário, ou o automático se não há
+// um ou se ele venceu.
+func Chosen(user models.User, now time.Time) (status, text string, until *time.Time) {
+	if user.StatusExpiresAt != nil && !user.StatusExpiresAt.After(now) {
+		return StatusOnline, "", nil
+	}
+
+	status = user.Status
+	if status == "" {
+		status = StatusOnline
+	}
+
+	return status, user.StatusText, user.StatusExpiresAt
+}
+
+// Resolve calcula a presença do usuário a partir do status escolhido e da
+// situação dele no registro de presença (conectado e ocioso).
+func Resolve(user models.User, online, idle bool, now time.Time) Presence {
+	status, text, until := Chosen(user, now)
+
+	if !online || status == StatusInvisible {
+		return Presence{UserId: user.UserId, Status: StatusOffline, LastSeenAt: user.LastSeenAt}
+	}
+
+	presence := Presence{UserId: user.UserId, Status: status, Text: text, Until: until}
+
+	if status == StatusOnline && idle {
+		presence.Status = StatusAway
+		presence.Idle = true
+	}
+
+	return presence
+}
+
+type PresenceService struct {
+	cfg      Config
+	users    userRepository.UserRepository
+	rooms    roomRepository.RoomRepository
+	messages messageRepository.MessageRepository
+}
+
+func New(cfg Config, users userRepository.UserRepository, rooms roomRepository.RoomRepository, messages messageRepository.MessageRepository) *PresenceService {
+	return &PresenceService{cfg: cfg, users: users, rooms: rooms, messages: messages}
+}
+
+func (s *PresenceService) IdleAfter() time.Duration {
+	return s.cfg.IdleAfter
+}
+
+func (s *PresenceService) User(userId string) (models.User, error) {
+	return s.users.FindByUserId(userId)
+}
+
+// SetStatus grava o status escolhido e devolve o usuário antes e depois da
+// mudança, para que a presença seja anunciada aos contatos.
+func (s *PresenceService) SetStatus(userId, status, text string, expiresAt *time.Time) (models.User, models.User, error) {
+	if !slices.Contains(Statuses, status) {
+		return models.User{}, models.User{}, ErrInvalidStatus
+	}
+
+	if utf8.RuneCountInString(text) > MaxTextLength {
+		return models.User{}, models.User{}, ErrTextTooLong
+	}
+
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return models.User{}, models.User{}, ErrInvalidExpiry
+	}
+
+	before, err := s.users.FindByUserId(userId)
+	if err != nil {
+		return before, before, err
+	}
+
+	stored := status
+	if status == StatusOnline {
+		stored = ""
+	}
+
+	if stored == "" && text == "" {
+		expiresAt = nil // nada para vencer
+	}
+
+	if err := s.users.SetStatus(userId, stored, text, expiresAt); err != nil {
+		return before, before, err
+	}
+
+	after, err := s.users.FindByUserId(userId)
+
+	return before, after, err
+}
+
+// Contacts devolve quem pode acompanhar a presença do usuário: quem já
+// trocou mensagens privadas com ele nos dois sentidos ou divide com ele uma
+// sala em que os dois já escreveram. Receber uma mensagem ou ser incluído
+// numa sala, sozinho, não torna ninguém contato.
+func (s *PresenceService) Contacts(userId string) ([]string, error) {
+	user, err := s.users.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	contacts := []string{}
+
+	add := func(filter userRepository.UserFilter) error {
+		users, err := s.users.Search(filter)
+		if err != nil {
+			return err
+		}
+
+		for _, contact := range users {
+			if contact.UserId != userId && !slices.Contains(contacts, contact.UserId) {
+				contacts = append(contacts, contact.UserId)
+			}
+		}
+
+		return nil
+	}
+
+	partners, err := s.messages.ConversationPartners(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(partners) > 0 {
+		if err := add(userRepository.UserFilter{IDs: partners}); err != nil {
+			return nil, err
+		}
+	}
+
+	rooms, err := s.rooms.ListByUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, room := range rooms {
+		senders, err := s.messages.RoomSenders(room.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if !slices.Contains(senders, user.ID) {
+			continue
+		}
+
+		// Só conta quem escreveu e ainda está na sala
+		members, err := s.rooms.Members(room.RoomId)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := add(userRepository.UserFilter{IDs: senders, UserIds: members}); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(contacts)
+
+	return contacts, nil
+}
+
+// MarkSeen grava quando o usuário deixou de aparecer online.
+func (s *PresenceService) MarkSeen(userId string, at time.Time) error {
+	return s.users.SetLastSeen(userId, at)
+}
+
+// ExpireStatus volta ao automático o status que venceu; retorna false se
+// outro nó já fez isso.
+func (s *PresenceService) ExpireStatus(userId string, now time.Time) (bool, error) {
+	return s.users.ClearExpiredStatus(userId, now)
+}
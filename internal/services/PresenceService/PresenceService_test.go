@@ -0,0 +1,127 @@
+package presenceService
+
+import (
+	"go-web-socket/internal/models"
+	messageRepository "go-web-socket/internal/repositories/MessageRepository"
+	roomRepository "go-web-socket/internal/repositories/RoomRepository"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	"slices"
+	"testing"
+	"time"
+)
+
+type fixture struct {
+	service  *PresenceService
+	users    map[string]models.User
+	rooms    *roomRepository.MemoryRoomRepository
+	messages *messageRepository.MemoryMessageRepository
+}
+
+func newFixture(t *testing.T, names ...string) fixture {
+	t.Helper()
+
+	f := fixture{
+		users:    make(map[string]models.User),
+		rooms:    roomRepository.NewMemoryRoomRepository(),
+		messages: messageRepository.NewMemoryMessageRepository(),
+	}
+
+	users := userRepository.NewMemoryUserRepository()
+	for _, name := range names {
+		user := models.User{UserId: name, Username: name}
+		if err := users.Create(&user); err != nil {
+			t.Fatal(err)
+		}
+		f.users[name] = user
+	}
+
+	f.service = New(Config{IdleAfter: time.Minute}, users, f.rooms, f.messages)
+
+	return f
+}
+
+func (f fixture) private(t *testing.T, from, to string) {
+	t.Helper()
+
+	recipient := f.users[to].ID
+	if err := f.messages.Create(&models.Message{UserID: f.users[from].ID, RecipientID: &recipient, Content: "oi"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (f fixture) post(t *testing.T, room models.Room, from string) {
+	t.Helper()
+
+	if err := f.messages.Create(&models.Message{UserID: f.users[from].ID, RoomID: &room.ID, Content: "oi"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (f fixture) contacts(t *testing.T, userId string) []string {
+	t.Helper()
+
+	contacts, err := f.service.Contacts(userId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return contacts
+}
+
+func TestContactsRequireAReply(t *testing.T) {
+	f := newFixture(t, "alice", "bob")
+
+	f.private(t, "bob", "alice")
+
+	if got := f.contacts(t, "alice"); len(got) != 0 {
+		t.Fatalf("uma mensagem sem resposta não faz contato: %v", got)
+	}
+	if got := f.contacts(t, "bob"); len(got) != 0 {
+		t.Fatalf("uma mensagem sem resposta não faz contato: %v", got)
+	}
+
+	f.private(t, "alice", "bob")
+
+	if got := f.contacts(t, "bob"); !slices.Equal(got, []string{"alice"}) {
+		t.Fatalf("contatos de bob = %v, esperado [alice]", got)
+	}
+}
+
+func TestContactsInRoomsRequireBothToWrite(t *testing.T) {
+	f := newFixture(t, "alice", "bob", "carol")
+
+	room := models.Room{RoomId: "r1", Name: "geral", OwnerId: "bob"}
+	if err := f.rooms.Create(&room); err != nil {
+		t.Fatal(err)
+	}
+
+	// Incluída pelo dono, alice ainda não aceitou nada
+	for _, member := range []string{"alice", "carol"} {
+		if err := f.rooms.AddMember(room.RoomId, member); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.post(t, room, "bob")
+
+	if got := f.contacts(t, "bob"); len(got) != 0 {
+		t.Fatalf("membros que não escreveram não são contatos: %v", got)
+	}
+
+	f.post(t, room, "alice")
+
+	if got := f.contacts(t, "bob"); !slices.Equal(got, []string{"alice"}) {
+		t.Fatalf("contatos de bob = %v, esperado [alice]", got)
+	}
+	if got := f.contacts(t, "carol"); len(got) != 0 {
+		t.Fatalf("carol não escreveu na sala: %v", got)
+	}
+
+	// Quem sai da sala deixa de ser contato por ela
+	if err := f.rooms.RemoveMember(room.RoomId, "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := f.contacts(t, "bob"); len(got) != 0 {
+		t.Fatalf("alice saiu da sala: %v", got)
+	}
+}
@@ -1,6 +1,7 @@
 package s3uploadservice
 
 import (
+	"bytes"
 	"encoding/base64"
 	"fmt"
 	"go-web-socket/internal/utils/file"
@@ -102,3 +103,53 @@ func Upload(base64Data string) (string, error) {
 
 	return fileURL, nil
 }
+
+func PutObject(key string, data []byte, contentType string) error {
+	godotenv.Load()
+
+	sess, err := session.NewSession(&aws.Config{
+		Region: aws.String(os.Getenv("AWS_DEFAULT_REGION")),
+	})
+	if err != nil {
+		log.Printf("Erro ao criar sessão: %v", err)
+		return fmt.Errorf("erro ao criar sessão: %v", err)
+	}
+
+	_, err = s3.New(sess).PutObject(&s3.PutObjectInput{
+		Bucket:      aws.String(os.Getenv("AWS_BUCKET")),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String(contentType),
+	})
+
+	if err != nil {
+		log.Printf("Erro ao fazer upload do arquivo: %v", err)
+		return fmt.Errorf("erro ao fazer upload do arquivo: %v", err)
+	}
+
+	return nil
+}
+
+func DeleteObject(key string) error {
+	godotenv.Load()
+
+	sess, err := session.NewSession(&aws.Config{
+		Region: aws.String(os.Getenv("AWS_DEFAULT_REGION")),
+	})
+	if err != nil {
+		log.Printf("Erro ao criar sessão: %v", err)
+		return fmt.Errorf("erro ao criar sessão: %v", err)
+	}
+
+	_, err = s3.New(sess).DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(os.Getenv("AWS_BUCKET")),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		log.Printf("Erro ao remover arquivo: %v", err)
+		return fmt.Errorf("erro ao remover arquivo: %v", err)
+	}
+
+	return nil
+}
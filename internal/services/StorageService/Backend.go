@@ -0,0 +1,98 @@
+package storageService
+
+import (
+	"errors"
+	"fmt"
+	"go-web-socket/config"
+	s3uploadservice "go-web-socket/internal/services/S3UploadService"
+	"os"
+	"path/filepath"
+)
+
+// Backend guarda os blobs pela chave derivada do hash do conteúdo.
+type Backend interface {
+	Put(key string, data []byte, contentType string) error
+	Delete(key string) error
+	URL(key string) string
+}
+
+type LocalBackend struct {
+	Dir string
+}
+
+func (b LocalBackend) path(key string) string {
+	return filepath.Join(b.Dir, filepath.FromSlash(key))
+}
+
+func (b LocalBackend) Put(key string, data []byte, _ string) error {
+	path := b.path(key)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("erro ao criar diretório: %v", err)
+	}
+
+	// Escreve num arquivo temporário e renomeia, para que um blob nunca
+	// fique visível pela metade.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("erro ao criar arquivo: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("erro ao gravar arquivo: %v", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("erro ao gravar arquivo: %v", err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (b LocalBackend) Delete(key string) error {
+	err := os.Remove(b.path(key))
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+func (b LocalBackend) URL(key string) string {
+	return filepath.ToSlash(b.path(key))
+}
+
+type S3Backend struct{}
+
+func (S3Backend) Put(key string, data []byte, contentType string) error {
+	return s3uploadservice.PutObject(key, data, contentType)
+}
+
+func (S3Backend) Delete(key string) error {
+	return s3uploadservice.DeleteObject(key)
+}
+
+func (S3Backend) URL(key string) string {
+	return key
+}
+
+// NewBackendFromEnv escolhe o backend pela variável STORAGE_DRIVER
+// ("local" por padrão ou "s3"). STORAGE_DIR define a pasta do backend local.
+func NewBackendFromEnv() Backend {
+	config.LoadEnv()
+
+	switch os.Getenv("STORAGE_DRIVER") {
+	case "s3":
+		return S3Backend{}
+	default:
+		dir := os.Getenv("STORAGE_DIR")
+		if dir == "" {
+			dir = "uploads"
+		}
+
+		return LocalBackend{Dir: dir}
+	}
+}
@@ -0,0 +1,186 @@
+package storageService
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-web-socket/internal/models"
+	"log"
+	"net/http"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrFileNotFound = errors.New("arquivo não encontrado")
+
+type StorageService struct {
+	db      *gorm.DB
+	backend Backend
+}
+
+func New(db *gorm.DB, backend Backend) *StorageService {
+	return &StorageService{db: db, backend: backend}
+}
+
+// BlobKey devolve a chave do blob no backend para um hash SHA-256.
+func BlobKey(hash string) string {
+	return fmt.Sprintf("blobs/%s/%s", hash[:2], hash)
+}
+
+func HashContent(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Store grava o conteúdo (se ainda não existir um blob com o mesmo hash) e
+// cria uma nova referência fileId -> hash, incrementando o contador do blob.
+func (s *StorageService) Store(userId, fileId, filename string, data []byte) (models.File, error) {
+	var file models.File
+
+	hash := HashContent(data)
+	mimeType := http.DetectContentType(data)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		stored := models.StoredFile{
+			Hash:     hash,
+			Size:     int64(len(data)),
+			MimeType: mimeType,
+			RefCount: 1,
+		}
+
+		result := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "hash"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"ref_count": gorm.Expr("ref_count + ?", 1),
+			}),
+		}).Create(&stored)
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if err := tx.Where("hash = ?", hash).First(&stored).Error; err != nil {
+			return err
+		}
+
+		// Só a primeira referência grava o blob. A linha fica travada até o
+		// commit, então um Release concorrente não apaga o blob no meio.
+		if stored.RefCount == 1 {
+			if err := s.backend.Put(BlobKey(hash), data, mimeType); err != nil {
+				return err
+			}
+		}
+
+		file = models.File{
+			FileId:   fileId,
+			UserId:   userId,
+			Filename: filename,
+			Hash:     hash,
+		}
+
+		return tx.Create(&file).Error
+	})
+
+	if err != nil {
+		log.Printf("Erro ao registrar arquivo %s: %v", fileId, err)
+		return file, fmt.Errorf("erro ao registrar arquivo: %v", err)
+	}
+
+	return file, nil
+}
+
+func (s *StorageService) Find(fileId string) (models.File, error) {
+	var file models.File
+
+	result := s.db.Where("file_id = ?", fileId).Limit(1).Find(&file)
+	if result.Error != nil {
+		return file, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return file, ErrFileNotFound
+	}
+
+	return file, nil
+}
+
+// Release remove a referência fileId. Quando ela era a última, o blob fica
+// com o contador em zero e só é apagado depois do commit, por purge.
+func (s *StorageService) Release(fileId string) error {
+	var (
+		hash string
+		last bool
+	)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var file models.File
+
+		result := tx.Where("file_id = ?", fileId).Limit(1).Find(&file)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrFileNotFound
+		}
+
+		if err := tx.Delete(&file).Error; err != nil {
+			return err
+		}
+
+		var stored models.StoredFile
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("hash = ?", file.Hash).
+			First(&stored).Error
+		if err != nil {
+			return err
+		}
+
+		hash, last = stored.Hash, stored.RefCount <= 1
+
+		return tx.Model(&stored).UpdateColumn("ref_count", gorm.Expr("ref_count - ?", 1)).Error
+	})
+
+	if err != nil || !last {
+		return err
+	}
+
+	s.purge(hash)
+
+	return nil
+}
+
+// purge apaga o blob sem referências e a linha dele. A linha fica travada
+// enquanto o blob é apagado, para não correr com um Store do mesmo conteúdo;
+// se um Store chegou antes, o contador não está mais em zero e nada é
+// apagado. Se falhar, a linha continua com o contador em zero e o próximo
+// Store do mesmo conteúdo grava o blob de novo.
+func (s *StorageService) purge(hash string) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var stored models.StoredFile
+
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("hash = ? AND ref_count <= 0", hash).
+			Limit(1).Find(&stored)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		if err := s.backend.Delete(BlobKey(hash)); err != nil {
+			return err
+		}
+
+		return tx.Delete(&stored).Error
+	})
+
+	if err != nil {
+		log.Printf("Erro ao remover blob %s: %v", hash, err)
+	}
+}
+
+func (s *StorageService) URL(hash string) string {
+	return s.backend.URL(BlobKey(hash))
+}
@@ -0,0 +1,292 @@
+package twoFactorService
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-web-socket/config"
+	"go-web-socket/internal/models"
+	recoveryCodeRepository "go-web-socket/internal/repositories/RecoveryCodeRepository"
+	sessionRepository "go-web-socket/internal/repositories/SessionRepository"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	jwtService "go-web-socket/internal/services/JWTService"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+var (
+	ErrAlreadyEnabled   = errors.New("a autenticação em dois fatores já está ativada")
+	ErrNotEnrolled      = errors.New("a autenticação em dois fatores não foi iniciada")
+	ErrNotEnabled       = errors.New("a autenticação em dois fatores não está ativada")
+	ErrInvalidCode      = errors.New("código inválido")
+	ErrInvalidChallenge = errors.New("desafio inválido ou expirado")
+)
+
+const (
+	period = 30
+	// skew aceita o código do passo anterior e do seguinte, para relógios
+	// levemente fora de sincronia.
+	skew = 1
+
+	recoveryCodeCount = 10
+)
+
+var totpOpts = totp.ValidateOpts{
+	Period:    period,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// Enrollment é devolvido ao iniciar o cadastro: o cliente mostra o URI como
+// QR code ou o segredo para digitação manual.
+type Enrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type TwoFactorService struct {
+	users         userRepository.UserRepository
+	recoveryCodes recoveryCodeRepository.RecoveryCodeRepository
+	sessions      sessionRepository.SessionRepository
+	now           func() time.Time
+}
+
+func New(users userRepository.UserRepository, recoveryCodes recoveryCodeRepository.RecoveryCodeRepository, sessions sessionRepository.SessionRepository) *TwoFactorService {
+	return &TwoFactorService{users: users, recoveryCodes: recoveryCodes, sessions: sessions, now: time.Now}
+}
+
+func issuer() string {
+	config.LoadEnv()
+
+	return config.GetEnv("TOTP_ISSUER", "go-web-socket")
+}
+
+// Enroll gera um novo segredo para o usuário. O 2FA só passa a valer depois
+// de Confirm; até lá um novo Enroll substitui o segredo.
+func (s *TwoFactorService) Enroll(user models.User) (Enrollment, error) {
+	if user.TOTPEnabled {
+		return Enrollment{}, ErrAlreadyEnabled
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      issuer(),
+		AccountName: user.Username,
+		Period:      period,
+		Digits:      otp.DigitsSix,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		return Enrollment{}, fmt.Errorf("erro ao gerar segredo: %v", err)
+	}
+
+	if err := s.users.SetTOTP(user.UserId, key.Secret(), false); err != nil {
+		return Enrollment{}, err
+	}
+
+	return Enrollment{Secret: key.Secret(), URI: key.URL()}, nil
+}
+
+// Confirm ativa o 2FA com o primeiro código gerado pelo aplicativo e devolve
+// os códigos de recuperação, que não podem ser consultados de novo.
+func (s *TwoFactorService) Confirm(user models.User, code string) ([]string, error) {
+	if user.TOTPEnabled {
+		return nil, ErrAlreadyEnabled
+	}
+
+	if user.TOTPSecret == "" {
+		return nil, ErrNotEnrolled
+	}
+
+	step, ok := s.matchStep(user.TOTPSecret, code)
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+
+	if err := s.users.SetTOTP(user.UserId, user.TOTPSecret, true); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.users.AdvanceTOTPStep(user.UserId, step); err != nil {
+		return nil, err
+	}
+
+	return s.replaceRecoveryCodes(user.UserId)
+}
+
+// Disable desativa o 2FA. Exige um código válido (TOTP ou de recuperação).
+func (s *TwoFactorService) Disable(user models.User, code string) error {
+	if err := s.Verify(user, code); err != nil {
+		return err
+	}
+
+	if err := s.users.SetTOTP(user.UserId, "", false); err != nil {
+		return err
+	}
+
+	return s.recoveryCodes.DeleteAll(user.UserId)
+}
+
+// RegenerateRecoveryCodes invalida os códigos de recuperação atuais e gera novos.
+func (s *TwoFactorService) RegenerateRecoveryCodes(user models.User, code string) ([]string, error) {
+	if err := s.Verify(user, code); err != nil {
+		return nil, err
+	}
+
+	return s.replaceRecoveryCodes(user.UserId)
+}
+
+// Verify aceita um código TOTP ainda não usado ou um código de recuperação,
+// que é consumido.
+func (s *TwoFactorService) Verify(user models.User, code string) error {
+	if !user.TOTPEnabled {
+		return ErrNotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+
+	if step, ok := s.matchStep(user.TOTPSecret, code); ok {
+		advanced, err := s.users.AdvanceTOTPStep(user.UserId, step)
+		if err != nil {
+			return err
+		}
+
+		if !advanced {
+			return ErrInvalidCode
+		}
+
+		return nil
+	}
+
+	consumed, err := s.recoveryCodes.Consume(user.UserId, hashRecoveryCode(code), s.now())
+	if err != nil {
+		return err
+	}
+
+	if !consumed {
+		return ErrInvalidCode
+	}
+
+	return nil
+}
+
+// Challenge emite o token de desafio do segundo passo do login.
+func (s *TwoFactorService) Challenge(user models.User) (string, *jwtService.Challenge, error) {
+	return jwtService.CreateChallengeToken(user.UserId)
+}
+
+// ResolveChallenge valida o token de desafio e devolve o usuário dele, sem
+// consumir o token.
+func (s *TwoFactorService) ResolveChallenge(token string) (models.User, *jwtService.Challenge, error) {
+	challenge, err := jwtService.DecodeChallengeToken(token)
+	if err != nil {
+		return models.User{}, nil, ErrInvalidChallenge
+	}
+
+	revoked, err := s.sessions.IsTokenRevoked(challenge.TokenId)
+	if err != nil {
+		return models.User{}, nil, err
+	}
+
+	if revoked {
+		return models.User{}, nil, ErrInvalidChallenge
+	}
+
+	user, err := s.users.FindByUserId(challenge.UserId)
+	if errors.Is(err, userRepository.ErrUserNotFound) {
+		return models.User{}, nil, ErrInvalidChallenge
+	}
+
+	if err != nil {
+		return models.User{}, nil, err
+	}
+
+	if !user.TOTPEnabled {
+		return models.User{}, nil, ErrInvalidChallenge
+	}
+
+	return user, challenge, nil
+}
+
+// CompleteChallenge confere o código e consome o token de desafio, que não
+// pode ser trocado duas vezes.
+func (s *TwoFactorService) CompleteChallenge(user models.User, challenge *jwtService.Challenge, code string) error {
+	if err := s.Verify(user, code); err != nil {
+		return err
+	}
+
+	return s.sessions.RevokeToken(challenge.TokenId, challenge.ExpiresAt)
+}
+
+// matchStep procura o código dentro da janela aceita e devolve o passo
+// (unix/30) em que ele foi gerado.
+func (s *TwoFactorService) matchStep(secret, code string) (int64, bool) {
+	if len(code) != int(otp.DigitsSix) {
+		return 0, false
+	}
+
+	now := s.now()
+
+	for offset := -skew; offset <= skew; offset++ {
+		t := now.Add(time.Duration(offset*period) * time.Second)
+
+		expected, err := totp.GenerateCodeCustom(secret, t, totpOpts)
+		if err != nil {
+			return 0, false
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return t.Unix() / period, true
+		}
+	}
+
+	return 0, false
+}
+
+func (s *TwoFactorService) replaceRecoveryCodes(userId string) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+
+		codes[i] = code
+		hashes[i] = hashRecoveryCode(code)
+	}
+
+	if err := s.recoveryCodes.Replace(userId, hashes); err != nil {
+		return nil, fmt.Errorf("erro ao salvar códigos de recuperação: %v", err)
+	}
+
+	return codes, nil
+}
+
+// newRecoveryCode gera um código como "k3j5m-2pq7x" (50 bits aleatórios).
+func newRecoveryCode() (string, error) {
+	buf := make([]byte, 10)
+
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	encoded := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf))[:10]
+
+	return encoded[:5] + "-" + encoded[5:], nil
+}
+
+// hashRecoveryCode ignora maiúsculas, espaços e hífens digitados pelo usuário.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
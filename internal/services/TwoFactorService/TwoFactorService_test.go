@@ -0,0 +1,120 @@
+package twoFactorService
+
+import (
+	"errors"
+	"go-web-socket/internal/models"
+	recoveryCodeRepository "go-web-socket/internal/repositories/RecoveryCodeRepository"
+	sessionRepository "go-web-socket/internal/repositories/SessionRepository"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+)
+
+type fixture struct {
+	service *TwoFactorService
+	users   *userRepository.MemoryUserRepository
+	now     time.Time
+}
+
+// newFixture cadastra e confirma o 2FA de um usuário e devolve os códigos de
+// recuperação.
+func newFixture(t *testing.T) (*fixture, []string) {
+	t.Helper()
+
+	f := &fixture{
+		users: userRepository.NewMemoryUserRepository(),
+		now:   time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	f.service = New(f.users, recoveryCodeRepository.NewMemoryRecoveryCodeRepository(), sessionRepository.NewMemorySessionRepository())
+	f.service.now = func() time.Time { return f.now }
+
+	user := models.User{UserId: "u1", Username: "alice"}
+	if err := f.users.Create(&user); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.service.Enroll(user); err != nil {
+		t.Fatal(err)
+	}
+
+	codes, err := f.service.Confirm(f.user(t), f.code(t, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return f, codes
+}
+
+func (f *fixture) user(t *testing.T) models.User {
+	t.Helper()
+
+	user, err := f.users.FindByUserId("u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return user
+}
+
+// code gera o código do passo atual mais offset.
+func (f *fixture) code(t *testing.T, offset int) string {
+	t.Helper()
+
+	code, err := totp.GenerateCodeCustom(f.user(t).TOTPSecret, f.now.Add(time.Duration(offset*period)*time.Second), totpOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return code
+}
+
+func TestVerifyRejectsAReusedStep(t *testing.T) {
+	f, _ := newFixture(t)
+
+	// O código que confirmou o cadastro já foi usado
+	if err := f.service.Verify(f.user(t), f.code(t, 0)); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("esperava ErrInvalidCode, veio %v", err)
+	}
+
+	f.now = f.now.Add(period * time.Second)
+
+	// O passo anterior ainda está na janela, mas é mais velho que o último aceito
+	if err := f.service.Verify(f.user(t), f.code(t, -1)); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("esperava ErrInvalidCode para o passo anterior, veio %v", err)
+	}
+
+	code := f.code(t, 0)
+	if err := f.service.Verify(f.user(t), code); err != nil {
+		t.Fatalf("o código novo deveria valer: %v", err)
+	}
+
+	if err := f.service.Verify(f.user(t), code); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("o mesmo código não pode valer duas vezes, veio %v", err)
+	}
+
+	// O passo seguinte, de um relógio adiantado, ainda vale
+	if err := f.service.Verify(f.user(t), f.code(t, 1)); err != nil {
+		t.Fatalf("o código do passo seguinte deveria valer: %v", err)
+	}
+}
+
+func TestRecoveryCodesAreSingleUse(t *testing.T) {
+	f, codes := newFixture(t)
+
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("%d códigos de recuperação, esperado %d", len(codes), recoveryCodeCount)
+	}
+
+	// Maiúsculas e espaços são ignorados
+	if err := f.service.Verify(f.user(t), " "+strings.ToUpper(codes[0])+" "); err != nil {
+		t.Fatalf("o código de recuperação deveria valer: %v", err)
+	}
+
+	if err := f.service.Verify(f.user(t), codes[0]); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("o código de recuperação não pode valer duas vezes, veio %v", err)
+	}
+}
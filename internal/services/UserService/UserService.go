@@ -1,65 +1,92 @@
 package userService
 
 import (
+	"errors"
 	"fmt"
-	"go-web-socket/config"
 	"go-web-socket/internal/models"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	presenceService "go-web-socket/internal/services/PresenceService"
 )
 
-func EditUser(userId string, data models.User) (models.User, error) {
-	db, err := config.GetDatabaseConnection()
+// Profile é o perfil público de um usuário, o que as listagens e as buscas
+// mostram. Presence vem quando a presença foi consultada.
+type Profile struct {
+	UserId   string                    `json:"user_id"`
+	Username string                    `json:"username"`
+	Name     string                    `json:"name"`
+	Avatar   string                    `json:"avatar"`
+	Bot      bool                      `json:"bot"`
+	Presence *presenceService.Presence `json:"presence,omitempty"`
+}
 
-	var user models.User
+func PublicProfile(user models.User) Profile {
+	return Profile{
+		UserId:   user.UserId,
+		Username: user.Username,
+		Name:     user.Name,
+		Avatar:   user.Avatar,
+		Bot:      user.Bot,
+	}
+}
 
-	if err != nil {
-		return user, fmt.Errorf("erro ao abrir conexção com banco de dados: %v", err.Error())
+// Profiles monta os perfis públicos dos usuários, cada um com a sua presença
+// entre presences, se estiver lá.
+func Profiles(users []models.User, presences []presenceService.Presence) []Profile {
+	byUser := make(map[string]presenceService.Presence, len(presences))
+	for _, presence := range presences {
+		byUser[presence.UserId] = presence
 	}
 
-	result := db.Model(&models.User{}).Where("user_id = ?", userId).Updates(&models.User{
+	profiles := make([]Profile, 0, len(users))
+	for _, user := range users {
+		profile := PublicProfile(user)
+
+		if presence, ok := byUser[user.UserId]; ok {
+			profile.Presence = &presence
+		}
+
+		profiles = append(profiles, profile)
+	}
+
+	return profiles
+}
+
+type UserService struct {
+	users userRepository.UserRepository
+}
+
+func New(users userRepository.UserRepository) *UserService {
+	return &UserService{users: users}
+}
+
+func (s *UserService) EditUser(userId string, data models.User) (models.User, error) {
+	user, err := s.users.Update(userId, models.User{
 		Name:   data.Name,
 		Avatar: data.Avatar,
-	}).Scan(&user)
+	})
 
-	if result.RowsAffected == 0 {
+	if errors.Is(err, userRepository.ErrUserNotFound) {
 		return user, fmt.Errorf("usuário não encontrado")
 	}
 
-	if err := result.Error; err != nil {
+	if err != nil {
 		return user, fmt.Errorf("erro ao atualizar usuário: %v", err)
 
 	}
 
-	sqlDB, err := db.DB()
-	if err == nil {
-		defer sqlDB.Close()
-	}
-
 	return user, nil
 
 }
 
-func UpdateUserAvatar(userId string, fileurl string) error {
-	db, err := config.GetDatabaseConnection()
-
-	if err != nil {
-		return fmt.Errorf("erro ao abrir conexção com banco de dados: %v", err.Error())
-
-	}
-
-	result := db.Model(&models.User{}).Where("user_id = ?", userId).Updates(&models.User{
+func (s *UserService) UpdateUserAvatar(userId string, fileurl string) error {
+	_, err := s.users.Update(userId, models.User{
 		Avatar: fileurl,
 	})
 
-	if result.RowsAffected == 0 {
+	if errors.Is(err, userRepository.ErrUserNotFound) {
 		return fmt.Errorf("usúario não econtrado")
 	}
 
-	sqlDB, err := db.DB()
-
-	if err == nil {
-		defer sqlDB.Close()
-	}
-
-	return nil
+	return err
 
 }
@@ -0,0 +1,226 @@
+package verificationService
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-web-socket/config"
+	"go-web-socket/internal/models"
+	actionTokenRepository "go-web-socket/internal/repositories/ActionTokenRepository"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	mailService "go-web-socket/internal/services/MailService"
+	"go-web-socket/internal/utils/audit"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const PurposeEmailVerification = "email_verification"
+
+// Mode diz o que uma conta com e-mail não verificado deixa de poder fazer.
+type Mode string
+
+const (
+	ModeOff    Mode = "off"    // nada é bloqueado
+	ModeSocket Mode = "socket" // faz login, mas não abre o WebSocket
+	ModeLogin  Mode = "login"  // não faz login
+)
+
+var (
+	ErrInvalidToken    = errors.New("link de verificação inválido")
+	ErrTokenExpired    = errors.New("link de verificação expirado; peça um novo")
+	ErrAlreadyVerified = errors.New("e-mail já verificado")
+)
+
+type Config struct {
+	Mode Mode
+	TTL  time.Duration
+	// ResendInterval é o intervalo mínimo entre dois e-mails para a mesma conta.
+	ResendInterval time.Duration
+	URL            string
+}
+
+func LoadConfig() Config {
+	config.LoadEnv()
+
+	mode := Mode(config.GetEnv("EMAIL_VERIFICATION_MODE", string(ModeSocket)))
+	if mode != ModeOff && mode != ModeLogin {
+		mode = ModeSocket
+	}
+
+	return Config{
+		Mode:           mode,
+		TTL:            config.GetEnvDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+		ResendInterval: config.GetEnvDuration("EMAIL_VERIFICATION_RESEND_INTERVAL", time.Minute),
+		URL:            config.GetEnv("EMAIL_VERIFICATION_URL", "http://localhost:3000/verify-email"),
+	}
+}
+
+type VerificationService struct {
+	cfg    Config
+	users  userRepository.UserRepository
+	tokens actionTokenRepository.ActionTokenRepository
+	mailer mailService.Sender
+}
+
+func New(cfg Config, users userRepository.UserRepository, tokens actionTokenRepository.ActionTokenRepository, mailer mailService.Sender) *VerificationService {
+	return &VerificationService{cfg: cfg, users: users, tokens: tokens, mailer: mailer}
+}
+
+// AllowsLogin diz se o usuário pode fazer login com o e-mail no estado atual.
+func (s *VerificationService) AllowsLogin(user models.User) bool {
+	return user.Verified || s.cfg.Mode != ModeLogin
+}
+
+// AllowsSocket diz se o usuário pode abrir o WebSocket.
+func (s *VerificationService) AllowsSocket(user models.User) bool {
+	return user.Verified || s.cfg.Mode == ModeOff
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newToken() (string, error) {
+	buf := make([]byte, 32)
+
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// Send emite um novo token, invalidando os anteriores, e envia o link para
+// o e-mail do usuário.
+func (s *VerificationService) Send(user models.User) error {
+	if user.Verified {
+		return ErrAlreadyVerified
+	}
+
+	if user.Email == nil {
+		return fmt.Errorf("usuário %s não tem e-mail", user.UserId)
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	if err := s.tokens.InvalidateAll(user.UserId, PurposeEmailVerification, now); err != nil {
+		return err
+	}
+
+	record := models.ActionToken{
+		Purpose:   PurposeEmailVerification,
+		TokenHash: hashToken(token),
+		UserId:    user.UserId,
+		ExpiresAt: now.Add(s.cfg.TTL),
+	}
+
+	if err := s.tokens.Create(&record); err != nil {
+		return fmt.Errorf("erro ao salvar token de verificação: %v", err)
+	}
+
+	mail := mailService.Mail{
+		To:      *user.Email,
+		Subject: "Confirme seu e-mail",
+		Body: fmt.Sprintf("Olá, %s.\n\nPara confirmar seu e-mail, acesse:\n%s\n\nO link expira em %s.\n",
+			user.Name, s.cfg.URL+"?token="+url.QueryEscape(token), s.cfg.TTL),
+	}
+
+	go func() {
+		if err := s.mailer.Send(mail); err != nil {
+			log.Printf("Erro ao enviar e-mail de verificação: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// Resend reenvia o link para a conta com esse username ou e-mail. Contas
+// inexistentes, já verificadas ou com envio recente são ignoradas em
+// silêncio, para não revelar quais contas existem.
+func (s *VerificationService) Resend(login string) error {
+	login = strings.TrimSpace(login)
+
+	var (
+		user models.User
+		err  error
+	)
+
+	if strings.Contains(login, "@") {
+		user, err = s.users.FindByEmail(strings.ToLower(login))
+	} else {
+		user, err = s.users.FindByUsername(login)
+	}
+
+	if errors.Is(err, userRepository.ErrUserNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if user.Verified || user.Email == nil || user.Disabled {
+		return nil
+	}
+
+	latest, err := s.tokens.FindLatest(user.UserId, PurposeEmailVerification)
+	if err != nil && !errors.Is(err, actionTokenRepository.ErrActionTokenNotFound) {
+		return err
+	}
+
+	if err == nil && time.Since(latest.CreatedAt) < s.cfg.ResendInterval {
+		return nil
+	}
+
+	return s.Send(user)
+}
+
+// Verify confirma o e-mail do dono do token.
+func (s *VerificationService) Verify(token string) error {
+	now := time.Now()
+
+	record, err := s.tokens.FindByHash(PurposeEmailVerification, hashToken(token))
+	if errors.Is(err, actionTokenRepository.ErrActionTokenNotFound) {
+		return ErrInvalidToken
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if record.UsedAt != nil {
+		return ErrInvalidToken
+	}
+
+	if now.After(record.ExpiresAt) {
+		return ErrTokenExpired
+	}
+
+	used, err := s.tokens.MarkUsed(record.ID, now)
+	if err != nil {
+		return err
+	}
+
+	if !used {
+		return ErrInvalidToken
+	}
+
+	if err := s.users.SetVerified(record.UserId, true); err != nil {
+		return err
+	}
+
+	audit.Log("email.verified", map[string]interface{}{"user_id": record.UserId})
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package verificationService
+
+import (
+	"errors"
+	"go-web-socket/internal/models"
+	actionTokenRepository "go-web-socket/internal/repositories/ActionTokenRepository"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	mailService "go-web-socket/internal/services/MailService"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "verification-test")
+	if err != nil {
+		panic(err)
+	}
+
+	os.Setenv("AUDIT_LOG_FILE", filepath.Join(dir, "audit.log"))
+
+	code := m.Run()
+
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// mailbox guarda os e-mails enviados, para o teste ler o link.
+type mailbox chan mailService.Mail
+
+func (m mailbox) Send(mail mailService.Mail) error {
+	m <- mail
+	return nil
+}
+
+var verificationLink = regexp.MustCompile(`token=(\S+)`)
+
+type fixture struct {
+	service *VerificationService
+	users   *userRepository.MemoryUserRepository
+	tokens  *actionTokenRepository.MemoryActionTokenRepository
+	mails   mailbox
+	user    models.User
+}
+
+func newFixture(t *testing.T, cfg Config) *fixture {
+	t.Helper()
+
+	f := &fixture{
+		users:  userRepository.NewMemoryUserRepository(),
+		tokens: actionTokenRepository.NewMemoryActionTokenRepository(),
+		mails:  make(mailbox, 10),
+	}
+
+	f.service = New(cfg, f.users, f.tokens, f.mails)
+
+	email := "alice@example.com"
+	f.user = models.User{UserId: "u1", Username: "alice", Email: &email}
+	if err := f.users.Create(&f.user); err != nil {
+		t.Fatal(err)
+	}
+
+	return f
+}
+
+// token envia a verificação e devolve o token do link.
+func (f *fixture) token(t *testing.T) string {
+	t.Helper()
+
+	if err := f.service.Send(f.user); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case mail := <-f.mails:
+		match := verificationLink.FindStringSubmatch(mail.Body)
+		if match == nil {
+			t.Fatalf("e-mail sem link: %q", mail.Body)
+		}
+
+		token, err := url.QueryUnescape(match[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return token
+	case <-time.After(time.Second):
+		t.Fatal("o e-mail de verificação não foi enviado")
+		return ""
+	}
+}
+
+func TestGatingByMode(t *testing.T) {
+	tests := []struct {
+		mode          Mode
+		verified      bool
+		login, socket bool
+	}{
+		{ModeOff, false, true, true},
+		{ModeSocket, false, true, false},
+		{ModeLogin, false, false, false},
+		{ModeLogin, true, true, true},
+		{ModeSocket, true, true, true},
+	}
+
+	for _, test := range tests {
+		service := New(Config{Mode: test.mode}, nil, nil, nil)
+		user := models.User{Verified: test.verified}
+
+		if got := service.AllowsLogin(user); got != test.login {
+			t.Errorf("%s, verificado %v: AllowsLogin = %v", test.mode, test.verified, got)
+		}
+
+		if got := service.AllowsSocket(user); got != test.socket {
+			t.Errorf("%s, verificado %v: AllowsSocket = %v", test.mode, test.verified, got)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	f := newFixture(t, Config{Mode: ModeLogin, TTL: time.Hour})
+
+	first := f.token(t)
+	second := f.token(t)
+
+	// Um link novo invalida os anteriores
+	if err := f.service.Verify(first); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("esperava ErrInvalidToken, veio %v", err)
+	}
+
+	if err := f.service.Verify(second); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := f.users.FindByUserId(f.user.UserId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !user.Verified || !f.service.AllowsLogin(user) {
+		t.Fatal("o e-mail não ficou verificado")
+	}
+
+	if err := f.service.Verify(second); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("o link não pode ser usado duas vezes, veio %v", err)
+	}
+
+	if err := f.service.Send(user); !errors.Is(err, ErrAlreadyVerified) {
+		t.Fatalf("esperava ErrAlreadyVerified, veio %v", err)
+	}
+}
+
+func TestVerifyExpiredLink(t *testing.T) {
+	f := newFixture(t, Config{Mode: ModeLogin, TTL: time.Millisecond})
+
+	token := f.token(t)
+	time.Sleep(5 * time.Millisecond)
+
+	if err := f.service.Verify(token); !errors.Is(err, ErrTokenExpired) {
+		t.Fatalf("esperava ErrTokenExpired, veio %v", err)
+	}
+}
+
+func TestResendIsRateLimited(t *testing.T) {
+	f := newFixture(t, Config{Mode: ModeLogin, TTL: time.Hour, ResendInterval: time.Minute})
+
+	if err := f.service.Resend("alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := f.tokens.FindLatest(f.user.UserId, PurposeEmailVerification)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.service.Resend("ALICE@example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	latest, err := f.tokens.FindLatest(f.user.UserId, PurposeEmailVerification)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if latest.ID != first.ID {
+		t.Fatal("o reenvio dentro do intervalo gerou outro link")
+	}
+}
@@ -0,0 +1,583 @@
+package webhookService
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-web-socket/config"
+	"go-web-socket/internal/models"
+	webhookRepository "go-web-socket/internal/repositories/WebhookRepository"
+	"go-web-socket/internal/utils/audit"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Eventos que podem ser assinados por um webhook.
+const (
+	EventMessageCreated   = "message.created"
+	EventUserConnected    = "user.connected"
+	EventUserDisconnected = "user.disconnected"
+	EventFileUploaded     = "file.uploaded"
+)
+
+var Events = []string{EventMessageCreated, EventUserConnected, EventUserDisconnected, EventFileUploaded}
+
+// SignatureHeader leva "sha256=" + hex(HMAC-SHA256(segredo, timestamp + "." + corpo)),
+// com o timestamp de TimestampHeader.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+)
+
+const (
+	claimBatch   = 20
+	deliveryList = 50
+)
+
+var (
+	ErrInvalidURL      = errors.New("a URL do webhook deve ser https")
+	ErrInternalTarget  = errors.New("a URL do webhook aponta para um endereço interno")
+	ErrUnresolvedHost  = errors.New("não foi possível resolver o host da URL do webhook")
+	ErrInvalidEvent    = errors.New("evento inválido")
+	ErrWebhookNotFound = webhookRepository.ErrWebhookNotFound
+)
+
+type Config struct {
+	MaxAttempts int
+	// BackoffBase é a espera antes da segunda tentativa; dobra a cada nova
+	// tentativa, até BackoffMax.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	Timeout     time.Duration
+	// DisableAfter é o número de entregas seguidas que esgotam as tentativas
+	// antes de o webhook ser desativado.
+	DisableAfter int
+	PollInterval time.Duration
+	// AllowHTTP e AllowInternal valem só para os webhooks globais, criados
+	// por administradores: aceitam URLs http:// e endereços internos
+	// (loopback, rede privada, link-local). Os de sala nunca os aceitam.
+	AllowHTTP     bool
+	AllowInternal bool
+}
+
+func LoadConfig() Config {
+	config.LoadEnv()
+
+	return Config{
+		MaxAttempts:   config.GetEnvInt("WEBHOOK_MAX_ATTEMPTS", 6),
+		BackoffBase:   config.GetEnvDuration("WEBHOOK_BACKOFF_BASE", 10*time.Second),
+		BackoffMax:    config.GetEnvDuration("WEBHOOK_BACKOFF_MAX", time.Hour),
+		Timeout:       config.GetEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		DisableAfter:  config.GetEnvInt("WEBHOOK_DISABLE_AFTER", 5),
+		PollInterval:  config.GetEnvDuration("WEBHOOK_POLL_INTERVAL", 5*time.Second),
+		AllowHTTP:     config.GetEnvBool("WEBHOOK_ALLOW_HTTP", false),
+		AllowInternal: config.GetEnvBool("WEBHOOK_ALLOW_INTERNAL", false),
+	}
+}
+
+// Payload é o corpo JSON de cada entrega.
+type Payload struct {
+	Id        string      `json:"id"`
+	Event     string      `json:"event"`
+	RoomId    string      `json:"room_id,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+type WebhookService struct {
+	cfg    Config
+	repo   webhookRepository.WebhookRepository
+	client *http.Client
+	// internalClient entrega aos webhooks globais quando AllowInternal está
+	// ligado; o client recusa endereços internos na conexão.
+	internalClient *http.Client
+	wake           chan struct{}
+}
+
+func New(cfg Config, repo webhookRepository.WebhookRepository) *WebhookService {
+	return &WebhookService{
+		cfg:            cfg,
+		repo:           repo,
+		client:         newClient(cfg.Timeout, publicOnly),
+		internalClient: newClient(cfg.Timeout, nil),
+		wake:           make(chan struct{}, 1),
+	}
+}
+
+func newClient(timeout time.Duration, control func(network, address string, conn syscall.RawConn) error) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: control}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // um proxy conectaria por nós a qualquer endereço
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		// um redirecionamento conta como falha
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+}
+
+// reservedPrefixes são as faixas de uso especial (RFC 6890) que não são
+// endereços globais e não estão entre as que o net já reconhece.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "esta rede"
+	netip.MustParsePrefix("100.64.0.0/10"),   // CGNAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // atribuições do IETF
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentação
+	netip.MustParsePrefix("198.18.0.0/15"),   // testes de desempenho
+	netip.MustParsePrefix("198.51.100.0/24"), // documentação
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentação
+	netip.MustParsePrefix("240.0.0.0/4"),     // reservado, inclui o broadcast
+	netip.MustParsePrefix("::/96"),           // IPv4 compatível (obsoleto)
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // NAT64 de uso local
+	netip.MustParsePrefix("100::/64"),        // descarte
+	netip.MustParsePrefix("2001::/23"),       // atribuições do IETF, inclui o Teredo
+	netip.MustParsePrefix("2001:db8::/32"),   // documentação
+}
+
+// Faixas IPv6 que carregam um endereço IPv4, conferido no lugar delas.
+var (
+	nat64Prefix     = netip.MustParsePrefix("64:ff9b::/96")
+	sixToFourPrefix = netip.MustParsePrefix("2002::/16")
+)
+
+// internalIP diz se o endereço não é global: loopback, rede privada,
+// link-local, não especificado, multicast ou outra faixa de uso especial.
+// Endereços IPv4 dentro de IPv6 (mapeados, NAT64 e 6to4) valem pelo IPv4.
+func internalIP(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return true
+	}
+
+	return internalAddr(addr.Unmap())
+}
+
+func internalAddr(addr netip.Addr) bool {
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsUnspecified() || addr.IsMulticast() {
+		return true
+	}
+
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	bytes := addr.As16()
+
+	switch {
+	case nat64Prefix.Contains(addr):
+		return internalAddr(netip.AddrFrom4([4]byte(bytes[12:16])))
+	case sixToFourPrefix.Contains(addr):
+		return internalAddr(netip.AddrFrom4([4]byte(bytes[2:6])))
+	}
+
+	return false
+}
+
+// publicOnly é o Control do dialer das entregas: confere o endereço já
+// resolvido, para que um DNS que mude depois do cadastro não leve a
+// entrega para dentro da rede.
+func publicOnly(network, address string, conn syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip == nil || internalIP(ip) {
+		return ErrInternalTarget
+	}
+
+	return nil
+}
+
+// Sign calcula a assinatura enviada em SignatureHeader, sem o prefixo.
+func Sign(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func EventList(webhook models.Webhook) []string {
+	return strings.Fields(webhook.Events)
+}
+
+// validateURL exige https e um host que resolva só para endereços
+// públicos, a não ser nos webhooks globais com AllowHTTP e AllowInternal.
+func (s *WebhookService) validateURL(raw string, global bool) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Hostname() == "" {
+		return ErrInvalidURL
+	}
+
+	if parsed.Scheme != "https" && !(parsed.Scheme == "http" && global && s.cfg.AllowHTTP) {
+		return ErrInvalidURL
+	}
+
+	if global && s.cfg.AllowInternal {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+	defer cancel()
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, parsed.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return ErrUnresolvedHost
+	}
+
+	for _, addr := range addrs {
+		if internalIP(addr.IP) {
+			return ErrInternalTarget
+		}
+	}
+
+	return nil
+}
+
+// clientFor escolhe o cliente HTTP das entregas do webhook.
+func (s *WebhookService) clientFor(webhook models.Webhook) *http.Client {
+	if webhook.RoomId == "" && s.cfg.AllowInternal {
+		return s.internalClient
+	}
+
+	return s.client
+}
+
+// Create cadastra um webhook na sala (ou global, com roomId vazio). Sem
+// eventos, assina todos. O segredo só é devolvido aqui.
+func (s *WebhookService) Create(ownerId, roomId, rawURL string, events []string) (models.Webhook, string, error) {
+	if err := s.validateURL(rawURL, roomId == ""); err != nil {
+		return models.Webhook{}, "", err
+	}
+
+	if len(events) == 0 {
+		events = Events
+	}
+
+	for _, event := range events {
+		if !slices.Contains(Events, event) {
+			return models.Webhook{}, "", fmt.Errorf("%w: %s", ErrInvalidEvent, event)
+		}
+	}
+
+	events = slices.Clone(events)
+	slices.Sort(events)
+	events = slices.Compact(events)
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return models.Webhook{}, "", err
+	}
+
+	secret := "whsec_" + base64.RawURLEncoding.EncodeToString(raw)
+
+	webhook := models.Webhook{
+		WebhookId: uuid.NewString(),
+		RoomId:    roomId,
+		OwnerId:   ownerId,
+		URL:       rawURL,
+		Secret:    secret,
+		Events:    strings.Join(events, " "),
+	}
+
+	if err := s.repo.Create(&webhook); err != nil {
+		return models.Webhook{}, "", err
+	}
+
+	audit.Log("webhook.created", map[string]interface{}{
+		"webhook_id": webhook.WebhookId,
+		"room_id":    roomId,
+		"user_id":    ownerId,
+		"url":        rawURL,
+	})
+
+	return webhook, secret, nil
+}
+
+func (s *WebhookService) List(roomId string) ([]models.Webhook, error) {
+	return s.repo.List(roomId)
+}
+
+func (s *WebhookService) Find(webhookId string) (models.Webhook, error) {
+	return s.repo.FindByWebhookId(webhookId)
+}
+
+func (s *WebhookService) Delete(webhookId, userId string) error {
+	if err := s.repo.Delete(webhookId); err != nil {
+		return err
+	}
+
+	audit.Log("webhook.deleted", map[string]interface{}{"webhook_id": webhookId, "user_id": userId})
+
+	return nil
+}
+
+// Enable reativa um webhook desativado por falhas e zera o contador.
+func (s *WebhookService) Enable(webhookId string) error {
+	return s.repo.Enable(webhookId)
+}
+
+func (s *WebhookService) Deliveries(webhookId string) ([]models.WebhookDelivery, error) {
+	return s.repo.ListDeliveries(webhookId, deliveryList)
+}
+
+// Publish enfileira o evento para os webhooks ativos que o assinam: os
+// globais e, se roomId não for vazio, os da sala. Não bloqueia quem chama.
+func (s *WebhookService) Publish(event, roomId string, data interface{}) {
+	createdAt := time.Now()
+
+	go func() {
+		webhooks, err := s.repo.ListActive(roomId)
+		if err != nil {
+			log.Printf("Erro ao buscar webhooks do evento %s: %v", event, err)
+			return
+		}
+
+		queued := 0
+		for _, webhook := range webhooks {
+			if !slices.Contains(EventList(webhook), event) {
+				continue
+			}
+
+			deliveryId := uuid.NewString()
+
+			payload, err := json.Marshal(Payload{
+				Id:        deliveryId,
+				Event:     event,
+				RoomId:    roomId,
+				CreatedAt: createdAt,
+				Data:      data,
+			})
+			if err != nil {
+				log.Printf("Erro ao serializar o evento %s: %v", event, err)
+				return
+			}
+
+			err = s.repo.CreateDelivery(&models.WebhookDelivery{
+				DeliveryId:    deliveryId,
+				WebhookId:     webhook.WebhookId,
+				Event:         event,
+				Payload:       string(payload),
+				Status:        webhookRepository.StatusPending,
+				NextAttemptAt: createdAt,
+			})
+			if err != nil {
+				log.Printf("Erro ao enfileirar entrega para o webhook %s: %v", webhook.WebhookId, err)
+				continue
+			}
+
+			queued++
+		}
+
+		if queued > 0 {
+			s.nudge()
+		}
+	}()
+}
+
+func (s *WebhookService) nudge() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start inicia o worker que faz as entregas pendentes.
+func (s *WebhookService) Start() {
+	go func() {
+		ticker := time.NewTicker(s.cfg.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			s.deliverDue()
+
+			select {
+			case <-ticker.C:
+			case <-s.wake:
+			}
+		}
+	}()
+}
+
+func (s *WebhookService) deliverDue() {
+	now := time.Now()
+
+	// A reserva dura mais que o timeout da requisição; se o processo cair no
+	// meio, a entrega volta para a fila depois disso.
+	claimed, err := s.repo.ClaimDue(now, now.Add(s.cfg.Timeout+time.Minute), claimBatch)
+	if err != nil {
+		log.Printf("Erro ao buscar entregas de webhook: %v", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range claimed {
+		wg.Add(1)
+
+		go func(delivery models.WebhookDelivery) {
+			defer wg.Done()
+			s.attempt(delivery)
+		}(delivery)
+	}
+
+	wg.Wait()
+
+	// Com o lote cheio pode haver mais entregas vencidas.
+	if len(claimed) == claimBatch {
+		s.nudge()
+	}
+}
+
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	wait := s.cfg.BackoffBase
+	for i := 1; i < attempts && wait < s.cfg.BackoffMax; i++ {
+		wait *= 2
+	}
+
+	return min(wait, s.cfg.BackoffMax)
+}
+
+func (s *WebhookService) attempt(delivery models.WebhookDelivery) {
+	webhook, err := s.repo.FindByWebhookId(delivery.WebhookId)
+
+	switch {
+	case errors.Is(err, webhookRepository.ErrWebhookNotFound):
+		delivery.Status = webhookRepository.StatusFailed
+		delivery.LastError = "webhook removido"
+	case err != nil:
+		log.Printf("Erro ao buscar webhook %s: %v", delivery.WebhookId, err)
+		return
+	case webhook.DisabledAt != nil:
+		delivery.Status = webhookRepository.StatusFailed
+		delivery.LastError = "webhook desativado"
+	default:
+		s.send(webhook, &delivery)
+	}
+
+	// Fora da fila, next_attempt_at não guarda a reserva do worker.
+	if delivery.Status != webhookRepository.StatusPending {
+		delivery.NextAttemptAt = time.Now()
+	}
+
+	if err := s.repo.SaveDelivery(&delivery); err != nil {
+		log.Printf("Erro ao salvar entrega %s: %v", delivery.DeliveryId, err)
+	}
+}
+
+func (s *WebhookService) send(webhook models.Webhook, delivery *models.WebhookDelivery) {
+	delivery.Attempts++
+
+	status, err := s.post(webhook, delivery)
+	now := time.Now()
+
+	delivery.LastStatus = status
+	delivery.LastError = ""
+
+	if err == nil {
+		delivery.Status = webhookRepository.StatusSucceeded
+		delivery.DeliveredAt = &now
+
+		if webhook.Failures > 0 {
+			if err := s.repo.ResetFailures(webhook.WebhookId); err != nil {
+				log.Printf("Erro ao zerar falhas do webhook %s: %v", webhook.WebhookId, err)
+			}
+		}
+
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if len(delivery.LastError) > 255 {
+		delivery.LastError = delivery.LastError[:255]
+	}
+
+	if delivery.Attempts < s.cfg.MaxAttempts {
+		delivery.NextAttemptAt = now.Add(s.backoff(delivery.Attempts))
+		return
+	}
+
+	delivery.Status = webhookRepository.StatusFailed
+
+	failures, err := s.repo.RecordFailure(webhook.WebhookId)
+	if err != nil {
+		log.Printf("Erro ao registrar falha do webhook %s: %v", webhook.WebhookId, err)
+		return
+	}
+
+	if failures < s.cfg.DisableAfter {
+		return
+	}
+
+	// Entregas paralelas podem passar do limite juntas; só uma desativa.
+	disabled, err := s.repo.Disable(webhook.WebhookId, now)
+	if err != nil {
+		log.Printf("Erro ao desativar webhook %s: %v", webhook.WebhookId, err)
+		return
+	}
+
+	if !disabled {
+		return
+	}
+
+	audit.Log("webhook.disabled", map[string]interface{}{
+		"webhook_id": webhook.WebhookId,
+		"room_id":    webhook.RoomId,
+		"failures":   failures,
+	})
+}
+
+func (s *WebhookService) post(webhook models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	request, err := http.NewRequest(http.MethodPost, webhook.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "go-web-socket-webhooks")
+	request.Header.Set("X-Webhook-Id", webhook.WebhookId)
+	request.Header.Set("X-Webhook-Event", delivery.Event)
+	request.Header.Set("X-Webhook-Delivery", delivery.DeliveryId)
+	request.Header.Set(TimestampHeader, timestamp)
+	request.Header.Set(SignatureHeader, "sha256="+Sign(webhook.Secret, timestamp, []byte(delivery.Payload)))
+
+	response, err := s.clientFor(webhook).Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("resposta HTTP %d", response.StatusCode)
+	}
+
+	return response.StatusCode, nil
+}
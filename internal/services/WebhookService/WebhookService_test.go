@@ -0,0 +1,271 @@
+package webhookService
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"go-web-socket/internal/models"
+	webhookRepository "go-web-socket/internal/repositories/WebhookRepository"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "webhook-test")
+	if err != nil {
+		panic(err)
+	}
+
+	os.Setenv("AUDIT_LOG_FILE", filepath.Join(dir, "audit.log"))
+
+	code := m.Run()
+
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestInternalIP(t *testing.T) {
+	tests := []struct {
+		ip       string
+		internal bool
+	}{
+		{"8.8.8.8", false},
+		{"2606:4700:4700::1111", false},
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"169.254.169.254", true},
+		{"0.1.2.3", true},
+		{"100.64.0.1", true},
+		{"100.127.255.254", true},
+		{"100.128.0.1", false},
+		{"198.18.0.1", true},
+		{"198.19.255.255", true},
+		{"255.255.255.255", true},
+		{"::1", true},
+		{"fd00::1", true},
+		{"fe80::1", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:169.254.169.254", true},
+		{"::ffff:8.8.8.8", false},
+		{"::127.0.0.1", true},
+		{"64:ff9b::a9fe:a9fe", true}, // NAT64 de 169.254.169.254
+		{"64:ff9b::808:808", false},  // NAT64 de 8.8.8.8
+		{"64:ff9b:1::808:808", true},
+		{"2002:7f00:1::", true},   // 6to4 de 127.0.0.1
+		{"2002:808:808::", false}, // 6to4 de 8.8.8.8
+		{"2001:0::1", true},       // Teredo
+		{"2001:db8::1", true},
+	}
+
+	for _, test := range tests {
+		if got := internalIP(net.ParseIP(test.ip)); got != test.internal {
+			t.Errorf("internalIP(%s) = %v, esperado %v", test.ip, got, test.internal)
+		}
+	}
+}
+
+func TestPublicOnlyRejectsInternalTargets(t *testing.T) {
+	for _, address := range []string{"100.64.0.1:443", "[::ffff:10.0.0.1]:443", "[64:ff9b::7f00:1]:443"} {
+		if err := publicOnly("tcp", address, nil); !errors.Is(err, ErrInternalTarget) {
+			t.Errorf("publicOnly(%s) = %v, esperado ErrInternalTarget", address, err)
+		}
+	}
+
+	if err := publicOnly("tcp", "8.8.8.8:443", nil); err != nil {
+		t.Errorf("publicOnly(8.8.8.8:443) = %v", err)
+	}
+}
+
+func TestSign(t *testing.T) {
+	payload := []byte(`{"event":"message.created"}`)
+
+	mac := hmac.New(sha256.New, []byte("whsec_teste"))
+	mac.Write([]byte("1700000000." + string(payload)))
+	want := hex.EncodeToString(mac.Sum(nil))
+
+	if got := Sign("whsec_teste", "1700000000", payload); got != want {
+		t.Fatalf("esperava %s, veio %s", want, got)
+	}
+
+	if Sign("whsec_teste", "1700000001", payload) == want {
+		t.Fatal("a assinatura deveria depender do timestamp")
+	}
+
+	if Sign("whsec_outro", "1700000000", payload) == want {
+		t.Fatal("a assinatura deveria depender do segredo")
+	}
+}
+
+// newTestService cria um serviço que entrega a um servidor local: só os
+// webhooks globais com AllowInternal aceitam http e loopback.
+func newTestService(t *testing.T, cfg Config, handler http.HandlerFunc) (*WebhookService, *webhookRepository.MemoryWebhookRepository, models.Webhook) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	cfg.Timeout = 5 * time.Second
+	cfg.BackoffBase = time.Second
+	cfg.BackoffMax = time.Minute
+	cfg.AllowHTTP = true
+	cfg.AllowInternal = true
+
+	repo := webhookRepository.NewMemoryWebhookRepository()
+	service := New(cfg, repo)
+
+	webhook, _, err := service.Create("admin", "", server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return service, repo, webhook
+}
+
+func deliver(t *testing.T, service *WebhookService, repo *webhookRepository.MemoryWebhookRepository, webhook models.Webhook) models.WebhookDelivery {
+	t.Helper()
+
+	delivery := models.WebhookDelivery{
+		DeliveryId:    uuid.NewString(),
+		WebhookId:     webhook.WebhookId,
+		Event:         EventMessageCreated,
+		Payload:       `{"event":"message.created"}`,
+		Status:        webhookRepository.StatusPending,
+		NextAttemptAt: time.Now(),
+	}
+
+	if err := repo.CreateDelivery(&delivery); err != nil {
+		t.Fatal(err)
+	}
+
+	service.attempt(delivery)
+
+	deliveries, err := repo.ListDeliveries(webhook.WebhookId, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return deliveries[0]
+}
+
+func TestDeliveryIsSigned(t *testing.T) {
+	var secret atomic.Value
+	var valid atomic.Bool
+
+	service, repo, webhook := newTestService(t, Config{MaxAttempts: 3, DisableAfter: 3}, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		signature := "sha256=" + Sign(secret.Load().(string), r.Header.Get(TimestampHeader), body)
+		valid.Store(hmac.Equal([]byte(signature), []byte(r.Header.Get(SignatureHeader))))
+	})
+
+	stored, err := repo.FindByWebhookId(webhook.WebhookId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret.Store(stored.Secret)
+
+	delivery := deliver(t, service, repo, webhook)
+
+	if delivery.Status != webhookRepository.StatusSucceeded {
+		t.Fatalf("esperava a entrega concluída, veio %s (%s)", delivery.Status, delivery.LastError)
+	}
+
+	if !valid.Load() {
+		t.Fatal("a assinatura recebida não confere com o segredo do webhook")
+	}
+}
+
+func TestWebhookIsDisabledAfterFailures(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+
+	service, repo, webhook := newTestService(t, Config{MaxAttempts: 1, DisableAfter: 2}, func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+
+	// Uma entrega que dá certo zera a sequência de falhas.
+	deliver(t, service, repo, webhook)
+
+	fail.Store(false)
+	if delivery := deliver(t, service, repo, webhook); delivery.Status != webhookRepository.StatusSucceeded {
+		t.Fatalf("esperava a entrega concluída, veio %s", delivery.Status)
+	}
+
+	fail.Store(true)
+	delivery := deliver(t, service, repo, webhook)
+
+	if delivery.Status != webhookRepository.StatusFailed || delivery.LastStatus != http.StatusInternalServerError || !strings.Contains(delivery.LastError, "500") {
+		t.Fatalf("entrega inesperada: %+v", delivery)
+	}
+
+	if stored, _ := repo.FindByWebhookId(webhook.WebhookId); stored.DisabledAt != nil {
+		t.Fatal("o webhook foi desativado antes do limite")
+	}
+
+	deliver(t, service, repo, webhook)
+
+	stored, err := repo.FindByWebhookId(webhook.WebhookId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.DisabledAt == nil {
+		t.Fatalf("esperava o webhook desativado depois de %d falhas seguidas", stored.Failures)
+	}
+
+	// Desativado, o webhook não recebe mais entregas.
+	fail.Store(false)
+	if delivery := deliver(t, service, repo, webhook); delivery.Status != webhookRepository.StatusFailed || delivery.LastError != "webhook desativado" {
+		t.Fatalf("entrega inesperada para webhook desativado: %+v", delivery)
+	}
+
+	if err := service.Enable(webhook.WebhookId); err != nil {
+		t.Fatal(err)
+	}
+
+	if delivery := deliver(t, service, repo, webhook); delivery.Status != webhookRepository.StatusSucceeded {
+		t.Fatalf("esperava a entrega concluída depois de reativar, veio %s", delivery.Status)
+	}
+}
+
+func TestFailedAttemptIsRetriedWithBackoff(t *testing.T) {
+	service, repo, webhook := newTestService(t, Config{MaxAttempts: 3, DisableAfter: 1}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	before := time.Now()
+	delivery := deliver(t, service, repo, webhook)
+
+	if delivery.Status != webhookRepository.StatusPending || delivery.Attempts != 1 {
+		t.Fatalf("esperava a entrega pendente com 1 tentativa, veio %s com %d", delivery.Status, delivery.Attempts)
+	}
+
+	if delivery.NextAttemptAt.Before(before.Add(time.Second)) {
+		t.Fatalf("a nova tentativa deveria esperar o backoff, veio %v", delivery.NextAttemptAt.Sub(before))
+	}
+
+	if stored, _ := repo.FindByWebhookId(webhook.WebhookId); stored.Failures != 0 || stored.DisabledAt != nil {
+		t.Fatal("uma tentativa com outras pela frente não conta como falha do webhook")
+	}
+
+	if got := service.backoff(3); got != 4*time.Second {
+		t.Fatalf("backoff(3): esperava 4s, veio %v", got)
+	}
+
+	if got := service.backoff(20); got != time.Minute {
+		t.Fatalf("backoff(20): esperava o máximo de 1m, veio %v", got)
+	}
+}
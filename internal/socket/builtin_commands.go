@@ -0,0 +1,204 @@
+package socket
+
+import (
+	"errors"
+	"fmt"
+	"go-web-socket/internal/models"
+	roomRepository "go-web-socket/internal/repositories/RoomRepository"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// defaultMuteDuration é usado quando o /mute não informa a duração.
+const defaultMuteDuration = time.Hour
+
+func builtinCommands() []Command {
+	return []Command{
+		{Name: "help", Description: "Lista os comandos disponíveis aqui", Handler: helpCommand},
+		{Name: "me", Usage: "/me <ação>", Description: "Envia uma ação em terceira pessoa", Handler: meCommand},
+		{Name: "mute", Usage: "/mute @usuário [duração]", Description: "Impede um membro de falar na sala (padrão 1h)", RoomOnly: true, Handler: muteCommand},
+		{Name: "unmute", Usage: "/unmute @usuário", Description: "Devolve a voz a um membro silenciado", RoomOnly: true, Handler: unmuteCommand},
+		{Name: "invite", Usage: "/invite @usuário", Description: "Adiciona um usuário à sala", RoomOnly: true, Handler: inviteCommand},
+		{Name: "topic", Usage: "/topic [novo tópico]", Description: "Mostra ou altera o tópico da sala", RoomOnly: true, Handler: topicCommand},
+	}
+}
+
+func helpCommand(h *Hub, call *CommandCall) error {
+	roomId := ""
+	if call.Room != nil {
+		roomId = call.Room.RoomId
+	}
+
+	var lines []string
+	for _, cmd := range h.commands.List(roomId) {
+		lines = append(lines, fmt.Sprintf("%s: %s", cmd.Usage, cmd.Description))
+	}
+
+	return call.Reply(strings.Join(lines, "\n"))
+}
+
+func meCommand(h *Hub, call *CommandCall) error {
+	if call.Args == "" {
+		return call.usage()
+	}
+
+	msg := Message{
+		Type:      call.Message.Type,
+		To:        call.Message.To,
+		From:      call.UserId,
+		Status:    "action",
+		Message:   call.Args,
+		Timestamp: time.Now(),
+	}
+
+	return h.dispatch(&msg, false)
+}
+
+func requireOwner(call *CommandCall) error {
+	if call.Room.OwnerId != call.UserId {
+		return fmt.Errorf("só o dono da sala pode usar /%s", call.Command.Name)
+	}
+
+	return nil
+}
+
+// findUser aceita "@username" ou "username".
+func findUser(h *Hub, arg string) (models.User, error) {
+	username := strings.TrimPrefix(arg, "@")
+
+	user, err := h.users.FindByUsername(username)
+	if errors.Is(err, userRepository.ErrUserNotFound) {
+		return user, fmt.Errorf("usuário %s não encontrado", username)
+	}
+
+	return user, err
+}
+
+func muteCommand(h *Hub, call *CommandCall) error {
+	if err := requireOwner(call); err != nil {
+		return err
+	}
+
+	args := strings.Fields(call.Args)
+	if len(args) == 0 || len(args) > 2 {
+		return call.usage()
+	}
+
+	duration := defaultMuteDuration
+	if len(args) == 2 {
+		parsed, err := time.ParseDuration(args[1])
+		if err != nil || parsed <= 0 {
+			return call.usage()
+		}
+
+		duration = parsed
+	}
+
+	user, err := findUser(h, args[0])
+	if err != nil {
+		return err
+	}
+
+	if user.UserId == call.Room.OwnerId {
+		return errors.New("o dono da sala não pode ser silenciado")
+	}
+
+	until := time.Now().Add(duration)
+
+	err = h.rooms.SetMutedUntil(call.Room.RoomId, user.UserId, &until)
+	if errors.Is(err, roomRepository.ErrNotMember) {
+		return fmt.Errorf("%s não é membro da sala", user.Username)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	h.announceRoom(call.Room.RoomId, call.UserId, "member-muted", user.UserId)
+
+	return call.Reply(fmt.Sprintf("%s silenciado até %s", user.Username, until.Format(time.RFC3339)))
+}
+
+func unmuteCommand(h *Hub, call *CommandCall) error {
+	if err := requireOwner(call); err != nil {
+		return err
+	}
+
+	if call.Args == "" || strings.ContainsAny(call.Args, " \t") {
+		return call.usage()
+	}
+
+	user, err := findUser(h, call.Args)
+	if err != nil {
+		return err
+	}
+
+	err = h.rooms.SetMutedUntil(call.Room.RoomId, user.UserId, nil)
+	if errors.Is(err, roomRepository.ErrNotMember) {
+		return fmt.Errorf("%s não é membro da sala", user.Username)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	h.announceRoom(call.Room.RoomId, call.UserId, "member-unmuted", user.UserId)
+
+	return call.Reply(user.Username + " pode falar de novo")
+}
+
+func inviteCommand(h *Hub, call *CommandCall) error {
+	if err := requireOwner(call); err != nil {
+		return err
+	}
+
+	if call.Args == "" || strings.ContainsAny(call.Args, " \t") {
+		return call.usage()
+	}
+
+	user, err := findUser(h, call.Args)
+	if err != nil {
+		return err
+	}
+
+	err = h.rooms.AddMember(call.Room.RoomId, user.UserId)
+	if errors.Is(err, roomRepository.ErrAlreadyMember) {
+		return fmt.Errorf("%s já é membro da sala", user.Username)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	h.announceRoom(call.Room.RoomId, call.UserId, "member-added", user.UserId)
+
+	return call.Reply(user.Username + " foi adicionado à sala")
+}
+
+func topicCommand(h *Hub, call *CommandCall) error {
+	if call.Args == "" {
+		if call.Room.Topic == "" {
+			return call.Reply("A sala não tem tópico")
+		}
+
+		return call.Reply("Tópico: " + call.Room.Topic)
+	}
+
+	if err := requireOwner(call); err != nil {
+		return err
+	}
+
+	if utf8.RuneCountInString(call.Args) > 255 {
+		return errors.New("o tópico pode ter até 255 caracteres")
+	}
+
+	if err := h.rooms.SetTopic(call.Room.RoomId, call.Args); err != nil {
+		return err
+	}
+
+	h.announceRoom(call.Room.RoomId, call.UserId, "topic-changed", call.Args)
+
+	return nil
+}
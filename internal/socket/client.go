@@ -0,0 +1,181 @@
+package socket
+
+import (
+	brokerService "go-web-socket/internal/services/BrokerService"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// 📌 Uma conexão WebSocket de um dispositivo (sessão) de um usuário
+type client struct {
+	conn      *websocket.Conn
+	userID    string
+	sessionID string
+	username  string
+	bot       bool
+
+	// o gorilla/websocket não aceita escritas concorrentes na mesma conexão
+	writeMu sync.Mutex
+
+	lastActive atomic.Int64 // UnixNano da última mensagem recebida
+
+	followMu  sync.RWMutex
+	following map[string]bool // usuários cuja presença a conexão recebe
+}
+
+func (c *client) follow(userIDs []string) {
+	following := make(map[string]bool, len(userIDs))
+	for _, userID := range userIDs {
+		following[userID] = true
+	}
+
+	c.followMu.Lock()
+	c.following = following
+	c.followMu.Unlock()
+}
+
+func (c *client) follows(userID string) bool {
+	c.followMu.RLock()
+	defer c.followMu.RUnlock()
+
+	return c.following[userID]
+}
+
+func (c *client) write(message []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	return c.conn.WriteMessage(websocket.TextMessage, message)
+}
+
+func (c *client) close(code int, reason string) {
+	c.writeMu.Lock()
+	c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+	c.writeMu.Unlock()
+
+	c.conn.Close()
+}
+
+// 📌 Registra a conexão; retorna true se for a primeira do usuário
+func (h *Hub) addClient(c *client) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	first := len(h.clients[c.userID]) == 0
+	if first {
+		h.clients[c.userID] = make(map[*client]struct{})
+	}
+
+	h.clients[c.userID][c] = struct{}{}
+
+	return first
+}
+
+// 📌 Remove a conexão; retorna true se era a última do usuário
+func (h *Hub) removeClient(c *client) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	conns, ok := h.clients[c.userID]
+	if !ok {
+		return false
+	}
+
+	if _, ok := conns[c]; !ok {
+		return false
+	}
+
+	delete(conns, c)
+	if len(conns) > 0 {
+		return false
+	}
+
+	delete(h.clients, c.userID)
+
+	return true
+}
+
+func (h *Hub) clientsOf(userID string) []*client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var conns []*client
+	for c := range h.clients[userID] {
+		conns = append(conns, c)
+	}
+
+	return conns
+}
+
+func (h *Hub) allClients() []*client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var conns []*client
+	for _, userConns := range h.clients {
+		for c := range userConns {
+			conns = append(conns, c)
+		}
+	}
+
+	return conns
+}
+
+// 📌 Lista os usuários conectados a este nó
+func (h *Hub) localPresence() []brokerService.Presence {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	presence := make([]brokerService.Presence, 0, len(h.clients))
+	for userID, conns := range h.clients {
+		// todas as conexões de um usuário são do mesmo tipo de conta
+		for c := range conns {
+			presence = append(presence, brokerService.Presence{UserId: userID, Bot: c.bot})
+			break
+		}
+	}
+
+	return presence
+}
+
+// 📌 Fecha as conexões abertas por uma sessão (ex.: sessão revogada) em
+// todos os nós. Retorna quantas estavam neste nó.
+func (h *Hub) DisconnectSession(sessionID string) int {
+	h.publish(brokerService.Envelope{Kind: brokerService.KindDisconnectSession, Target: sessionID})
+
+	return h.closeSession(sessionID)
+}
+
+func (h *Hub) closeSession(sessionID string) int {
+	var closed int
+
+	for _, c := range h.allClients() {
+		if c.sessionID == sessionID {
+			c.close(websocket.ClosePolicyViolation, "sessão encerrada")
+			closed++
+		}
+	}
+
+	return closed
+}
+
+// 📌 Fecha todas as conexões de um usuário (ex.: conta desativada) em todos
+// os nós. Retorna quantas estavam neste nó.
+func (h *Hub) DisconnectUser(userID string) int {
+	h.sendToUserNodes(userID, brokerService.Envelope{Kind: brokerService.KindDisconnectUser, Target: userID})
+
+	return h.closeUser(userID)
+}
+
+func (h *Hub) closeUser(userID string) int {
+	conns := h.clientsOf(userID)
+
+	for _, c := range conns {
+		c.close(websocket.ClosePolicyViolation, "desconectado por um administrador")
+	}
+
+	return len(conns)
+}
@@ -0,0 +1,232 @@
+package socket
+
+import (
+	"encoding/json"
+	brokerService "go-web-socket/internal/services/BrokerService"
+	webhookService "go-web-socket/internal/services/WebhookService"
+	"log"
+	"time"
+)
+
+// 📌 Registra a conexão. A primeira conexão do usuário neste nó o registra
+// no broker, e a transição diz se ele ficou online no cluster; se o broker
+// falhar, vale a contagem local e o próximo heartbeat tenta de novo.
+func (h *Hub) join(c *client) brokerService.Transition {
+	if !h.addClient(c) {
+		return brokerService.Transition{}
+	}
+
+	transition, err := h.broker.Claim(c.userID, c.bot)
+	if err != nil {
+		log.Printf("Erro ao registrar presença de %s no broker: %v", c.userID, err)
+		h.markUnsynced(c.userID, c.bot)
+		return brokerService.Transition{After: brokerService.State{Online: true}}
+	}
+
+	return transition
+}
+
+// 📌 Remove a conexão. Os comandos de um bot saem de todos os nós quando ele
+// fica offline no cluster.
+func (h *Hub) leave(c *client) brokerService.Transition {
+	if !h.removeClient(c) {
+		return brokerService.Transition{}
+	}
+
+	h.mu.Lock()
+	delete(h.idle, c.userID)
+	h.mu.Unlock()
+
+	transition, err := h.broker.Release(c.userID)
+	if err != nil {
+		log.Printf("Erro ao remover presença de %s no broker: %v", c.userID, err)
+		h.markUnsynced(c.userID, c.bot)
+		transition = brokerService.Transition{Before: brokerService.State{Online: true}}
+	}
+
+	if c.bot && transition.Disconnected() {
+		h.removeBotCommands(c.userID)
+	}
+
+	return transition
+}
+
+// 📌 Repassa o envelope aos outros nós
+func (h *Hub) publish(envelope brokerService.Envelope) {
+	if err := h.broker.Publish(envelope); err != nil {
+		log.Printf("Erro ao publicar %s no broker: %v", envelope.Kind, err)
+	}
+}
+
+// 📌 Repassa o envelope aos outros nós em que o usuário está conectado.
+// Retorna quantos são; se o registro falhar, publica para todos.
+func (h *Hub) sendToUserNodes(userID string, envelope brokerService.Envelope) (int, error) {
+	nodes, err := h.broker.Nodes(userID)
+	if err != nil {
+		h.publish(envelope)
+		return 0, err
+	}
+
+	var sent int
+	for _, node := range nodes {
+		if node == h.broker.Node() {
+			continue
+		}
+
+		if err := h.broker.Send(node, envelope); err != nil {
+			log.Printf("Erro ao enviar %s ao nó %s: %v", envelope.Kind, node, err)
+			continue
+		}
+
+		sent++
+	}
+
+	return sent, nil
+}
+
+// 📌 Entrega às conexões deste nó o que outro nó publicou
+func (h *Hub) receive(envelope brokerService.Envelope) {
+	switch envelope.Kind {
+	case brokerService.KindPrivate:
+		h.deliverPrivate(h.clientsOf(envelope.Target), envelope.Payload)
+	case brokerService.KindRoom:
+		var msg Message
+		if err := json.Unmarshal(envelope.Payload, &msg); err != nil {
+			log.Printf("Mensagem de sala inválida vinda do nó %s: %v", envelope.Node, err)
+			return
+		}
+
+		if err := h.deliverRoom(msg, envelope.Payload); err != nil {
+			log.Printf("Erro ao entregar mensagem da sala %s: %v", envelope.Target, err)
+		}
+	case brokerService.KindBroadcast:
+		h.deliverBroadcast(envelope.Payload)
+	case brokerService.KindPresence:
+		h.deliverPresence(envelope.Target, envelope.Payload)
+	case brokerService.KindDisconnectSession:
+		h.closeSession(envelope.Target)
+	case brokerService.KindDisconnectUser:
+		h.closeUser(envelope.Target)
+	case brokerService.KindBotCommand:
+		var cmd Command
+		if err := json.Unmarshal(envelope.Payload, &cmd); err != nil {
+			log.Printf("Comando de bot inválido vindo do nó %s: %v", envelope.Node, err)
+			return
+		}
+
+		if err := h.commands.RegisterBot(envelope.Target, cmd); err != nil {
+			log.Printf("Comando /%s do bot %s não registrado na sala %s: %v", cmd.Name, cmd.BotId, envelope.Target, err)
+		}
+	case brokerService.KindBotCommandsGone:
+		h.commands.RemoveBot(envelope.Target)
+	case brokerService.KindBotCommandsSync:
+		h.sendBotCommands(envelope.Node)
+	}
+}
+
+// 📌 Renova a concessão deste nó no registro de presença a cada intervalo
+func (h *Hub) StartHeartbeat(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			h.heartbeat()
+		}
+	}()
+}
+
+func (h *Hub) heartbeat() {
+	result, err := h.broker.Heartbeat()
+	if err != nil {
+		log.Printf("Erro ao renovar a presença do nó %s: %v", h.broker.Node(), err)
+		return
+	}
+
+	// A concessão venceu (ex.: o Redis ficou fora do ar) e outro nó removeu
+	// os usuários daqui: registra de novo quem continua conectado
+	if result.Expired {
+		log.Printf("Concessão do nó %s tinha vencido; registrando os usuários de novo", h.broker.Node())
+
+		for _, presence := range h.localPresence() {
+			h.markUnsynced(presence.UserId, presence.Bot)
+		}
+	}
+
+	for userID, bot := range h.takeUnsynced() {
+		h.resync(userID, bot)
+	}
+
+	// Usuários de nós que caíram
+	for _, presence := range result.Offline {
+		if presence.Bot {
+			h.removeBotCommands(presence.UserId)
+		}
+
+		h.announceTransition(presence.UserId, presence.Bot, brokerService.Transition{Before: brokerService.State{Online: true}})
+		h.webhooks.Publish(webhookService.EventUserDisconnected, "", map[string]interface{}{"user_id": presence.UserId, "bot": presence.Bot})
+	}
+
+	h.sweepPresence()
+}
+
+// 📌 Marca o usuário para o próximo heartbeat acertar o registro dele no
+// broker, depois de um Claim, Release ou SetIdle que falhou
+func (h *Hub) markUnsynced(userID string, bot bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.unsynced[userID] = bot
+}
+
+func (h *Hub) takeUnsynced() map[string]bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	unsynced := h.unsynced
+	h.unsynced = make(map[string]bool)
+
+	return unsynced
+}
+
+// 📌 Deixa o registro do usuário no broker igual ao estado local: registrado
+// (e ocioso, se for o caso) enquanto houver conexões aqui, removido depois
+// da última. Se o broker falhar de novo, tenta no próximo heartbeat.
+func (h *Hub) resync(userID string, bot bool) {
+	h.mu.RLock()
+	_, connected := h.clients[userID]
+	idle := h.idle[userID]
+	h.mu.RUnlock()
+
+	var transition brokerService.Transition
+	var err error
+
+	if connected {
+		transition, err = h.broker.Claim(userID, bot)
+		if err == nil && idle {
+			var idled brokerService.Transition
+			if idled, err = h.broker.SetIdle(userID, true); err == nil {
+				transition.After = idled.After
+			}
+		}
+	} else {
+		transition, err = h.broker.Release(userID)
+	}
+
+	if err != nil {
+		log.Printf("Erro ao acertar a presença de %s no broker: %v", userID, err)
+		h.markUnsynced(userID, bot)
+		return
+	}
+
+	// A última conexão pode ter saído enquanto o registro era refeito
+	h.mu.RLock()
+	_, stillConnected := h.clients[userID]
+	h.mu.RUnlock()
+
+	if connected && !stillConnected {
+		h.markUnsynced(userID, bot)
+	}
+
+	h.announceTransition(userID, bot, transition)
+}
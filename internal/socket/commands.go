@@ -0,0 +1,427 @@
+package socket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-web-socket/internal/middleware"
+	"go-web-socket/internal/models"
+	roomRepository "go-web-socket/internal/repositories/RoomRepository"
+	brokerService "go-web-socket/internal/services/BrokerService"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrCommandExists      = errors.New("já existe um comando com esse nome")
+	ErrInvalidCommandName = errors.New("nome de comando inválido")
+)
+
+var commandNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,31}$`)
+
+// CommandHandler atende um comando. O erro devolvido vira uma resposta
+// efêmera para quem chamou.
+type CommandHandler func(h *Hub, call *CommandCall) error
+
+type Command struct {
+	Name        string         `json:"name"`
+	Usage       string         `json:"usage"`
+	Description string         `json:"description"`
+	RoomOnly    bool           `json:"room_only,omitempty"`
+	BotId       string         `json:"bot_id,omitempty"` // comando atendido por um bot
+	Handler     CommandHandler `json:"-"`
+}
+
+// CommandCall é uma chamada de comando feita por uma conexão.
+type CommandCall struct {
+	Command Command
+	Args    string
+	UserId  string
+	Message Message      // mensagem original; Type e To dão o contexto
+	Room    *models.Room // sala onde o comando foi usado, nil fora de salas
+
+	client *client
+}
+
+// Reply responde só para a conexão que chamou o comando.
+func (call *CommandCall) Reply(text string) error {
+	msg := Message{
+		Type:      "ephemeral",
+		To:        call.UserId,
+		Status:    call.Command.Name,
+		Message:   text,
+		Timestamp: time.Now(),
+	}
+
+	msgBytes, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return call.client.write(msgBytes)
+}
+
+func (call *CommandCall) usage() error {
+	return fmt.Errorf("uso: %s", call.Command.Usage)
+}
+
+// 📌 CommandRegistry guarda os comandos nativos e os registrados por bots em
+// cada sala. Cada nó tem o seu; os comandos de bots são repassados pelo
+// broker aos outros nós, que os atendem encaminhando a chamada ao bot.
+type CommandRegistry struct {
+	mu       sync.RWMutex
+	builtins map[string]Command
+	rooms    map[string]map[string]Command
+}
+
+func NewCommandRegistry() *CommandRegistry {
+	return &CommandRegistry{
+		builtins: make(map[string]Command),
+		rooms:    make(map[string]map[string]Command),
+	}
+}
+
+// Register adiciona um comando atendido por um handler em Go, disponível em
+// todas as salas.
+func (r *CommandRegistry) Register(cmd Command) error {
+	if !commandNamePattern.MatchString(cmd.Name) || cmd.Handler == nil {
+		return ErrInvalidCommandName
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.builtins[cmd.Name]; exists {
+		return ErrCommandExists
+	}
+
+	if cmd.Usage == "" {
+		cmd.Usage = "/" + cmd.Name
+	}
+
+	r.builtins[cmd.Name] = cmd
+
+	return nil
+}
+
+// RegisterBot adiciona um comando que é repassado ao bot, só na sala
+// informada. O mesmo bot pode registrar de novo para atualizar a descrição.
+func (r *CommandRegistry) RegisterBot(roomId string, cmd Command) error {
+	if !commandNamePattern.MatchString(cmd.Name) || cmd.BotId == "" {
+		return ErrInvalidCommandName
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.builtins[cmd.Name]; exists {
+		return ErrCommandExists
+	}
+
+	if existing, exists := r.rooms[roomId][cmd.Name]; exists && existing.BotId != cmd.BotId {
+		return ErrCommandExists
+	}
+
+	if r.rooms[roomId] == nil {
+		r.rooms[roomId] = make(map[string]Command)
+	}
+
+	cmd.Usage = "/" + cmd.Name + " [texto]"
+	cmd.RoomOnly = true
+	r.rooms[roomId][cmd.Name] = cmd
+
+	return nil
+}
+
+// RemoveBot tira os comandos do bot de todas as salas.
+func (r *CommandRegistry) RemoveBot(botId string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for roomId, commands := range r.rooms {
+		for name, cmd := range commands {
+			if cmd.BotId == botId {
+				delete(commands, name)
+			}
+		}
+
+		if len(commands) == 0 {
+			delete(r.rooms, roomId)
+		}
+	}
+}
+
+// BotCommands devolve os comandos do bot, por sala.
+func (r *CommandRegistry) BotCommands(botId string) map[string][]Command {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	commands := make(map[string][]Command)
+	for roomId, roomCommands := range r.rooms {
+		for _, cmd := range roomCommands {
+			if cmd.BotId == botId {
+				commands[roomId] = append(commands[roomId], cmd)
+			}
+		}
+	}
+
+	return commands
+}
+
+func (r *CommandRegistry) Lookup(roomId, name string) (Command, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if cmd, ok := r.builtins[name]; ok {
+		return cmd, true
+	}
+
+	cmd, ok := r.rooms[roomId][name]
+
+	return cmd, ok
+}
+
+// List devolve os comandos disponíveis na sala, em ordem alfabética. Com
+// roomId vazio, só os que funcionam fora de salas.
+func (r *CommandRegistry) List(roomId string) []Command {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	commands := []Command{}
+	for _, cmd := range r.builtins {
+		if roomId == "" && cmd.RoomOnly {
+			continue
+		}
+
+		commands = append(commands, cmd)
+	}
+
+	for _, cmd := range r.rooms[roomId] {
+		commands = append(commands, cmd)
+	}
+
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+
+	return commands
+}
+
+// 📌 Registra um comando em Go no hub
+func (h *Hub) RegisterCommand(cmd Command) error {
+	return h.commands.Register(cmd)
+}
+
+// isCommand diz se o texto é um comando. "//texto" é enviado como "/texto".
+func isCommand(text string) bool {
+	return strings.HasPrefix(text, "/") && !strings.HasPrefix(text, "//")
+}
+
+func parseCommand(text string) (name, args string) {
+	name, args, _ = strings.Cut(strings.TrimPrefix(text, "/"), " ")
+
+	return strings.ToLower(name), strings.TrimSpace(args)
+}
+
+// 📌 Executa o comando enviado por um usuário e responde a ele com o erro,
+// se houver
+func (h *Hub) runCommand(c *client, msg Message) {
+	name, args := parseCommand(msg.Message)
+
+	call := &CommandCall{
+		Command: Command{Name: name},
+		Args:    args,
+		UserId:  c.userID,
+		Message: msg,
+		client:  c,
+	}
+
+	if err := h.callCommand(call); err != nil {
+		call.Reply("Erro: " + err.Error())
+	}
+}
+
+func (h *Hub) callCommand(call *CommandCall) error {
+	roomId := ""
+
+	if call.Message.Type == "room" {
+		room, err := h.rooms.FindByRoomId(call.Message.To)
+		if errors.Is(err, roomRepository.ErrRoomNotFound) {
+			return ErrNotRoomMember
+		}
+
+		if err != nil {
+			return err
+		}
+
+		member, err := h.rooms.IsMember(room.RoomId, call.UserId)
+		if err != nil {
+			return err
+		}
+
+		if !member {
+			return ErrNotRoomMember
+		}
+
+		call.Room = &room
+		roomId = room.RoomId
+	}
+
+	cmd, ok := h.commands.Lookup(roomId, call.Command.Name)
+	if !ok {
+		return fmt.Errorf("comando desconhecido /%s, veja /help", call.Command.Name)
+	}
+
+	call.Command = cmd
+
+	if cmd.RoomOnly && call.Room == nil {
+		return fmt.Errorf("/%s só pode ser usado em uma sala", cmd.Name)
+	}
+
+	if cmd.BotId != "" {
+		return h.forwardCommand(call)
+	}
+
+	return cmd.Handler(h, call)
+}
+
+// 📌 Repassa o comando ao bot que o registrou; o bot responde com uma
+// mensagem "ephemeral" para quem chamou ou com uma mensagem na sala
+func (h *Hub) forwardCommand(call *CommandCall) error {
+	msg := Message{
+		Type:      "command",
+		To:        call.Command.BotId,
+		From:      call.UserId,
+		Room:      call.Room.RoomId,
+		Status:    call.Command.Name,
+		Message:   call.Args,
+		Timestamp: time.Now(),
+	}
+
+	msgBytes, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	if err := h.sendPrivateMessage(call.Command.BotId, msgBytes); errors.Is(err, ErrUserOffline) {
+		return errors.New("o bot deste comando não está conectado")
+	}
+
+	return nil
+}
+
+// 📌 Registra um comando de bot na sala: {"type": "register-command", "to":
+// sala, "message": nome, "data": descrição}
+func (h *Hub) registerBotCommand(c *client, msg Message) error {
+	member, err := h.rooms.IsMember(msg.To, c.userID)
+	if err != nil {
+		return err
+	}
+
+	if !member {
+		return ErrNotRoomMember
+	}
+
+	cmd := Command{
+		Name:        strings.ToLower(strings.TrimPrefix(msg.Message, "/")),
+		Description: msg.Status,
+		BotId:       c.userID,
+	}
+
+	if err := h.commands.RegisterBot(msg.To, cmd); err != nil {
+		return err
+	}
+
+	log.Printf("Bot %s registrou /%s na sala %s", c.userID, cmd.Name, msg.To)
+
+	h.shareBotCommand("", msg.To, cmd)
+
+	return nil
+}
+
+// 📌 Repassa o comando de bot a um nó, ou a todos com node vazio
+func (h *Hub) shareBotCommand(node, roomId string, cmd Command) {
+	payload, err := json.Marshal(cmd)
+	if err != nil {
+		log.Printf("Erro ao serializar o comando /%s: %v", cmd.Name, err)
+		return
+	}
+
+	envelope := brokerService.Envelope{Kind: brokerService.KindBotCommand, Target: roomId, Payload: payload}
+
+	if node == "" {
+		h.publish(envelope)
+		return
+	}
+
+	if err := h.broker.Send(node, envelope); err != nil {
+		log.Printf("Erro ao enviar o comando /%s ao nó %s: %v", cmd.Name, node, err)
+	}
+}
+
+// 📌 Envia a um nó que acabou de subir os comandos dos bots conectados aqui
+func (h *Hub) sendBotCommands(node string) {
+	for _, presence := range h.localPresence() {
+		if !presence.Bot {
+			continue
+		}
+
+		for roomId, commands := range h.commands.BotCommands(presence.UserId) {
+			for _, cmd := range commands {
+				h.shareBotCommand(node, roomId, cmd)
+			}
+		}
+	}
+}
+
+// 📌 Tira os comandos do bot deste nó e dos outros
+func (h *Hub) removeBotCommands(botId string) {
+	h.commands.RemoveBot(botId)
+	h.publish(brokerService.Envelope{Kind: brokerService.KindBotCommandsGone, Target: botId})
+}
+
+// 📌 Avisa os membros da sala sobre uma mudança feita por um comando
+func (h *Hub) announceRoom(roomId, from, status, text string) {
+	msg := Message{
+		Type:      "room",
+		To:        roomId,
+		From:      from,
+		Status:    status,
+		Message:   text,
+		Timestamp: time.Now(),
+	}
+
+	msgBytes, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("Erro ao serializar aviso da sala: %v", err)
+		return
+	}
+
+	if err := h.sendRoomMessage(msg, msgBytes); err != nil {
+		log.Printf("Erro ao avisar a sala %s: %v", roomId, err)
+	}
+}
+
+// 📌 Lista os comandos disponíveis numa sala (requer o middleware de autenticação)
+func (h *Hub) GetRoomCommands(ctx *gin.Context) {
+	roomId := ctx.Param("room_id")
+
+	member, err := h.rooms.IsMember(roomId, middleware.CurrentUser(ctx).UserId)
+	if err != nil {
+		log.Printf("Erro ao buscar sala %s: %v", roomId, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Houve um erro ao buscar a sala"})
+		return
+	}
+
+	if !member {
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "Sala não encontrada"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": h.commands.List(roomId)})
+}
@@ -0,0 +1,221 @@
+package socket
+
+import (
+	"encoding/json"
+	"errors"
+	"go-web-socket/internal/models"
+	roomRepository "go-web-socket/internal/repositories/RoomRepository"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func noop(h *Hub, call *CommandCall) error { return nil }
+
+func TestRegisterRejectsInvalidAndDuplicateNames(t *testing.T) {
+	registry := NewCommandRegistry()
+
+	for _, name := range []string{"", "Help", "1x", "com espaço", strings.Repeat("a", 33)} {
+		if err := registry.Register(Command{Name: name, Handler: noop}); !errors.Is(err, ErrInvalidCommandName) {
+			t.Errorf("%q: esperava ErrInvalidCommandName, veio %v", name, err)
+		}
+	}
+
+	if err := registry.Register(Command{Name: "ping"}); !errors.Is(err, ErrInvalidCommandName) {
+		t.Fatalf("comando sem handler: esperava ErrInvalidCommandName, veio %v", err)
+	}
+
+	if err := registry.Register(Command{Name: "ping", Handler: noop}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := registry.Register(Command{Name: "ping", Handler: noop}); !errors.Is(err, ErrCommandExists) {
+		t.Fatalf("esperava ErrCommandExists, veio %v", err)
+	}
+}
+
+func TestBotCommandsStayInTheirRoom(t *testing.T) {
+	registry := NewCommandRegistry()
+
+	if err := registry.Register(Command{Name: "help", Handler: noop}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := registry.RegisterBot("r1", Command{Name: "help", BotId: "bot1"}); !errors.Is(err, ErrCommandExists) {
+		t.Fatalf("bot não pode sobrescrever um comando nativo, veio %v", err)
+	}
+
+	if err := registry.RegisterBot("r1", Command{Name: "deploy", BotId: "bot1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := registry.RegisterBot("r1", Command{Name: "deploy", BotId: "bot2"}); !errors.Is(err, ErrCommandExists) {
+		t.Fatalf("outro bot não pode tomar o comando, veio %v", err)
+	}
+
+	if err := registry.RegisterBot("r1", Command{Name: "deploy", Description: "nova", BotId: "bot1"}); err != nil {
+		t.Fatalf("o mesmo bot pode registrar de novo, veio %v", err)
+	}
+
+	if err := registry.RegisterBot("r2", Command{Name: "deploy", BotId: "bot2"}); err != nil {
+		t.Fatalf("o nome é livre em outra sala, veio %v", err)
+	}
+
+	cmd, ok := registry.Lookup("r1", "deploy")
+	if !ok || cmd.BotId != "bot1" || cmd.Description != "nova" || !cmd.RoomOnly {
+		t.Fatalf("comando inesperado na sala r1: %+v", cmd)
+	}
+
+	if _, ok := registry.Lookup("r3", "deploy"); ok {
+		t.Fatal("o comando não deveria existir na sala r3")
+	}
+
+	registry.RemoveBot("bot1")
+
+	if _, ok := registry.Lookup("r1", "deploy"); ok {
+		t.Fatal("o comando do bot removido continuou na sala")
+	}
+
+	if _, ok := registry.Lookup("r2", "deploy"); !ok {
+		t.Fatal("o comando do outro bot sumiu")
+	}
+}
+
+func TestListHidesRoomOnlyCommandsOutsideRooms(t *testing.T) {
+	registry := NewCommandRegistry()
+
+	for _, cmd := range builtinCommands() {
+		if err := registry.Register(cmd); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := registry.RegisterBot("r1", Command{Name: "deploy", BotId: "bot1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	names := func(commands []Command) string {
+		var list []string
+		for _, cmd := range commands {
+			list = append(list, cmd.Name)
+		}
+
+		return strings.Join(list, " ")
+	}
+
+	if got := names(registry.List("")); got != "help me" {
+		t.Fatalf("fora de salas: esperava \"help me\", veio %q", got)
+	}
+
+	if got := names(registry.List("r1")); got != "deploy help invite me mute topic unmute" {
+		t.Fatalf("na sala: veio %q", got)
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	if isCommand("//shrug") || isCommand("oi /help") || !isCommand("/help") {
+		t.Fatal("isCommand não reconheceu os comandos corretamente")
+	}
+
+	name, args := parseCommand("/MUTE  @bob 10m ")
+	if name != "mute" || args != "@bob 10m" {
+		t.Fatalf("esperava mute e \"@bob 10m\", veio %q e %q", name, args)
+	}
+}
+
+func newCommandHub(t *testing.T) *Hub {
+	t.Helper()
+
+	rooms := roomRepository.NewMemoryRoomRepository()
+	if err := rooms.Create(&models.Room{RoomId: "r1", Name: "geral", OwnerId: "u1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	h := &Hub{rooms: rooms, commands: NewCommandRegistry()}
+	for _, cmd := range builtinCommands() {
+		if err := h.commands.Register(cmd); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return h
+}
+
+func TestCallCommandRules(t *testing.T) {
+	h := newCommandHub(t)
+
+	tests := []struct {
+		name   string
+		userId string
+		msg    Message
+		want   string
+	}{
+		{"comando desconhecido", "u1", Message{Type: "private", To: "u2", Message: "/nada"}, "comando desconhecido /nada"},
+		{"comando de sala fora de sala", "u1", Message{Type: "private", To: "u2", Message: "/topic"}, "/topic só pode ser usado em uma sala"},
+		{"quem não é membro", "u2", Message{Type: "room", To: "r1", Message: "/topic"}, ErrNotRoomMember.Error()},
+		{"sala inexistente", "u1", Message{Type: "room", To: "r9", Message: "/help"}, ErrNotRoomMember.Error()},
+	}
+
+	for _, test := range tests {
+		name, args := parseCommand(test.msg.Message)
+		call := &CommandCall{Command: Command{Name: name}, Args: args, UserId: test.userId, Message: test.msg}
+
+		err := h.callCommand(call)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: esperava %q, veio %v", test.name, test.want, err)
+		}
+	}
+}
+
+// dial abre uma conexão de teste e devolve o client do servidor e a ponta
+// de quem chamou.
+func dial(t *testing.T, userId string) (*client, *websocket.Conn) {
+	t.Helper()
+
+	server := make(chan *websocket.Conn, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		server <- conn
+	}))
+	t.Cleanup(srv.Close)
+
+	peer, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { peer.Close() })
+
+	return &client{conn: <-server, userID: userId}, peer
+}
+
+func TestCommandErrorsAreEphemeral(t *testing.T) {
+	h := newCommandHub(t)
+	c, peer := dial(t, "u1")
+
+	h.runCommand(c, Message{Type: "room", To: "r1", Message: "/me"})
+
+	peer.SetReadDeadline(time.Now().Add(time.Second))
+
+	_, data, err := peer.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reply Message
+	if err := json.Unmarshal(data, &reply); err != nil {
+		t.Fatal(err)
+	}
+
+	if reply.Type != "ephemeral" || reply.To != "u1" || reply.Status != "me" || reply.Message != "Erro: uso: /me <ação>" {
+		t.Fatalf("resposta inesperada: %+v", reply)
+	}
+}
@@ -0,0 +1,19 @@
+package socket
+
+import (
+	"regexp"
+	"strings"
+)
+
+var mentionPattern = regexp.MustCompile(`@([\p{L}\p{N}_.-]+)`)
+
+// 📌 Diz se o texto menciona @username (sem diferenciar maiúsculas)
+func mentions(text, username string) bool {
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		if strings.EqualFold(strings.TrimRight(match[1], ".-"), username) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,277 @@
+package socket
+
+import (
+	"encoding/json"
+	"go-web-socket/internal/models"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	brokerService "go-web-socket/internal/services/BrokerService"
+	presenceService "go-web-socket/internal/services/PresenceService"
+	"log"
+	"slices"
+	"time"
+)
+
+// 📌 Anuncia a presença do usuário depois de uma transição no registro
+// (conectou, desconectou, ficou ocioso ou voltou)
+func (h *Hub) announceTransition(userID string, bot bool, transition brokerService.Transition) {
+	if transition.Before == transition.After {
+		return
+	}
+
+	user, err := h.users.FindByUserId(userID)
+	if err != nil {
+		log.Printf("Presença de %s não anunciada: %v", userID, err)
+		return
+	}
+
+	now := time.Now()
+	before := presenceService.Resolve(user, transition.Before.Online, transition.Before.Idle, now)
+	after := presenceService.Resolve(user, transition.After.Online, transition.After.Idle, now)
+
+	h.announcePresence(userID, bot, before, after)
+}
+
+// 📌 Anuncia a troca do status escolhido pelo usuário
+func (h *Hub) StatusChanged(before, after models.User) {
+	states, err := h.broker.States([]string{after.UserId})
+	if err != nil {
+		log.Printf("Presença de %s não anunciada: %v", after.UserId, err)
+		return
+	}
+
+	state := states[after.UserId]
+	now := time.Now()
+
+	h.announcePresence(after.UserId, after.Bot,
+		presenceService.Resolve(before, state.Online, state.Idle, now),
+		presenceService.Resolve(after, state.Online, state.Idle, now))
+}
+
+// 📌 Envia a nova presença a quem acompanha o usuário, em todos os nós. O
+// status é "user-connected" ou "user-disconnected" quando o usuário passa a
+// aparecer ou deixa de aparecer online, e "user-presence" nas outras mudanças.
+func (h *Hub) announcePresence(userID string, bot bool, before, after presenceService.Presence) {
+	if before.Same(after) {
+		return
+	}
+
+	now := time.Now()
+	status := "user-presence"
+
+	switch {
+	case before.Status == presenceService.StatusOffline:
+		status = "user-connected"
+	case after.Status == presenceService.StatusOffline:
+		status = "user-disconnected"
+
+		if err := h.presence.MarkSeen(userID, now); err != nil {
+			log.Printf("Erro ao gravar o último acesso de %s: %v", userID, err)
+		} else {
+			after.LastSeenAt = &now
+		}
+	}
+
+	msg := Message{
+		Type:      "status",
+		From:      userID,
+		Status:    status,
+		Bot:       bot,
+		Presence:  &after,
+		Timestamp: now,
+	}
+
+	msgBytes, err := json.Marshal(msg)
+	if err != nil {
+		log.Println("Erro ao serializar mensagem de status:", err)
+		return
+	}
+
+	h.publish(brokerService.Envelope{Kind: brokerService.KindPresence, Target: userID, Payload: msgBytes})
+	h.deliverPresence(userID, msgBytes)
+}
+
+// 📌 Entrega a mudança de presença às conexões deste nó que acompanham o
+// usuário e às do próprio usuário. Bots não recebem presença.
+func (h *Hub) deliverPresence(userID string, message []byte) {
+	for _, c := range h.allClients() {
+		if !c.bot && (c.userID == userID || c.follows(userID)) {
+			c.write(message)
+		}
+	}
+}
+
+// 📌 Presença atual de cada usuário, como os contatos a veem
+func (h *Hub) Presences(userIDs []string) ([]presenceService.Presence, error) {
+	presences := []presenceService.Presence{}
+	if len(userIDs) == 0 {
+		return presences, nil
+	}
+
+	users, err := h.users.Search(userRepository.UserFilter{UserIds: userIDs})
+	if err != nil {
+		return nil, err
+	}
+
+	states, err := h.broker.States(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, user := range users {
+		state := states[user.UserId]
+		presences = append(presences, presenceService.Resolve(user, state.Online, state.Idle, now))
+	}
+
+	return presences, nil
+}
+
+// 📌 Presença dos usuários que viewerID pode acompanhar: os contatos dele e
+// ele mesmo. Os outros ficam de fora.
+func (h *Hub) ContactPresences(viewerID string, userIDs []string) ([]presenceService.Presence, error) {
+	contacts, err := h.presence.Contacts(viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := slices.DeleteFunc(slices.Clone(userIDs), func(userID string) bool {
+		return userID != viewerID && !slices.Contains(contacts, userID)
+	})
+
+	return h.Presences(visible)
+}
+
+// 📌 Define de quem a conexão recebe a presença: dos usuários informados ou,
+// com users nil, de todos os contatos. Quem não é contato é ignorado. Responde
+// com a presença atual de cada um.
+func (h *Hub) subscribePresence(c *client, users []string) error {
+	contacts, err := h.presence.Contacts(c.userID)
+	if err != nil {
+		return err
+	}
+
+	if users != nil {
+		contacts = slices.DeleteFunc(contacts, func(contact string) bool {
+			return !slices.Contains(users, contact)
+		})
+	}
+
+	c.follow(contacts)
+
+	presences, err := h.Presences(contacts)
+	if err != nil {
+		return err
+	}
+
+	msgBytes, err := json.Marshal(Message{Type: "presence", Presences: presences, Timestamp: time.Now()})
+	if err != nil {
+		return err
+	}
+
+	return c.write(msgBytes)
+}
+
+// 📌 Registra atividade na conexão; o usuário que estava ausente por
+// inatividade neste nó volta a aparecer online
+func (h *Hub) touch(c *client) {
+	c.lastActive.Store(time.Now().UnixNano())
+
+	h.mu.RLock()
+	idle := h.idle[c.userID]
+	h.mu.RUnlock()
+
+	if idle {
+		h.setIdle(c.userID, c.bot, false)
+	}
+}
+
+// 📌 Marca as conexões do usuário neste nó como ociosas ou ativas
+func (h *Hub) setIdle(userID string, bot, idle bool) {
+	h.mu.Lock()
+	if _, connected := h.clients[userID]; !connected || h.idle[userID] == idle {
+		h.mu.Unlock()
+		return
+	}
+
+	h.idle[userID] = idle
+	h.mu.Unlock()
+
+	transition, err := h.broker.SetIdle(userID, idle)
+	if err != nil {
+		log.Printf("Erro ao registrar inatividade de %s no broker: %v", userID, err)
+		h.markUnsynced(userID, bot)
+		return
+	}
+
+	h.announceTransition(userID, bot, transition)
+}
+
+// 📌 Marca como ociosos os usuários deste nó sem atividade em nenhuma conexão
+// há PRESENCE_IDLE_AFTER e volta ao automático os status que venceram
+func (h *Hub) sweepPresence() {
+	now := time.Now()
+	activeAfter := now.Add(-h.presence.IdleAfter()).UnixNano()
+
+	var users []string
+
+	for _, presence := range h.localPresence() {
+		if presence.Bot {
+			continue // bots não ficam ausentes
+		}
+
+		users = append(users, presence.UserId)
+
+		idle := true
+		for _, c := range h.clientsOf(presence.UserId) {
+			if c.lastActive.Load() > activeAfter {
+				idle = false
+				break
+			}
+		}
+
+		if idle {
+			h.setIdle(presence.UserId, false, true)
+		}
+	}
+
+	h.expireStatuses(users, now)
+}
+
+// 📌 Volta ao automático os status escolhidos que venceram. Mais de um nó
+// pode ter o usuário; só o que limpar o status anuncia a mudança.
+func (h *Hub) expireStatuses(userIDs []string, now time.Time) {
+	if len(userIDs) == 0 {
+		return
+	}
+
+	users, err := h.users.Search(userRepository.UserFilter{UserIds: userIDs})
+	if err != nil {
+		log.Printf("Erro ao verificar status vencidos: %v", err)
+		return
+	}
+
+	for _, user := range users {
+		if user.StatusExpiresAt == nil || user.StatusExpiresAt.After(now) {
+			continue
+		}
+
+		cleared, err := h.presence.ExpireStatus(user.UserId, now)
+		if err != nil {
+			log.Printf("Erro ao vencer o status de %s: %v", user.UserId, err)
+			continue
+		}
+
+		if !cleared {
+			continue
+		}
+
+		// como o status aparecia antes de vencer
+		before := user
+		before.StatusExpiresAt = nil
+
+		after := user
+		after.Status, after.StatusText, after.StatusExpiresAt = "", "", nil
+
+		h.StatusChanged(before, after)
+	}
+}
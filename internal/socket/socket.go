@@ -1,11 +1,26 @@
 package socket
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"go-web-socket/internal/middleware"
+	"go-web-socket/internal/models"
+	messageRepository "go-web-socket/internal/repositories/MessageRepository"
+	roomRepository "go-web-socket/internal/repositories/RoomRepository"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	brokerService "go-web-socket/internal/services/BrokerService"
+	presenceService "go-web-socket/internal/services/PresenceService"
+	storageService "go-web-socket/internal/services/StorageService"
+	userService "go-web-socket/internal/services/UserService"
+	verificationService "go-web-socket/internal/services/VerificationService"
+	webhookService "go-web-socket/internal/services/WebhookService"
+	"go-web-socket/internal/utils/pagination"
+	"log"
 	"net/http"
-	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -28,21 +43,115 @@ type Message struct {
 	MimeType    string    `json:"mime_type"`
 	Filename    string    `json:"filename"`
 	FileUrl     string    `json:"fileurl"`
+	Bot         bool      `json:"bot,omitempty"`  // remetente (ou usuário do status) é um bot
+	Room        string    `json:"room,omitempty"` // sala de origem de um comando repassado a um bot
+	// Integration identifica o webhook de entrada que publicou a mensagem
+	Integration *Integration `json:"integration,omitempty"`
+	// Users escolhe de quais contatos receber a presença (subscribe-presence)
+	Users     []string                   `json:"users,omitempty"`
+	Presence  *presenceService.Presence  `json:"presence,omitempty"`  // nas mensagens de status
+	Presences []presenceService.Presence `json:"presences,omitempty"` // na resposta a subscribe-presence
+}
+
+// 📌 Integração (webhook de entrada) que publicou uma mensagem
+type Integration struct {
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	IconURL string `json:"icon_url,omitempty"`
 }
 
 var (
-	clients    sync.Map
-	fileChunks = make(map[string]map[int][]byte)
+	ErrUserOffline   = errors.New("usuário não encontrado")
+	ErrNotRoomMember = errors.New("você não é membro desta sala")
+	ErrBotBroadcast  = errors.New("bots só enviam mensagens privadas ou de sala")
+	ErrMutedInRoom   = errors.New("você está silenciado nesta sala")
+	ErrNotEphemeral  = errors.New("só bots enviam mensagens efêmeras")
+	ErrReservedType  = errors.New("tipo de mensagem reservado ao servidor")
+)
+
+// 📌 Tipos que só o servidor gera: avisos do sistema, mudanças e listas de
+// presença e chamadas de comandos para os bots
+var reservedTypes = map[string]bool{
+	"system":   true,
+	"status":   true,
+	"presence": true,
+	"command":  true,
+}
+
+// 📌 Prepara uma mensagem recebida de um cliente: o remetente é sempre quem
+// está autenticado e os campos preenchidos pelo servidor são descartados
+func clientMessage(msg *Message, from string) error {
+	if reservedTypes[msg.Type] {
+		return ErrReservedType
+	}
+
+	msg.From = from
+	msg.Integration = nil
+	msg.Presence = nil
+	msg.Presences = nil
+
+	return nil
+}
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// 📌 Hub guarda as conexões abertas e as dependências usadas pelo socket
+type Hub struct {
+	users        userRepository.UserRepository
+	messages     messageRepository.MessageRepository
+	rooms        roomRepository.RoomRepository
+	files        *storageService.StorageService
+	verification *verificationService.VerificationService
+	webhooks     *webhookService.WebhookService
+	broker       brokerService.Broker
+	presence     *presenceService.PresenceService
+	commands     *CommandRegistry
+
+	mu         sync.RWMutex
+	clients    map[string]map[*client]struct{}
+	idle       map[string]bool // usuários deste nó sem atividade em nenhuma conexão
+	unsynced   map[string]bool // usuários cuja mudança o broker não registrou → bot
+	fileChunks map[string]map[int][]byte
 	chunkMutex sync.Mutex
-	upgrader   = websocket.Upgrader{
-		ReadBufferSize:  1024,
-		WriteBufferSize: 1024,
-		CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+func NewHub(users userRepository.UserRepository, messages messageRepository.MessageRepository, rooms roomRepository.RoomRepository, files *storageService.StorageService, verification *verificationService.VerificationService, webhooks *webhookService.WebhookService, broker brokerService.Broker, presence *presenceService.PresenceService) *Hub {
+	h := &Hub{
+		users:        users,
+		messages:     messages,
+		rooms:        rooms,
+		files:        files,
+		verification: verification,
+		webhooks:     webhooks,
+		broker:       broker,
+		presence:     presence,
+		commands:     NewCommandRegistry(),
+		clients:      make(map[string]map[*client]struct{}),
+		idle:         make(map[string]bool),
+		unsynced:     make(map[string]bool),
+		fileChunks:   make(map[string]map[int][]byte),
 	}
-)
 
-// 📌 Envia mensagem via HTTP (REST API)
-func SendMessage(ctx *gin.Context) {
+	for _, cmd := range builtinCommands() {
+		if err := h.commands.Register(cmd); err != nil {
+			panic(fmt.Sprintf("comando /%s: %v", cmd.Name, err))
+		}
+	}
+
+	h.broker.Subscribe(h.receive)
+
+	// Os comandos dos bots já conectados a outros nós
+	h.publish(brokerService.Envelope{Kind: brokerService.KindBotCommandsSync})
+
+	return h
+}
+
+// 📌 Envia mensagem via HTTP (REST API, requer o middleware de autenticação)
+func (h *Hub) SendMessage(ctx *gin.Context) {
 	var msg Message
 	msg.Timestamp = time.Now()
 
@@ -52,22 +161,28 @@ func SendMessage(ctx *gin.Context) {
 		return
 	}
 
-	// Converte a mensagem para JSON
-	messageBytes, err := json.Marshal(msg)
+	// O remetente é sempre quem se autenticou (usuário ou chave de API)
+	sender, err := h.users.FindByUserId(middleware.CurrentUser(ctx).UserId)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Erro ao processar mensagem"})
+		ctx.JSON(http.StatusForbidden, gin.H{"message": "Usuário não encontrado"})
 		return
 	}
 
-	// Se for mensagem privada, envia direto ao destinatário
-	if msg.Type == "private" {
-		if err := sendPrivateMessage(msg.To, messageBytes); err != nil {
-			ctx.JSON(http.StatusNotFound, gin.H{"message": "Usuário não encontrado"})
-			return
-		}
-	} else {
-		// Broadcast para todos os clientes
-		broadcastMessage(messageBytes)
+	if err := clientMessage(&msg, sender.UserId); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	switch err := h.dispatch(&msg, sender.Bot); {
+	case errors.Is(err, ErrUserOffline):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "Usuário não encontrado"})
+		return
+	case errors.Is(err, ErrNotRoomMember), errors.Is(err, ErrBotBroadcast), errors.Is(err, ErrMutedInRoom), errors.Is(err, ErrNotEphemeral):
+		ctx.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Erro ao processar mensagem"})
+		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
@@ -76,25 +191,96 @@ func SendMessage(ctx *gin.Context) {
 	})
 }
 
-// 📌 Retorna os usuários online
-func GetOnlineUsers(ctx *gin.Context) {
-	var users []string
-	clients.Range(func(key, _ interface{}) bool {
-		users = append(users, key.(string))
-		return true
-	})
+// 📌 Lista os perfis dos usuários online em qualquer nó, menos os invisíveis,
+// paginados e filtrados por ?search= (trecho do nome ou do username). Só os
+// contatos de quem pediu vêm com a presença (requer o middleware de
+// autenticação).
+func (h *Hub) GetOnlineUsers(ctx *gin.Context) {
+	page, err := pagination.Parse(ctx.Query("page"), ctx.Query("per_page"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	online, err := h.broker.Online()
+	if err != nil {
+		log.Printf("Erro ao consultar o registro de presença: %v", err)
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"message": "Presença indisponível no momento"})
+		return
+	}
+
+	if len(online) == 0 {
+		ctx.JSON(http.StatusOK, gin.H{"data": []userService.Profile{}, "pagination": page.Meta(0)})
+		return
+	}
+
+	userIDs := make([]string, 0, len(online))
+	for _, presence := range online {
+		userIDs = append(userIDs, presence.UserId)
+	}
 
-	ctx.JSON(http.StatusOK, gin.H{"online_users": users})
+	filter := userRepository.UserFilter{
+		Search:     ctx.Query("search"),
+		UserIds:    userIDs,
+		HideStatus: presenceService.StatusInvisible,
+	}
+
+	total, err := h.users.Count(filter)
+	if err != nil {
+		log.Printf("Erro ao contar usuários online: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Houve um erro ao buscar os usuários online"})
+		return
+	}
+
+	filter.Limit, filter.Offset = page.PerPage, page.Offset()
+
+	users, err := h.users.Search(filter)
+	if err != nil {
+		log.Printf("Erro ao buscar usuários online: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Houve um erro ao buscar os usuários online"})
+		return
+	}
+
+	pageIDs := make([]string, 0, len(users))
+	for _, user := range users {
+		pageIDs = append(pageIDs, user.UserId)
+	}
+
+	presences, err := h.ContactPresences(middleware.CurrentUser(ctx).UserId, pageIDs)
+	if err != nil {
+		log.Printf("Erro ao consultar o registro de presença: %v", err)
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"message": "Presença indisponível no momento"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": userService.Profiles(users, presences), "pagination": page.Meta(total)})
 }
 
-// 📌 Manipula conexões WebSocket
-func HandleSocket(ctx *gin.Context) {
+// 📌 Manipula conexões WebSocket (requer o middleware de autenticação)
+func (h *Hub) HandleSocket(ctx *gin.Context) {
 	userID := ctx.Param("user_id")
 	if userID == "" {
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "user_id é obrigatório"})
 		return
 	}
 
+	claims := middleware.CurrentUser(ctx)
+	if claims == nil || claims.UserId != userID {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "O token não pertence a este usuário"})
+		return
+	}
+
+	user, err := h.users.FindByUserId(userID)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "Usuário não encontrado"})
+		return
+	}
+
+	if !h.verification.AllowsSocket(user) {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "E-mail não verificado", "email_verified": false})
+		return
+	}
+
 	conn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
 	if err != nil {
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "Erro ao estabelecer WebSocket", "details": err.Error()})
@@ -102,17 +288,25 @@ func HandleSocket(ctx *gin.Context) {
 	}
 
 	defer conn.Close()
-	clients.Store(userID, conn)
-	broadcastUserStatus(userID, true)
 
-	fmt.Println("Novo usuário conectado:", userID)
+	c := &client{conn: conn, userID: userID, sessionID: claims.SessionId, username: user.Username, bot: user.Bot}
+	c.lastActive.Store(time.Now().UnixNano())
 
-	conn.SetCloseHandler(func(code int, text string) error {
-		fmt.Println("Usuário desconectado:", userID)
-		clients.Delete(userID)
-		broadcastUserStatus(userID, false)
-		return nil
-	})
+	transition := h.join(c)
+	if transition.Connected() {
+		h.webhooks.Publish(webhookService.EventUserConnected, "", map[string]interface{}{"user_id": userID, "bot": c.bot})
+	}
+
+	h.announceTransition(userID, c.bot, transition)
+	h.touch(c) // uma nova conexão tira o usuário da ausência por inatividade
+
+	if !c.bot {
+		if err := h.subscribePresence(c, nil); err != nil {
+			log.Printf("Erro ao enviar a presença dos contatos de %s: %v", userID, err)
+		}
+	}
+
+	fmt.Println("Novo usuário conectado:", userID)
 
 	for {
 		messageType, message, err := conn.ReadMessage()
@@ -126,33 +320,59 @@ func HandleSocket(ctx *gin.Context) {
 				continue
 			}
 
-			msgData.From = userID
+			h.touch(c)
 
-			switch msgData.Type {
-			case "private":
-				if err := sendPrivateMessage(msgData.To, message); err != nil {
-					conn.WriteMessage(websocket.TextMessage, []byte("Erro: "+err.Error()))
-				}
+			if msgData.Timestamp.IsZero() {
+				msgData.Timestamp = time.Now()
+			}
+
+			err := clientMessage(&msgData, userID)
+
+			switch {
+			case err != nil:
+				// tipo reservado, não chega ao dispatch
+			case msgData.Type == "activity":
+				// só avisa que o usuário está usando o app
+			case !c.bot && msgData.Type == "subscribe-presence":
+				// sem "users", acompanha todos os contatos
+				err = h.subscribePresence(c, msgData.Users)
+			case c.bot && msgData.Type == "register-command":
+				err = h.registerBotCommand(c, msgData)
+			case !c.bot && isCommand(msgData.Message):
+				h.runCommand(c, msgData)
 			default:
-				broadcastMessage(message)
+				if !c.bot && strings.HasPrefix(msgData.Message, "//") {
+					msgData.Message = msgData.Message[1:]
+				}
+
+				err = h.dispatch(&msgData, c.bot)
+			}
+
+			if err != nil {
+				c.write([]byte("Erro: " + err.Error()))
 			}
 		} else if messageType == websocket.BinaryMessage {
-			err := handleFileChunk(userID, message)
+			err := h.handleFileChunk(userID, message)
 			if err != nil {
-				conn.WriteMessage(websocket.TextMessage, []byte("Erro ao processar o arquivo: "+err.Error()))
+				c.write([]byte("Erro ao processar o arquivo: " + err.Error()))
 			} else {
-				conn.WriteMessage(websocket.TextMessage, []byte("Chunk de arquivo recebido"))
+				c.write([]byte("Chunk de arquivo recebido"))
 			}
 		}
 	}
 
-	clients.Delete(userID)
-	broadcastUserStatus(userID, false)
+	transition = h.leave(c)
+	h.announceTransition(userID, c.bot, transition)
+
+	if transition.Disconnected() {
+		h.webhooks.Publish(webhookService.EventUserDisconnected, "", map[string]interface{}{"user_id": userID, "bot": c.bot})
+	}
+
 	fmt.Println("Usuário desconectado:", userID)
 }
 
 // 📌 Manipula os chunks de arquivo recebidos
-func handleFileChunk(userID string, message []byte) error {
+func (h *Hub) handleFileChunk(userID string, message []byte) error {
 	fmt.Println(string(message))
 
 	var msg Message
@@ -166,18 +386,18 @@ func handleFileChunk(userID string, message []byte) error {
 		return err
 	}
 
-	chunkMutex.Lock()
-	defer chunkMutex.Unlock()
+	h.chunkMutex.Lock()
+	defer h.chunkMutex.Unlock()
 
-	if _, exists := fileChunks[msg.FileId]; !exists {
-		fileChunks[msg.FileId] = make(map[int][]byte)
+	if _, exists := h.fileChunks[msg.FileId]; !exists {
+		h.fileChunks[msg.FileId] = make(map[int][]byte)
 	}
 
-	fileChunks[msg.FileId][msg.ChunkIndex] = chunkData
+	h.fileChunks[msg.FileId][msg.ChunkIndex] = chunkData
 	fmt.Printf("Recebido chunk %d de %d para arquivo %s\n", msg.ChunkIndex+1, msg.TotalChunks, msg.FileId)
 
-	if len(fileChunks[msg.FileId]) == msg.TotalChunks {
-		return finalizeFileUpload(msg.FileId)
+	if len(h.fileChunks[msg.FileId]) == msg.TotalChunks {
+		return h.finalizeFileUpload(userID, msg)
 	}
 
 	return nil
@@ -188,65 +408,271 @@ func decodeBase64(data string) ([]byte, error) {
 	return base64.StdEncoding.DecodeString(data)
 }
 
-// 📌 Finaliza a reconstrução do arquivo
-func finalizeFileUpload(fileId string) error {
-	filePath := fmt.Sprintf("uploads/%s_reconstructed", fileId)
+// 📌 Finaliza a reconstrução do arquivo e grava no storage (deduplicado por SHA-256)
+func (h *Hub) finalizeFileUpload(userID string, msg Message) error {
+	var data bytes.Buffer
 
-	if _, err := os.Stat("uploads/"); os.IsNotExist(err) {
-		os.Mkdir("uploads/", 0755)
+	for i := 0; i < len(h.fileChunks[msg.FileId]); i++ {
+		data.Write(h.fileChunks[msg.FileId][i])
 	}
 
-	file, err := os.Create(filePath)
+	delete(h.fileChunks, msg.FileId)
+
+	file, err := h.files.Store(userID, msg.FileId, msg.Filename, data.Bytes())
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	for i := 0; i < len(fileChunks[fileId]); i++ {
-		if _, err := file.Write(fileChunks[fileId][i]); err != nil {
+	fmt.Println("Arquivo reconstruído com sucesso:", h.files.URL(file.Hash))
+
+	h.webhooks.Publish(webhookService.EventFileUploaded, "", map[string]interface{}{
+		"user_id":  userID,
+		"file_id":  file.FileId,
+		"filename": file.Filename,
+		"hash":     file.Hash,
+		"url":      h.files.URL(file.Hash),
+	})
+
+	return nil
+}
+
+// 📌 Salva no histórico as mensagens de texto entre usuários conhecidos
+func (h *Hub) saveMessage(msg Message) {
+	if msg.Message == "" || msg.Type == "status" || msg.Type == "system" || msg.Type == "ephemeral" {
+		return
+	}
+
+	sender, err := h.users.FindByUserId(msg.From)
+	if err != nil {
+		log.Printf("Mensagem não salva, remetente %q: %v", msg.From, err)
+		return
+	}
+
+	record := models.Message{
+		UserID:  sender.ID,
+		Content: msg.Message,
+	}
+
+	if msg.Integration != nil {
+		record.Integration = msg.Integration.Name
+	}
+
+	if msg.Type == "private" {
+		recipient, err := h.users.FindByUserId(msg.To)
+		if err != nil {
+			log.Printf("Mensagem não salva, destinatário %q: %v", msg.To, err)
+			return
+		}
+
+		record.RecipientID = &recipient.ID
+	}
+
+	if msg.Type == "room" {
+		room, err := h.rooms.FindByRoomId(msg.To)
+		if err != nil {
+			log.Printf("Mensagem não salva, sala %q: %v", msg.To, err)
+			return
+		}
+
+		record.RoomID = &room.ID
+	}
+
+	if err := h.messages.Create(&record); err != nil {
+		log.Printf("Erro ao salvar mensagem: %v", err)
+	}
+}
+
+// 📌 Valida, salva e entrega uma mensagem de um usuário ou bot. msg.From já
+// deve ser o remetente autenticado.
+func (h *Hub) dispatch(msg *Message, fromBot bool) error {
+	switch msg.Type {
+	case "private":
+	case "ephemeral":
+		// resposta de um bot a um comando, vista só pelo destinatário
+		if !fromBot {
+			return ErrNotEphemeral
+		}
+	case "room":
+		member, err := h.rooms.FindMember(msg.To, msg.From)
+		if errors.Is(err, roomRepository.ErrNotMember) {
+			return ErrNotRoomMember
+		}
+
+		if err != nil {
 			return err
 		}
+
+		if member.MutedUntil != nil && time.Now().Before(*member.MutedUntil) {
+			return ErrMutedInRoom
+		}
+	default:
+		if fromBot {
+			return ErrBotBroadcast
+		}
+	}
+
+	msg.Bot = fromBot
+
+	messageBytes, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	h.saveMessage(*msg)
+
+	if msg.Type != "ephemeral" {
+		h.publishMessage(*msg)
+	}
+
+	switch msg.Type {
+	case "private", "ephemeral":
+		return h.sendPrivateMessage(msg.To, messageBytes)
+	case "room":
+		return h.sendRoomMessage(*msg, messageBytes)
+	default:
+		h.broadcastMessage(messageBytes)
+		return nil
+	}
+}
+
+// 📌 Publica a mensagem para os webhooks (os da sala só recebem as dela)
+func (h *Hub) publishMessage(msg Message) {
+	roomId := ""
+	if msg.Type == "room" {
+		roomId = msg.To
+	}
+
+	h.webhooks.Publish(webhookService.EventMessageCreated, roomId, map[string]interface{}{
+		"type":        msg.Type,
+		"from":        msg.From,
+		"to":          msg.To,
+		"message":     msg.Message,
+		"status":      msg.Status,
+		"bot":         msg.Bot,
+		"integration": msg.Integration,
+		"timestamp":   msg.Timestamp,
+	})
+}
+
+// 📌 Publica a mensagem recebida por um webhook de entrada em nome do dono:
+// na sala do webhook ou, se for pessoal, para o próprio dono. Dono offline
+// não é erro, a mensagem fica no histórico.
+func (h *Hub) PostAsIntegration(hook models.IncomingWebhook, name, iconURL, text string) error {
+	msg := Message{
+		Type:      "private",
+		To:        hook.OwnerId,
+		From:      hook.OwnerId,
+		Message:   text,
+		Timestamp: time.Now(),
+		Integration: &Integration{
+			Id:      hook.HookId,
+			Name:    name,
+			IconURL: iconURL,
+		},
+	}
+
+	if hook.RoomId != "" {
+		msg.Type = "room"
+		msg.To = hook.RoomId
+	}
+
+	if err := h.dispatch(&msg, false); err != nil && !errors.Is(err, ErrUserOffline) {
+		return err
 	}
 
-	fmt.Println("Arquivo reconstruído com sucesso:", filePath)
-	delete(fileChunks, fileId)
 	return nil
 }
 
-// 📌 Enviar mensagem privada
-func sendPrivateMessage(toUser string, message []byte) error {
-	if conn, ok := clients.Load(toUser); ok {
-		return conn.(*websocket.Conn).WriteMessage(websocket.TextMessage, message)
+// 📌 Enviar mensagem privada, às conexões deste nó e, pelo broker, às dos
+// outros nós
+func (h *Hub) sendPrivateMessage(toUser string, message []byte) error {
+	conns := h.clientsOf(toUser)
+
+	remote, err := h.sendToUserNodes(toUser, brokerService.Envelope{Kind: brokerService.KindPrivate, Target: toUser, Payload: message})
+	if err != nil && len(conns) == 0 {
+		return err
+	}
+
+	if len(conns) == 0 && remote == 0 {
+		return ErrUserOffline
 	}
-	return fmt.Errorf("usuário não encontrado")
+
+	return h.deliverPrivate(conns, message)
 }
 
-// 📌 Broadcast para todos os clientes conectados
-func broadcastMessage(message []byte) {
-	clients.Range(func(_, clientConn interface{}) bool {
-		clientConn.(*websocket.Conn).WriteMessage(websocket.TextMessage, message)
-		return true
-	})
+// 📌 Escreve a mensagem nas conexões deste nó
+func (h *Hub) deliverPrivate(conns []*client, message []byte) error {
+	var err error
+	for _, c := range conns {
+		if writeErr := c.write(message); writeErr != nil {
+			err = writeErr
+		}
+	}
+
+	return err
+}
+
+// 📌 Entrega a mensagem aos membros conectados da sala, neste e nos outros nós
+func (h *Hub) sendRoomMessage(msg Message, message []byte) error {
+	h.publish(brokerService.Envelope{Kind: brokerService.KindRoom, Target: msg.To, Payload: message})
+
+	return h.deliverRoom(msg, message)
+}
+
+// 📌 Entrega a mensagem aos membros da sala conectados a este nó. Bots só
+// recebem as mensagens que os mencionam (@username).
+func (h *Hub) deliverRoom(msg Message, message []byte) error {
+	members, err := h.rooms.Members(msg.To)
+	if err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		for _, c := range h.clientsOf(member) {
+			if c.bot && !mentions(msg.Message, c.username) {
+				continue
+			}
+
+			c.write(message)
+		}
+	}
+
+	return nil
 }
 
-// 📌 Notifica usuários sobre conexão/desconexão
-func broadcastUserStatus(userID string, connected bool) {
-	status := "user-disconnected"
-	if connected {
-		status = "user-connected"
+// 📌 Broadcast para todos os clientes conectados, em todos os nós
+func (h *Hub) broadcastMessage(message []byte) {
+	h.publish(brokerService.Envelope{Kind: brokerService.KindBroadcast, Payload: message})
+
+	h.deliverBroadcast(message)
+}
+
+// 📌 Broadcast para os clientes deste nó, menos os bots, que só recebem o
+// que é endereçado a eles
+func (h *Hub) deliverBroadcast(message []byte) {
+	for _, c := range h.allClients() {
+		if c.bot {
+			continue
+		}
+
+		c.write(message)
 	}
+}
 
+// 📌 Envia um aviso do sistema para todos os clientes conectados
+func (h *Hub) BroadcastSystem(status, text string) {
 	msg := Message{
-		Type:   "status",
-		From:   userID,
-		Status: status,
+		Type:      "system",
+		Status:    status,
+		Message:   text,
+		Timestamp: time.Now(),
 	}
 
 	msgBytes, err := json.Marshal(msg)
 	if err != nil {
-		fmt.Println("Erro ao serializar mensagem de status:", err)
+		fmt.Println("Erro ao serializar mensagem do sistema:", err)
 		return
 	}
 
-	broadcastMessage(msgBytes)
+	h.broadcastMessage(msgBytes)
 }
@@ -0,0 +1,35 @@
+package socket
+
+import (
+	"errors"
+	"testing"
+
+	presenceService "go-web-socket/internal/services/PresenceService"
+)
+
+func TestClientMessageRejectsReservedTypes(t *testing.T) {
+	for _, kind := range []string{"system", "status", "presence", "command"} {
+		msg := Message{Type: kind, Message: "Manutenção em 5 minutos"}
+		if err := clientMessage(&msg, "u1"); !errors.Is(err, ErrReservedType) {
+			t.Errorf("tipo %q: esperava ErrReservedType, veio %v", kind, err)
+		}
+	}
+}
+
+func TestClientMessageResetsServerFields(t *testing.T) {
+	msg := Message{
+		Type:        "private",
+		From:        "admin",
+		Integration: &Integration{Id: "hook"},
+		Presence:    &presenceService.Presence{},
+		Presences:   []presenceService.Presence{{}},
+	}
+
+	if err := clientMessage(&msg, "u1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg.From != "u1" || msg.Integration != nil || msg.Presence != nil || msg.Presences != nil {
+		t.Fatalf("campos do servidor não foram descartados: %+v", msg)
+	}
+}
@@ -0,0 +1,52 @@
+package audit
+
+import (
+	"encoding/json"
+	"go-web-socket/config"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	mu   sync.Mutex
+	file *os.File
+)
+
+// Log grava um evento de segurança no log de auditoria (AUDIT_LOG_FILE,
+// padrão audit.log), uma linha JSON por evento.
+func Log(event string, fields map[string]interface{}) {
+	entry := map[string]interface{}{
+		"time":  time.Now().UTC().Format(time.RFC3339),
+		"event": event,
+	}
+
+	for key, value := range fields {
+		entry[key] = value
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("Erro ao serializar evento de auditoria %s: %v", event, err)
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if file == nil {
+		config.LoadEnv()
+
+		file, err = os.OpenFile(config.GetEnv("AUDIT_LOG_FILE", "audit.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+		if err != nil {
+			log.Printf("Erro ao abrir log de auditoria: %v", err)
+			file = nil
+			return
+		}
+	}
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		log.Printf("Erro ao gravar log de auditoria: %v", err)
+	}
+}
@@ -1,13 +1,241 @@
 package useHash
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"go-web-socket/config"
+	"log"
+	"math"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+var errInvalidHash = errors.New("hash de senha em formato desconhecido")
+
+// Params são os parâmetros usados para gerar hashes novos. Hashes gravados
+// com parâmetros diferentes continuam válidos e são refeitos no login.
+type Params struct {
+	Algorithm  string
+	BcryptCost int
+	// Argon2Memory é em KiB.
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+}
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+var (
+	params     Params
+	paramsOnce sync.Once
+
+	dummyHash     string
+	dummyHashOnce sync.Once
+)
+
+// Limites aceitos para os parâmetros do Argon2id. Memória acima de 4 GiB
+// derrubaria o processo a cada login.
+const (
+	argon2MaxMemory     = 4 * 1024 * 1024
+	argon2MaxIterations = 100
+)
+
+var defaultParams = Params{
+	Algorithm:         AlgorithmBcrypt,
+	BcryptCost:        12,
+	Argon2Memory:      64 * 1024,
+	Argon2Iterations:  3,
+	Argon2Parallelism: 2,
+}
+
+// LoadParams lê PASSWORD_HASH_ALGORITHM (bcrypt ou argon2id), BCRYPT_COST
+// e ARGON2_MEMORY/ARGON2_ITERATIONS/ARGON2_PARALLELISM. Valores inválidos
+// são trocados pelo padrão, com um aviso no log.
+func LoadParams() Params {
+	paramsOnce.Do(func() {
+		config.LoadEnv()
+
+		params = parseParams(
+			config.GetEnv("PASSWORD_HASH_ALGORITHM", defaultParams.Algorithm),
+			config.GetEnvInt("BCRYPT_COST", defaultParams.BcryptCost),
+			config.GetEnvInt("ARGON2_MEMORY", int(defaultParams.Argon2Memory)),
+			config.GetEnvInt("ARGON2_ITERATIONS", int(defaultParams.Argon2Iterations)),
+			config.GetEnvInt("ARGON2_PARALLELISM", int(defaultParams.Argon2Parallelism)),
+		)
+	})
+
+	return params
+}
+
+// parseParams valida os valores antes das conversões: um uint8(256) vira 0
+// e o argon2 entra em pânico com paralelismo ou iterações zerados.
+func parseParams(algorithm string, cost, memory, iterations, parallelism int) Params {
+	p := defaultParams
+
+	switch algorithm {
+	case AlgorithmBcrypt, AlgorithmArgon2id:
+		p.Algorithm = algorithm
+	default:
+		log.Printf("PASSWORD_HASH_ALGORITHM inválido (%q), usando %s", algorithm, p.Algorithm)
+	}
+
+	if cost >= bcrypt.MinCost && cost <= bcrypt.MaxCost {
+		p.BcryptCost = cost
+	} else {
+		log.Printf("BCRYPT_COST inválido (%d), usando %d", cost, p.BcryptCost)
+	}
+
+	if parallelism >= 1 && parallelism <= math.MaxUint8 {
+		p.Argon2Parallelism = uint8(parallelism)
+	} else {
+		log.Printf("ARGON2_PARALLELISM inválido (%d), usando %d", parallelism, p.Argon2Parallelism)
+	}
+
+	// O argon2 exige ao menos 8 KiB por via de paralelismo.
+	if memory >= 8*int(p.Argon2Parallelism) && memory <= argon2MaxMemory {
+		p.Argon2Memory = uint32(memory)
+	} else {
+		log.Printf("ARGON2_MEMORY inválido (%d), usando %d", memory, p.Argon2Memory)
+	}
+
+	if iterations >= 1 && iterations <= argon2MaxIterations {
+		p.Argon2Iterations = uint32(iterations)
+	} else {
+		log.Printf("ARGON2_ITERATIONS inválido (%d), usando %d", iterations, p.Argon2Iterations)
+	}
+
+	return p
+}
 
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+	p := LoadParams()
+
+	if p.Algorithm == AlgorithmArgon2id {
+		return hashArgon2id(password, p)
+	}
+
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), p.BcryptCost)
 	return string(bytes), err
 }
 
 func CheckPasswordHash(password, hash string) bool {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		return checkArgon2id(password, hash)
+	}
+
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
+
+// NeedsRehash diz se o hash foi gerado com outro algoritmo ou com
+// parâmetros diferentes dos atuais.
+func NeedsRehash(hash string) bool {
+	p := LoadParams()
+
+	if strings.HasPrefix(hash, "$argon2id$") {
+		if p.Algorithm != AlgorithmArgon2id {
+			return true
+		}
+
+		hp, _, _, err := decodeArgon2id(hash)
+		return err != nil || hp.Argon2Memory != p.Argon2Memory ||
+			hp.Argon2Iterations != p.Argon2Iterations ||
+			hp.Argon2Parallelism != p.Argon2Parallelism
+	}
+
+	if p.Algorithm != AlgorithmBcrypt {
+		return true
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != p.BcryptCost
+}
+
+// CheckDummyHash gasta o mesmo tempo de uma verificação real. É usado quando
+// o usuário não existe, para que o tempo de resposta não revele isso.
+func CheckDummyHash(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = HashPassword("dummy-password")
+	})
+
+	CheckPasswordHash(password, dummyHash)
+}
+
+// hashArgon2id gera o hash no formato PHC:
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+func hashArgon2id(password string, p Params) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, p.Argon2Iterations, p.Argon2Memory, p.Argon2Parallelism, argon2KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Argon2Memory, p.Argon2Iterations, p.Argon2Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func decodeArgon2id(hash string) (Params, []byte, []byte, error) {
+	var p Params
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return p, nil, nil, errInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, errInvalidHash
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Argon2Memory, &p.Argon2Iterations, &p.Argon2Parallelism); err != nil {
+		return p, nil, nil, errInvalidHash
+	}
+
+	if p.Argon2Iterations < 1 || p.Argon2Parallelism < 1 || p.Argon2Memory > argon2MaxMemory {
+		return p, nil, nil, errInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, errInvalidHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, errInvalidHash
+	}
+
+	p.Algorithm = AlgorithmArgon2id
+
+	return p, salt, key, nil
+}
+
+func checkArgon2id(password, hash string) bool {
+	p, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false
+	}
+
+	other := argon2.IDKey([]byte(password), salt, p.Argon2Iterations, p.Argon2Memory, p.Argon2Parallelism, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(key, other) == 1
+}
@@ -0,0 +1,59 @@
+package useHash
+
+import "testing"
+
+func TestParseParamsFallsBackOnInvalidValues(t *testing.T) {
+	tests := []struct {
+		name                               string
+		algorithm                          string
+		cost, memory, iterations, parallel int
+		want                               Params
+	}{
+		{
+			name:      "valores válidos",
+			algorithm: AlgorithmArgon2id, cost: 10, memory: 32 * 1024, iterations: 2, parallel: 4,
+			want: Params{Algorithm: AlgorithmArgon2id, BcryptCost: 10, Argon2Memory: 32 * 1024, Argon2Iterations: 2, Argon2Parallelism: 4},
+		},
+		{
+			name:      "algoritmo desconhecido",
+			algorithm: "md5", cost: 12, memory: 64 * 1024, iterations: 3, parallel: 2,
+			want: defaultParams,
+		},
+		{
+			name:      "paralelismo que estoura o uint8",
+			algorithm: AlgorithmBcrypt, cost: 12, memory: 64 * 1024, iterations: 3, parallel: 256,
+			want: defaultParams,
+		},
+		{
+			name:      "zeros e negativos",
+			algorithm: AlgorithmBcrypt, cost: 0, memory: -1, iterations: 0, parallel: 0,
+			want: defaultParams,
+		},
+		{
+			name:      "memória menor que 8 KiB por via",
+			algorithm: AlgorithmBcrypt, cost: 12, memory: 8, iterations: 3, parallel: 2,
+			want: defaultParams,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := parseParams(test.algorithm, test.cost, test.memory, test.iterations, test.parallel)
+			if got != test.want {
+				t.Fatalf("parseParams = %+v, esperado %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCheckRejectsBrokenArgon2Hash(t *testing.T) {
+	// p=0 e t=0 fariam o argon2 entrar em pânico.
+	for _, hash := range []string{
+		"$argon2id$v=19$m=65536,t=3,p=0$c2FsdHNhbHRzYWx0c2FsdA$a2V5",
+		"$argon2id$v=19$m=65536,t=0,p=2$c2FsdHNhbHRzYWx0c2FsdA$a2V5",
+	} {
+		if CheckPasswordHash("senha", hash) {
+			t.Fatalf("hash inválido aceito: %s", hash)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	type User struct {
+		ID       uint   `gorm:"primaryKey"`
+		Avatar   string `gorm:"size:255"`
+		UserId   string `gorm:"size:255;unique"`
+		Username string `gorm:"size:255;unique"`
+		Name     string `gorm:"size:150"`
+		Password string `gorm:"size:150"`
+	}
+
+	type Message struct {
+		ID        uint           `gorm:"primaryKey"`
+		UserID    uint           `gorm:"not null"`
+		User      User           `gorm:"constraint:OnDelete:CASCADE;"`
+		Content   string         `gorm:"type:text;not null"`
+		CreatedAt time.Time      `gorm:"autoCreateTime"`
+		DeletedAt gorm.DeletedAt `gorm:"index"`
+	}
+
+	register(Migration{
+		Version: "20250128000000",
+		Name:    "create_users_and_messages",
+		Up: func(tx *gorm.DB) error {
+			// Bancos criados pelo antigo AutoMigrate já têm essas tabelas.
+			if tx.Migrator().HasTable(&User{}) && tx.Migrator().HasTable(&Message{}) {
+				return nil
+			}
+
+			return tx.Migrator().CreateTable(&User{}, &Message{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&Message{}, &User{})
+		},
+	})
+}
@@ -0,0 +1,42 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	type StoredFile struct {
+		ID        uint      `gorm:"primaryKey"`
+		Hash      string    `gorm:"size:64;unique;not null"`
+		Size      int64     `gorm:"not null"`
+		MimeType  string    `gorm:"size:100"`
+		RefCount  int       `gorm:"not null;default:0"`
+		CreatedAt time.Time `gorm:"autoCreateTime"`
+	}
+
+	type File struct {
+		ID        uint      `gorm:"primaryKey"`
+		FileId    string    `gorm:"size:255;unique;not null"`
+		UserId    string    `gorm:"size:255;index"`
+		Filename  string    `gorm:"size:255"`
+		Hash      string    `gorm:"size:64;index;not null"`
+		CreatedAt time.Time `gorm:"autoCreateTime"`
+	}
+
+	register(Migration{
+		Version: "20250301000000",
+		Name:    "create_stored_files",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasTable(&StoredFile{}) && tx.Migrator().HasTable(&File{}) {
+				return nil
+			}
+
+			return tx.Migrator().CreateTable(&StoredFile{}, &File{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&File{}, &StoredFile{})
+		},
+	})
+}
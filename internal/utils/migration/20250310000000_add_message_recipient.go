@@ -0,0 +1,28 @@
+package migration
+
+import "gorm.io/gorm"
+
+func init() {
+	type Message struct {
+		RecipientID *uint `gorm:"index"`
+	}
+
+	register(Migration{
+		Version: "20250310000000",
+		Name:    "add_message_recipient",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&Message{}, "RecipientID"); err != nil {
+				return err
+			}
+
+			return tx.Migrator().CreateIndex(&Message{}, "RecipientID")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropIndex(&Message{}, "RecipientID"); err != nil {
+				return err
+			}
+
+			return tx.Migrator().DropColumn(&Message{}, "RecipientID")
+		},
+	})
+}
@@ -0,0 +1,31 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	type RefreshToken struct {
+		ID        uint      `gorm:"primaryKey"`
+		TokenHash string    `gorm:"size:64;unique;not null"`
+		FamilyId  string    `gorm:"size:36;index;not null"`
+		UserId    string    `gorm:"size:255;index;not null"`
+		ExpiresAt time.Time `gorm:"not null"`
+		UsedAt    *time.Time
+		RevokedAt *time.Time
+		CreatedAt time.Time `gorm:"autoCreateTime"`
+	}
+
+	register(Migration{
+		Version: "20250320000000",
+		Name:    "create_refresh_tokens",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&RefreshToken{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&RefreshToken{})
+		},
+	})
+}
@@ -0,0 +1,36 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	type Session struct {
+		ID         uint   `gorm:"primaryKey"`
+		SessionId  string `gorm:"size:36;unique;not null"`
+		UserId     string `gorm:"size:255;index;not null"`
+		UserAgent  string `gorm:"size:255"`
+		IP         string `gorm:"size:45"`
+		LastSeenAt time.Time
+		CreatedAt  time.Time `gorm:"autoCreateTime"`
+		RevokedAt  *time.Time
+	}
+
+	type RevokedToken struct {
+		Jti       string    `gorm:"primaryKey;size:36"`
+		ExpiresAt time.Time `gorm:"index;not null"`
+	}
+
+	register(Migration{
+		Version: "20250401000000",
+		Name:    "create_sessions",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&Session{}, &RevokedToken{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&RevokedToken{}, &Session{})
+		},
+	})
+}
@@ -0,0 +1,29 @@
+package migration
+
+import "gorm.io/gorm"
+
+func init() {
+	type User struct {
+		Role     string `gorm:"size:20;not null;default:user"`
+		Disabled bool   `gorm:"not null;default:false"`
+	}
+
+	register(Migration{
+		Version: "20250415000000",
+		Name:    "add_user_role_and_disabled",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&User{}, "Role"); err != nil {
+				return err
+			}
+
+			return tx.Migrator().AddColumn(&User{}, "Disabled")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropColumn(&User{}, "Disabled"); err != nil {
+				return err
+			}
+
+			return tx.Migrator().DropColumn(&User{}, "Role")
+		},
+	})
+}
@@ -0,0 +1,52 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	type User struct {
+		TOTPSecret   string `gorm:"size:64"`
+		TOTPEnabled  bool   `gorm:"not null;default:false"`
+		TOTPLastStep int64  `gorm:"not null;default:0"`
+	}
+
+	type RecoveryCode struct {
+		ID        uint   `gorm:"primaryKey"`
+		UserId    string `gorm:"size:255;index;not null"`
+		CodeHash  string `gorm:"size:64;not null"`
+		UsedAt    *time.Time
+		CreatedAt time.Time `gorm:"autoCreateTime"`
+	}
+
+	columns := []string{"TOTPSecret", "TOTPEnabled", "TOTPLastStep"}
+
+	register(Migration{
+		Version: "20250501000000",
+		Name:    "add_totp",
+		Up: func(tx *gorm.DB) error {
+			for _, column := range columns {
+				if err := tx.Migrator().AddColumn(&User{}, column); err != nil {
+					return err
+				}
+			}
+
+			return tx.Migrator().CreateTable(&RecoveryCode{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&RecoveryCode{}); err != nil {
+				return err
+			}
+
+			for _, column := range columns {
+				if err := tx.Migrator().DropColumn(&User{}, column); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	})
+}
@@ -0,0 +1,52 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	// O índice único é criado à parte: o SQLite não aceita UNIQUE em
+	// ALTER TABLE ADD COLUMN.
+	type User struct {
+		Email *string `gorm:"size:255;uniqueIndex:idx_users_email"`
+	}
+
+	type ActionToken struct {
+		ID        uint      `gorm:"primaryKey"`
+		Purpose   string    `gorm:"size:32;not null"`
+		TokenHash string    `gorm:"size:64;unique;not null"`
+		UserId    string    `gorm:"size:255;index;not null"`
+		ExpiresAt time.Time `gorm:"not null"`
+		UsedAt    *time.Time
+		CreatedAt time.Time `gorm:"autoCreateTime"`
+	}
+
+	register(Migration{
+		Version: "20250510000000",
+		Name:    "add_email_and_action_tokens",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&User{}, "Email"); err != nil {
+				return err
+			}
+
+			if err := tx.Migrator().CreateIndex(&User{}, "idx_users_email"); err != nil {
+				return err
+			}
+
+			return tx.Migrator().CreateTable(&ActionToken{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&ActionToken{}); err != nil {
+				return err
+			}
+
+			if err := tx.Migrator().DropIndex(&User{}, "idx_users_email"); err != nil {
+				return err
+			}
+
+			return tx.Migrator().DropColumn(&User{}, "Email")
+		},
+	})
+}
@@ -0,0 +1,25 @@
+package migration
+
+import "gorm.io/gorm"
+
+func init() {
+	type User struct {
+		Verified bool `gorm:"not null;default:false"`
+	}
+
+	register(Migration{
+		Version: "20250520000000",
+		Name:    "add_user_verified",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&User{}, "Verified"); err != nil {
+				return err
+			}
+
+			// Contas criadas antes da verificação existir continuam usáveis.
+			return tx.Model(&User{}).Where("1 = 1").Update("verified", true).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&User{}, "Verified")
+		},
+	})
+}
@@ -0,0 +1,28 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	type Identity struct {
+		ID        uint      `gorm:"primaryKey"`
+		Issuer    string    `gorm:"size:255;not null;uniqueIndex:idx_identities_issuer_subject"`
+		Subject   string    `gorm:"size:255;not null;uniqueIndex:idx_identities_issuer_subject"`
+		UserId    string    `gorm:"size:255;index;not null"`
+		CreatedAt time.Time `gorm:"autoCreateTime"`
+	}
+
+	register(Migration{
+		Version: "20250601000000",
+		Name:    "create_identities",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&Identity{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&Identity{})
+		},
+	})
+}
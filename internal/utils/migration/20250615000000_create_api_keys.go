@@ -0,0 +1,34 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	type APIKey struct {
+		ID         uint   `gorm:"primaryKey"`
+		KeyId      string `gorm:"size:16;unique;not null"`
+		SecretHash string `gorm:"size:64;not null"`
+		UserId     string `gorm:"size:255;index;not null"`
+		Name       string `gorm:"size:100"`
+		Scopes     string `gorm:"size:255;not null"`
+		ExpiresAt  *time.Time
+		RevokedAt  *time.Time
+		LastUsedAt *time.Time
+		LastUsedIP string    `gorm:"size:45"`
+		CreatedAt  time.Time `gorm:"autoCreateTime"`
+	}
+
+	register(Migration{
+		Version: "20250615000000",
+		Name:    "create_api_keys",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&APIKey{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&APIKey{})
+		},
+	})
+}
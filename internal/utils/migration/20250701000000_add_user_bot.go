@@ -0,0 +1,20 @@
+package migration
+
+import "gorm.io/gorm"
+
+func init() {
+	type User struct {
+		Bot bool `gorm:"not null;default:false"`
+	}
+
+	register(Migration{
+		Version: "20250701000000",
+		Name:    "add_user_bot",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().AddColumn(&User{}, "Bot")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&User{}, "Bot")
+		},
+	})
+}
@@ -0,0 +1,55 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	type Room struct {
+		ID        uint      `gorm:"primaryKey"`
+		RoomId    string    `gorm:"size:36;unique;not null"`
+		Name      string    `gorm:"size:100;not null"`
+		OwnerId   string    `gorm:"size:255;index;not null"`
+		CreatedAt time.Time `gorm:"autoCreateTime"`
+	}
+
+	type RoomMember struct {
+		ID        uint      `gorm:"primaryKey"`
+		RoomId    string    `gorm:"size:36;not null;uniqueIndex:idx_room_members_room_user"`
+		UserId    string    `gorm:"size:255;not null;uniqueIndex:idx_room_members_room_user;index"`
+		CreatedAt time.Time `gorm:"autoCreateTime"`
+	}
+
+	type Message struct {
+		RoomID *uint `gorm:"index"`
+	}
+
+	register(Migration{
+		Version: "20250702000000",
+		Name:    "create_rooms",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().CreateTable(&Room{}, &RoomMember{}); err != nil {
+				return err
+			}
+
+			if err := tx.Migrator().AddColumn(&Message{}, "RoomID"); err != nil {
+				return err
+			}
+
+			return tx.Migrator().CreateIndex(&Message{}, "RoomID")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropIndex(&Message{}, "RoomID"); err != nil {
+				return err
+			}
+
+			if err := tx.Migrator().DropColumn(&Message{}, "RoomID"); err != nil {
+				return err
+			}
+
+			return tx.Migrator().DropTable(&RoomMember{}, &Room{})
+		},
+	})
+}
@@ -0,0 +1,36 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	type Room struct {
+		Topic string `gorm:"size:255"`
+	}
+
+	type RoomMember struct {
+		MutedUntil *time.Time
+	}
+
+	register(Migration{
+		Version: "20250710000000",
+		Name:    "add_room_topic_and_mutes",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&Room{}, "Topic"); err != nil {
+				return err
+			}
+
+			return tx.Migrator().AddColumn(&RoomMember{}, "MutedUntil")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropColumn(&RoomMember{}, "MutedUntil"); err != nil {
+				return err
+			}
+
+			return tx.Migrator().DropColumn(&Room{}, "Topic")
+		},
+	})
+}
@@ -0,0 +1,48 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	type Webhook struct {
+		ID         uint   `gorm:"primaryKey"`
+		WebhookId  string `gorm:"size:36;unique;not null"`
+		RoomId     string `gorm:"size:36;index"`
+		OwnerId    string `gorm:"size:255;not null"`
+		URL        string `gorm:"size:2048;not null"`
+		Secret     string `gorm:"size:64;not null"`
+		Events     string `gorm:"size:255;not null"`
+		Failures   int    `gorm:"not null;default:0"`
+		DisabledAt *time.Time
+		CreatedAt  time.Time `gorm:"autoCreateTime"`
+	}
+
+	type WebhookDelivery struct {
+		ID            uint      `gorm:"primaryKey"`
+		DeliveryId    string    `gorm:"size:36;unique;not null"`
+		WebhookId     string    `gorm:"size:36;index;not null"`
+		Event         string    `gorm:"size:50;not null"`
+		Payload       string    `gorm:"type:text;not null"`
+		Status        string    `gorm:"size:20;not null;index:idx_webhook_deliveries_due"`
+		Attempts      int       `gorm:"not null;default:0"`
+		NextAttemptAt time.Time `gorm:"not null;index:idx_webhook_deliveries_due"`
+		LastStatus    int
+		LastError     string `gorm:"size:255"`
+		DeliveredAt   *time.Time
+		CreatedAt     time.Time `gorm:"autoCreateTime"`
+	}
+
+	register(Migration{
+		Version: "20250720000000",
+		Name:    "create_webhooks",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&Webhook{}, &WebhookDelivery{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&WebhookDelivery{}, &Webhook{})
+		},
+	})
+}
@@ -0,0 +1,44 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	type IncomingWebhook struct {
+		ID         uint   `gorm:"primaryKey"`
+		HookId     string `gorm:"size:16;unique;not null"`
+		TokenHash  string `gorm:"size:64;not null"`
+		RoomId     string `gorm:"size:36;index"`
+		OwnerId    string `gorm:"size:255;index;not null"`
+		Name       string `gorm:"size:100;not null"`
+		IconURL    string `gorm:"size:2048"`
+		LastUsedAt *time.Time
+		CreatedAt  time.Time `gorm:"autoCreateTime"`
+	}
+
+	type Message struct {
+		Integration string `gorm:"size:100"`
+	}
+
+	register(Migration{
+		Version: "20250801000000",
+		Name:    "create_incoming_webhooks",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().CreateTable(&IncomingWebhook{}); err != nil {
+				return err
+			}
+
+			return tx.Migrator().AddColumn(&Message{}, "Integration")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropColumn(&Message{}, "Integration"); err != nil {
+				return err
+			}
+
+			return tx.Migrator().DropTable(&IncomingWebhook{})
+		},
+	})
+}
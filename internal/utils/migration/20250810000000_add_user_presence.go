@@ -0,0 +1,41 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	type User struct {
+		Status          string `gorm:"size:16;not null;default:''"`
+		StatusText      string `gorm:"size:100"`
+		StatusExpiresAt *time.Time
+		LastSeenAt      *time.Time
+	}
+
+	columns := []string{"Status", "StatusText", "StatusExpiresAt", "LastSeenAt"}
+
+	register(Migration{
+		Version: "20250810000000",
+		Name:    "add_user_presence",
+		Up: func(tx *gorm.DB) error {
+			for _, column := range columns {
+				if err := tx.Migrator().AddColumn(&User{}, column); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for i := len(columns) - 1; i >= 0; i-- {
+				if err := tx.Migrator().DropColumn(&User{}, columns[i]); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	})
+}
@@ -1,29 +1,308 @@
 package migration
 
 import (
+	"errors"
 	"fmt"
-	"go-web-socket/config"
-	"go-web-socket/internal/models"
 	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration é um passo versionado do schema. Version é um timestamp no
+// formato 20060102150405 e define a ordem de execução.
+type Migration struct {
+	Version string
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration registra cada migration aplicada no banco.
+type SchemaMigration struct {
+	Version   string    `gorm:"primaryKey;size:14"`
+	Name      string    `gorm:"size:255"`
+	AppliedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+type Status struct {
+	Version   string
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	Unknown   bool // aplicada no banco, mas ausente deste binário
+}
+
+var (
+	ErrSchemaBehind = errors.New("o schema do banco de dados está desatualizado")
+	ErrSchemaAhead  = errors.New("o banco de dados tem migrations que este binário não conhece")
 )
 
-func RunMigration() {
-	db, err := config.GetDatabaseConnection()
+var registry []Migration
+
+// register é chamada no init() de cada arquivo de migration.
+func register(m Migration) {
+	registry = append(registry, m)
+}
+
+// Migrations devolve as migrations registradas em ordem de versão.
+func Migrations() []Migration {
+	migrations := make([]Migration, len(registry))
+	copy(migrations, registry)
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations
+}
+
+// applied lê as versões aplicadas. Sem a tabela schema_migrations nenhuma
+// foi aplicada; só o Up a cria.
+func applied(db *gorm.DB) (map[string]SchemaMigration, error) {
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		return map[string]SchemaMigration{}, nil
+	}
+
+	var rows []SchemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("erro ao ler schema_migrations: %v", err)
+	}
+
+	versions := make(map[string]SchemaMigration, len(rows))
+	for _, row := range rows {
+		versions[row.Version] = row
+	}
+
+	return versions, nil
+}
 
+// Pending devolve as migrations que ainda não foram aplicadas.
+func Pending(db *gorm.DB) ([]Migration, error) {
+	done, err := applied(db)
 	if err != nil {
-		panic(err.Error())
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, m := range Migrations() {
+		if _, ok := done[m.Version]; !ok {
+			pending = append(pending, m)
+		}
+	}
+
+	return pending, nil
+}
+
+// unknown devolve as versões aplicadas no banco que este binário não tem,
+// em ordem: o banco foi migrado por uma versão mais nova do servidor.
+func unknown(done map[string]SchemaMigration) []SchemaMigration {
+	known := make(map[string]bool, len(registry))
+	for _, m := range registry {
+		known[m.Version] = true
+	}
+
+	var rows []SchemaMigration
+	for version, row := range done {
+		if !known[version] {
+			rows = append(rows, row)
+		}
 	}
 
-	err = db.AutoMigrate(&models.User{}, &models.Message{})
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Version < rows[j].Version })
+
+	return rows
+}
+
+// Up aplica todas as migrations pendentes, uma transação por migration.
+func Up(db *gorm.DB) ([]Migration, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("erro ao criar tabela schema_migrations: %v", err)
+	}
+
+	pending, err := Pending(db)
 	if err != nil {
-		log.Fatalf("Failed to migrate models: %v", err)
+		return nil, err
 	}
 
-	sqlDB, err := db.DB()
+	var done []Migration
+	for _, m := range pending {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
 
-	if err == nil {
-		defer sqlDB.Close()
+			return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name}).Error
+		})
+
+		if err != nil {
+			return done, fmt.Errorf("erro ao aplicar migration %s_%s: %v", m.Version, m.Name, err)
+		}
+
+		log.Printf("Migration aplicada: %s_%s", m.Version, m.Name)
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+// Down desfaz as últimas steps migrations aplicadas, da mais nova para a
+// mais antiga.
+func Down(db *gorm.DB, steps int) ([]Migration, error) {
+	done, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := Migrations()
+
+	var reverted []Migration
+	for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+		m := migrations[i]
+		if _, ok := done[m.Version]; !ok {
+			continue
+		}
+
+		if m.Down == nil {
+			return reverted, fmt.Errorf("a migration %s_%s não pode ser desfeita", m.Version, m.Name)
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Down(tx); err != nil {
+				return err
+			}
+
+			return tx.Delete(&SchemaMigration{Version: m.Version}).Error
+		})
+
+		if err != nil {
+			return reverted, fmt.Errorf("erro ao desfazer migration %s_%s: %v", m.Version, m.Name, err)
+		}
+
+		log.Printf("Migration desfeita: %s_%s", m.Version, m.Name)
+		reverted = append(reverted, m)
+	}
+
+	return reverted, nil
+}
+
+func GetStatus(db *gorm.DB) ([]Status, error) {
+	done, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var status []Status
+	for _, m := range Migrations() {
+		row, ok := done[m.Version]
+		status = append(status, Status{
+			Version:   m.Version,
+			Name:      m.Name,
+			Applied:   ok,
+			AppliedAt: row.AppliedAt,
+		})
+	}
+
+	for _, row := range unknown(done) {
+		status = append(status, Status{
+			Version:   row.Version,
+			Name:      row.Name,
+			Applied:   true,
+			AppliedAt: row.AppliedAt,
+			Unknown:   true,
+		})
+	}
+
+	return status, nil
+}
+
+// EnsureUpToDate falha quando existe alguma migration pendente ou quando o
+// banco tem migrations que este binário não conhece. O servidor usa isso
+// para não subir com o schema atrasado nem adiantado. Não cria nada no
+// banco: sem schema_migrations, todas estão pendentes.
+func EnsureUpToDate(db *gorm.DB) error {
+	done, err := applied(db)
+	if err != nil {
+		return err
+	}
+
+	if rows := unknown(done); len(rows) > 0 {
+		var names []string
+		for _, row := range rows {
+			names = append(names, row.Version+"_"+row.Name)
+		}
+
+		return fmt.Errorf("%w: %s; atualize o servidor", ErrSchemaAhead, strings.Join(names, ", "))
+	}
+
+	var pending []Migration
+	for _, m := range Migrations() {
+		if _, ok := done[m.Version]; !ok {
+			pending = append(pending, m)
+		}
+	}
+
+	if len(pending) > 0 {
+		var names []string
+		for _, m := range pending {
+			names = append(names, m.Version+"_"+m.Name)
+		}
+
+		return fmt.Errorf("%w: pendentes %s; rode \"migrate up\"", ErrSchemaBehind, strings.Join(names, ", "))
+	}
+
+	return nil
+}
+
+var migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+var migrationTemplate = template.Must(template.New("migration").Parse(`package migration
+
+import "gorm.io/gorm"
+
+func init() {
+	register(Migration{
+		Version: "{{.Version}}",
+		Name:    "{{.Name}}",
+		Up: func(tx *gorm.DB) error {
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return nil
+		},
+	})
+}
+`))
+
+// Create gera um novo arquivo de migration vazio em dir.
+func Create(dir, name string) (string, error) {
+	if !migrationName.MatchString(name) {
+		return "", fmt.Errorf("nome de migration inválido %q: use letras minúsculas, números e _", name)
+	}
+
+	version := time.Now().UTC().Format("20060102150405")
+	path := filepath.Join(dir, fmt.Sprintf("%s_%s.go", version, name))
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", fmt.Errorf("erro ao criar arquivo de migration: %v", err)
+	}
+	defer file.Close()
+
+	err = migrationTemplate.Execute(file, struct{ Version, Name string }{version, name})
+	if err != nil {
+		return "", fmt.Errorf("erro ao escrever arquivo de migration: %v", err)
 	}
 
-	fmt.Println("Migration executed successfully!")
+	return path, nil
 }
@@ -0,0 +1,91 @@
+package migration
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { sqlDB.Close() })
+
+	return db
+}
+
+func TestEnsureUpToDateOnEmptyDatabase(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := EnsureUpToDate(db); !errors.Is(err, ErrSchemaBehind) {
+		t.Fatalf("esperava ErrSchemaBehind, veio %v", err)
+	}
+
+	// A verificação da inicialização não pode criar nada no banco.
+	if db.Migrator().HasTable(&SchemaMigration{}) {
+		t.Fatal("EnsureUpToDate criou a tabela schema_migrations")
+	}
+}
+
+func TestUpThenEnsureUpToDate(t *testing.T) {
+	db := openTestDB(t)
+
+	done, err := Up(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(done) != len(Migrations()) {
+		t.Fatalf("aplicou %d de %d migrations", len(done), len(Migrations()))
+	}
+
+	if err := EnsureUpToDate(db); err != nil {
+		t.Fatalf("schema deveria estar em dia: %v", err)
+	}
+
+	if _, err := Down(db, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := EnsureUpToDate(db); !errors.Is(err, ErrSchemaBehind) {
+		t.Fatalf("esperava ErrSchemaBehind depois do down, veio %v", err)
+	}
+}
+
+func TestEnsureUpToDateRejectsUnknownVersions(t *testing.T) {
+	db := openTestDB(t)
+
+	if _, err := Up(db); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Create(&SchemaMigration{Version: "29991231000000", Name: "from_the_future"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := EnsureUpToDate(db); !errors.Is(err, ErrSchemaAhead) {
+		t.Fatalf("esperava ErrSchemaAhead, veio %v", err)
+	}
+
+	status, err := GetStatus(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if last := status[len(status)-1]; last.Version != "29991231000000" || !last.Unknown {
+		t.Fatalf("status não mostra a versão desconhecida: %+v", last)
+	}
+}
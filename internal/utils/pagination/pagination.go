@@ -0,0 +1,66 @@
+package pagination
+
+import (
+	"errors"
+	"strconv"
+)
+
+const (
+	DefaultPerPage = 20
+	MaxPerPage     = 100
+)
+
+var ErrInvalidPage = errors.New("page e per_page devem ser inteiros positivos")
+
+// Page é a página pedida em ?page= e ?per_page=, começando em 1.
+type Page struct {
+	Number  int
+	PerPage int
+}
+
+// Meta acompanha a resposta de uma listagem paginada.
+type Meta struct {
+	Page       int   `json:"page"`
+	PerPage    int   `json:"per_page"`
+	Total      int64 `json:"total"`
+	TotalPages int64 `json:"total_pages"`
+}
+
+// Parse lê a página dos parâmetros da query. Vazios usam a primeira página
+// e DefaultPerPage; per_page acima de MaxPerPage é reduzido a ele.
+func Parse(page, perPage string) (Page, error) {
+	p := Page{Number: 1, PerPage: DefaultPerPage}
+
+	if page != "" {
+		number, err := strconv.Atoi(page)
+		if err != nil || number < 1 {
+			return p, ErrInvalidPage
+		}
+
+		p.Number = number
+	}
+
+	if perPage != "" {
+		size, err := strconv.Atoi(perPage)
+		if err != nil || size < 1 {
+			return p, ErrInvalidPage
+		}
+
+		p.PerPage = min(size, MaxPerPage)
+	}
+
+	return p, nil
+}
+
+func (p Page) Offset() int {
+	return (p.Number - 1) * p.PerPage
+}
+
+func (p Page) Meta(total int64) Meta {
+	return Meta{
+		Page:       p.Number,
+		PerPage:    p.PerPage,
+		Total:      total,
+		TotalPages: (total + int64(p.PerPage) - 1) / int64(p.PerPage),
+	}
+}
@@ -0,0 +1,106 @@
+package pagination
+
+import (
+	"errors"
+	"fmt"
+	"go-web-socket/internal/models"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		page, perPage string
+		want          Page
+	}{
+		{"", "", Page{Number: 1, PerPage: DefaultPerPage}},
+		{"3", "10", Page{Number: 3, PerPage: 10}},
+		{"1", "1000", Page{Number: 1, PerPage: MaxPerPage}},
+	}
+
+	for _, test := range tests {
+		got, err := Parse(test.page, test.perPage)
+		if err != nil || got != test.want {
+			t.Errorf("Parse(%q, %q) = %+v, %v; esperado %+v", test.page, test.perPage, got, err, test.want)
+		}
+	}
+
+	for _, invalid := range [][2]string{{"0", ""}, {"-1", ""}, {"a", ""}, {"", "0"}, {"", "dez"}, {"1.5", ""}} {
+		if _, err := Parse(invalid[0], invalid[1]); !errors.Is(err, ErrInvalidPage) {
+			t.Errorf("Parse(%q, %q): esperava ErrInvalidPage, veio %v", invalid[0], invalid[1], err)
+		}
+	}
+}
+
+func TestMeta(t *testing.T) {
+	tests := []struct {
+		total, pages int64
+	}{
+		{0, 0}, {1, 1}, {10, 1}, {11, 2}, {25, 3},
+	}
+
+	for _, test := range tests {
+		meta := Page{Number: 2, PerPage: 10}.Meta(test.total)
+		if meta.TotalPages != test.pages || meta.Total != test.total || meta.Page != 2 || meta.PerPage != 10 {
+			t.Errorf("Meta(%d) = %+v, esperava %d páginas", test.total, meta, test.pages)
+		}
+	}
+}
+
+// TestPagesCoverTheListing percorre as páginas como o GET /users faz e
+// confere que cada usuário aparece uma única vez.
+func TestPagesCoverTheListing(t *testing.T) {
+	users := userRepository.NewMemoryUserRepository()
+
+	for i := 1; i <= 7; i++ {
+		user := models.User{UserId: fmt.Sprintf("u%d", i), Username: fmt.Sprintf("user%d", i), Name: "Usuário"}
+		if err := users.Create(&user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	filter := userRepository.UserFilter{Search: "user"}
+
+	total, err := users.Count(filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := map[string]bool{}
+	meta := Page{Number: 1, PerPage: 3}.Meta(total)
+
+	for number := 1; number <= int(meta.TotalPages); number++ {
+		page, err := Parse(fmt.Sprint(number), "3")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		filter.Limit, filter.Offset = page.PerPage, page.Offset()
+
+		result, err := users.Search(filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := min(3, 7-page.Offset()); len(result) != want {
+			t.Fatalf("página %d: esperava %d usuários, veio %d", number, want, len(result))
+		}
+
+		for _, user := range result {
+			if seen[user.UserId] {
+				t.Fatalf("%s apareceu em mais de uma página", user.UserId)
+			}
+
+			seen[user.UserId] = true
+		}
+	}
+
+	if len(seen) != 7 || meta.TotalPages != 3 {
+		t.Fatalf("esperava 7 usuários em 3 páginas, vieram %d em %d", len(seen), meta.TotalPages)
+	}
+
+	filter.Offset = 9
+	if result, _ := users.Search(filter); len(result) != 0 {
+		t.Fatalf("depois da última página esperava lista vazia, veio %d", len(result))
+	}
+}
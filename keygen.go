@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	jwtService "go-web-socket/internal/services/JWTService"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// runKeygen gera uma nova chave de assinatura de JWT em <dir>/<kid>.pem.
+func runKeygen(args []string) {
+	log.SetOutput(os.Stderr)
+
+	flags := flag.NewFlagSet("keygen", flag.ExitOnError)
+	alg := flags.String("alg", "EdDSA", "algoritmo da chave: EdDSA ou RS256")
+	dir := flags.String("dir", "keys", "pasta das chaves (JWT_KEYS_DIR)")
+	kid := flags.String("kid", time.Now().UTC().Format("20060102150405"), "identificador da chave")
+	flags.Parse(args)
+
+	key, err := jwtService.GenerateKey(*alg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.MkdirAll(*dir, 0700); err != nil {
+		log.Fatal(err)
+	}
+
+	path := filepath.Join(*dir, *kid+".pem")
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(key); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("Chave criada:", path)
+	fmt.Printf("Para assinar com ela, defina JWT_SIGNING_KID=%s\n", *kid)
+}
@@ -1,22 +1,162 @@
 package main
 
 import (
+	"go-web-socket/config"
+	admincontroller "go-web-socket/internal/controllers/adminController"
+	apikeycontroller "go-web-socket/internal/controllers/apiKeyController"
+	authcontroller "go-web-socket/internal/controllers/authController"
+	botcontroller "go-web-socket/internal/controllers/botController"
+	filecontroller "go-web-socket/internal/controllers/fileController"
+	incomingwebhookcontroller "go-web-socket/internal/controllers/incomingWebhookController"
 	logincontroller "go-web-socket/internal/controllers/loginController"
-	"go-web-socket/internal/controllers/userController"
+	passwordcontroller "go-web-socket/internal/controllers/passwordController"
+	presencecontroller "go-web-socket/internal/controllers/presenceController"
+	roomcontroller "go-web-socket/internal/controllers/roomController"
+	twofactorcontroller "go-web-socket/internal/controllers/twoFactorController"
+	usercontroller "go-web-socket/internal/controllers/userController"
+	verificationcontroller "go-web-socket/internal/controllers/verificationController"
+	webhookcontroller "go-web-socket/internal/controllers/webhookController"
+	"go-web-socket/internal/middleware"
+	apiKeyRepository "go-web-socket/internal/repositories/APIKeyRepository"
+	actionTokenRepository "go-web-socket/internal/repositories/ActionTokenRepository"
+	identityRepository "go-web-socket/internal/repositories/IdentityRepository"
+	incomingWebhookRepository "go-web-socket/internal/repositories/IncomingWebhookRepository"
+	messageRepository "go-web-socket/internal/repositories/MessageRepository"
+	recoveryCodeRepository "go-web-socket/internal/repositories/RecoveryCodeRepository"
+	refreshTokenRepository "go-web-socket/internal/repositories/RefreshTokenRepository"
+	roomRepository "go-web-socket/internal/repositories/RoomRepository"
+	sessionRepository "go-web-socket/internal/repositories/SessionRepository"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	webhookRepository "go-web-socket/internal/repositories/WebhookRepository"
+	apiKeyService "go-web-socket/internal/services/APIKeyService"
+	authService "go-web-socket/internal/services/AuthService"
+	brokerService "go-web-socket/internal/services/BrokerService"
+	incomingWebhookService "go-web-socket/internal/services/IncomingWebhookService"
+	jwtService "go-web-socket/internal/services/JWTService"
+	loginGuardService "go-web-socket/internal/services/LoginGuardService"
+	mailService "go-web-socket/internal/services/MailService"
+	oidcService "go-web-socket/internal/services/OIDCService"
+	passwordService "go-web-socket/internal/services/PasswordService"
+	presenceService "go-web-socket/internal/services/PresenceService"
+	storageService "go-web-socket/internal/services/StorageService"
+	twoFactorService "go-web-socket/internal/services/TwoFactorService"
+	userService "go-web-socket/internal/services/UserService"
+	verificationService "go-web-socket/internal/services/VerificationService"
+	webhookService "go-web-socket/internal/services/WebhookService"
 	"go-web-socket/internal/socket"
 	"go-web-socket/internal/utils/logger"
 	"go-web-socket/internal/utils/migration"
+	"log"
 	"net/http"
+	"os"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			runMigrate(os.Args[2:])
+			return
+		case "keygen":
+			runKeygen(os.Args[2:])
+			return
+		case "set-role":
+			runSetRole(os.Args[2:])
+			return
+		case "mock-oidc":
+			runMockOIDC(os.Args[2:])
+			return
+		}
+	}
+
 	logger.InitLogger()
-	migration.RunMigration()
 
-	app := gin.Default()
+	if _, err := jwtService.LoadKeys(); err != nil {
+		log.Fatalf("Erro ao carregar as chaves JWT: %v", err)
+	}
+
+	db, err := config.OpenDatabase(config.LoadDatabaseConfig())
+	if err != nil {
+		log.Fatalf("Erro ao conectar ao banco de dados: %v", err)
+	}
+
+	// MIGRATE_ON_START=true aplica as migrations pendentes na inicialização,
+	// útil em desenvolvimento. Em produção use "go-web-socket migrate up".
+	if config.GetEnv("MIGRATE_ON_START", "false") == "true" {
+		if _, err := migration.Up(db); err != nil {
+			log.Fatalf("Erro ao aplicar migrations: %v", err)
+		}
+	}
+
+	if err := migration.EnsureUpToDate(db); err != nil {
+		log.Fatalf("Servidor não iniciado: %v", err)
+	}
+
+	userRepo := userRepository.NewGormUserRepository(db)
+	messageRepo := messageRepository.NewGormMessageRepository(db)
+	refreshTokenRepo := refreshTokenRepository.NewGormRefreshTokenRepository(db)
+	sessionRepo := sessionRepository.NewGormSessionRepository(db)
+	actionTokenRepo := actionTokenRepository.NewGormActionTokenRepository(db)
+	roomRepo := roomRepository.NewGormRoomRepository(db)
+	webhookRepo := webhookRepository.NewGormWebhookRepository(db)
+	incomingWebhookRepo := incomingWebhookRepository.NewGormIncomingWebhookRepository(db)
+	apiKeyRepo := apiKeyRepository.NewGormAPIKeyRepository(db)
+	identityRepo := identityRepository.NewGormIdentityRepository(db)
+	recoveryCodeRepo := recoveryCodeRepository.NewGormRecoveryCodeRepository(db)
+
+	users := userService.New(userRepo)
+	auth := authService.New(userRepo, refreshTokenRepo, sessionRepo)
+	files := storageService.New(db, storageService.NewBackendFromEnv())
+	twoFactor := twoFactorService.New(userRepo, recoveryCodeRepo, sessionRepo)
+	mailer := mailService.NewSenderFromEnv()
+	passwords := passwordService.New(userRepo, actionTokenRepo, auth, mailer, passwordService.LoadPolicy())
+	verification := verificationService.New(verificationService.LoadConfig(), userRepo, actionTokenRepo, mailer)
+	apiKeys := apiKeyService.New(apiKeyRepo, userRepo)
+	webhooks := webhookService.New(webhookService.LoadConfig(), webhookRepo)
+	webhooks.Start()
+	incomingWebhooks := incomingWebhookService.New(incomingWebhookService.LoadConfig(), incomingWebhookRepo, userRepo)
+	oidc := oidcService.New(oidcService.LoadConfig(), userRepo, identityRepo)
+	presence := presenceService.New(presenceService.LoadConfig(), userRepo, roomRepo, messageRepo)
+
+	brokerConfig := brokerService.LoadConfig()
+	broker, err := brokerService.New(brokerConfig)
+	if err != nil {
+		log.Fatalf("Erro ao iniciar o broker: %v", err)
+	}
+
+	hub := socket.NewHub(userRepo, messageRepo, roomRepo, files, verification, webhooks, broker, presence)
+	hub.StartHeartbeat(brokerConfig.HeartbeatInterval)
+
+	requireAuth := middleware.Auth(auth)
+	allowAPIKey := func(scope string) gin.HandlerFunc {
+		return middleware.AuthWithAPIKey(auth, apiKeys, scope)
+	}
+
+	// O mesmo contador vale para o login e para a senha atual na troca de senha
+	loginGuard := loginGuardService.New(loginGuardService.LoadConfig())
+
+	authController := authcontroller.New(auth, hub)
+	loginController := logincontroller.New(userRepo, auth, loginGuard, twoFactor, verification, oidc)
+	userController := usercontroller.New(userRepo, users, passwords, verification, hub)
+	fileController := filecontroller.New(files)
+	apiKeyController := apikeycontroller.New(apiKeys, hub)
+	roomController := roomcontroller.New(roomRepo, userRepo)
+	botController := botcontroller.New(userRepo, apiKeys, hub)
+	webhookController := webhookcontroller.New(webhooks, roomRepo)
+	incomingWebhookController := incomingwebhookcontroller.New(incomingWebhooks, roomRepo, hub)
+	presenceController := presencecontroller.New(presence, hub)
+	passwordController := passwordcontroller.New(passwords, loginGuard, hub)
+	verificationController := verificationcontroller.New(verification)
+	twoFactorController := twofactorcontroller.New(userRepo, twoFactor)
+	adminController := admincontroller.New(userRepo, messageRepo, auth, hub)
+
+	// Como o gin.Default(), mas o log de acesso esconde os tokens que vão na
+	// URL (webhooks de entrada e WebSocket).
+	app := gin.New()
+	app.Use(middleware.AccessLog(), gin.Recovery())
 
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: []string{"http://localhost:3000", "http://192.168.0.124:3000"}, // Ajuste conforme seu frontend
@@ -30,17 +170,77 @@ func main() {
 		})
 	})
 
-	app.POST("/login", logincontroller.Login)
-	app.GET("/user/:username", userController.GetUser)
+	app.POST("/login", loginController.Login)
+	app.GET("/auth/oidc/login", loginController.OIDCLogin)
+	app.GET("/auth/oidc/callback", loginController.OIDCCallback)
+	app.POST("/auth/2fa/verify", loginController.VerifyTwoFactor)
+	app.POST("/auth/refresh", authController.Refresh)
+	app.POST("/auth/password/forgot", passwordController.ForgotPassword)
+	app.POST("/auth/password/reset", passwordController.ResetPassword)
+	app.POST("/auth/verify-email", verificationController.VerifyEmail)
+	app.POST("/auth/verify-email/resend", verificationController.Resend)
+	app.GET("/.well-known/jwks.json", authController.JWKS)
+	app.POST("/auth/logout", requireAuth, authController.Logout)
+	app.GET("/me/sessions", requireAuth, authController.GetSessions)
+	app.DELETE("/me/sessions/:id", requireAuth, authController.DeleteSession)
+	app.POST("/me/password", requireAuth, passwordController.ChangePassword)
+	app.GET("/me/status", requireAuth, presenceController.GetStatus)
+	app.PUT("/me/status", requireAuth, presenceController.SetStatus)
+	app.DELETE("/me/status", requireAuth, presenceController.ClearStatus)
+	app.GET("/me/contacts", requireAuth, presenceController.GetContacts)
+	app.GET("/me/api-keys", requireAuth, apiKeyController.GetAPIKeys)
+	app.POST("/me/api-keys", requireAuth, apiKeyController.CreateAPIKey)
+	app.DELETE("/me/api-keys/:id", requireAuth, apiKeyController.DeleteAPIKey)
+	app.GET("/me/incoming-webhooks", requireAuth, incomingWebhookController.GetIncomingWebhooks)
+	app.POST("/me/incoming-webhooks", requireAuth, incomingWebhookController.CreateIncomingWebhook)
+	app.DELETE("/me/incoming-webhooks/:id", requireAuth, incomingWebhookController.DeleteIncomingWebhook)
+	app.POST("/me/2fa/enroll", requireAuth, twoFactorController.Enroll)
+	app.POST("/me/2fa/confirm", requireAuth, twoFactorController.Confirm)
+	app.POST("/me/2fa/disable", requireAuth, twoFactorController.Disable)
+	app.POST("/me/2fa/recovery-codes", requireAuth, twoFactorController.RegenerateRecoveryCodes)
+	app.GET("/user/:username", requireAuth, userController.GetUser)
 	app.POST("/create-user", userController.CreateUser)
-	app.POST("/upload-user-avatar/:user_id", userController.UploadUserAvatar)
-	app.POST("/change-user-avatar:user_id", userController.UploadUserAvatar)
-	app.GET("/users", userController.GetUsers)
-	app.PUT("/edit-user/:user_id", userController.EditUser)
+	app.POST("/upload-user-avatar/:user_id", requireAuth, userController.UploadUserAvatar)
+	app.POST("/change-user-avatar:user_id", requireAuth, userController.UploadUserAvatar)
+	app.GET("/users", allowAPIKey(apiKeyService.ScopeUsersRead), userController.GetUsers)
+	app.PUT("/edit-user/:user_id", requireAuth, userController.EditUser)
+	app.DELETE("/files/:file_id", requireAuth, fileController.DeleteFile)
+	app.GET("/rooms", allowAPIKey(apiKeyService.ScopeRoomsRead), roomController.GetRooms)
+	app.POST("/rooms", requireAuth, roomController.CreateRoom)
+	app.GET("/rooms/:room_id/commands", allowAPIKey(apiKeyService.ScopeRoomsRead), hub.GetRoomCommands)
+	app.GET("/rooms/:room_id/members", allowAPIKey(apiKeyService.ScopeRoomsRead), roomController.GetMembers)
+	app.POST("/rooms/:room_id/members", requireAuth, roomController.AddMember)
+	app.DELETE("/rooms/:room_id/members/:user_id", requireAuth, roomController.RemoveMember)
+	app.GET("/rooms/:room_id/webhooks", requireAuth, webhookController.GetWebhooks)
+	app.POST("/rooms/:room_id/webhooks", requireAuth, webhookController.CreateWebhook)
+	app.DELETE("/rooms/:room_id/webhooks/:id", requireAuth, webhookController.DeleteWebhook)
+	app.POST("/rooms/:room_id/webhooks/:id/enable", requireAuth, webhookController.EnableWebhook)
+	app.GET("/rooms/:room_id/webhooks/:id/deliveries", requireAuth, webhookController.GetDeliveries)
+	app.GET("/rooms/:room_id/incoming-webhooks", requireAuth, incomingWebhookController.GetIncomingWebhooks)
+	app.POST("/rooms/:room_id/incoming-webhooks", requireAuth, incomingWebhookController.CreateIncomingWebhook)
+	app.DELETE("/rooms/:room_id/incoming-webhooks/:id", requireAuth, incomingWebhookController.DeleteIncomingWebhook)
+	app.POST("/hooks/:hook_id/:token", incomingWebhookController.Post)
+
+	admin := app.Group("/admin", requireAuth, middleware.RequireRole(jwtService.RoleAdmin))
+	admin.GET("/users", adminController.GetUsers)
+	admin.POST("/users/:user_id/disable", adminController.DisableUser)
+	admin.POST("/users/:user_id/enable", adminController.EnableUser)
+	admin.POST("/users/:user_id/disconnect", adminController.DisconnectUser)
+	admin.DELETE("/messages/:id", adminController.DeleteMessage)
+	admin.GET("/webhooks", webhookController.GetWebhooks)
+	admin.POST("/webhooks", webhookController.CreateWebhook)
+	admin.DELETE("/webhooks/:id", webhookController.DeleteWebhook)
+	admin.POST("/webhooks/:id/enable", webhookController.EnableWebhook)
+	admin.GET("/webhooks/:id/deliveries", webhookController.GetDeliveries)
+	admin.POST("/bots", botController.CreateBot)
+	admin.POST("/bots/:user_id/api-keys", botController.CreateAPIKey)
+	admin.DELETE("/bots/:user_id/api-keys/:id", botController.DeleteAPIKey)
+	admin.POST("/broadcast", adminController.Broadcast)
+
 	//socket
-	app.GET("/ws/user/:user_id", socket.HandleSocket)
-	app.GET("/ws/online-users", socket.GetOnlineUsers)
-	app.POST("/ws/send-private-message", socket.SendMessage)
+	app.GET("/ws/user/:user_id", allowAPIKey(apiKeyService.ScopeSocket), hub.HandleSocket)
+	app.GET("/ws/online-users", requireAuth, hub.GetOnlineUsers)
+	app.POST("/ws/send-private-message", allowAPIKey(apiKeyService.ScopeMessagesSend), hub.SendMessage)
 
 	app.Run()
 }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go-web-socket/config"
+	"go-web-socket/internal/utils/migration"
+	"log"
+	"os"
+)
+
+const migrateUsage = `uso: go-web-socket migrate <comando>
+
+comandos:
+  up              aplica todas as migrations pendentes
+  down [-steps N] desfaz as últimas N migrations (padrão 1)
+  status          lista as migrations e se já foram aplicadas
+  create <nome>   gera um novo arquivo de migration
+`
+
+func runMigrate(args []string) {
+	// Sem isso os logs iriam para o app.log e o comando ficaria mudo.
+	log.SetOutput(os.Stderr)
+
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	command, args := args[0], args[1:]
+
+	if command == "create" {
+		flags := flag.NewFlagSet("create", flag.ExitOnError)
+		dir := flags.String("dir", "internal/utils/migration", "pasta das migrations")
+		flags.Parse(args)
+
+		if flags.NArg() != 1 {
+			fmt.Fprint(os.Stderr, migrateUsage)
+			os.Exit(2)
+		}
+
+		path, err := migration.Create(*dir, flags.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Println("Migration criada:", path)
+		return
+	}
+
+	db, err := config.OpenDatabase(config.LoadDatabaseConfig())
+	if err != nil {
+		log.Fatalf("Erro ao conectar ao banco de dados: %v", err)
+	}
+
+	switch command {
+	case "up":
+		done, err := migration.Up(db)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Printf("%d migration(s) aplicada(s)\n", len(done))
+	case "down":
+		flags := flag.NewFlagSet("down", flag.ExitOnError)
+		steps := flags.Int("steps", 1, "quantidade de migrations a desfazer")
+		flags.Parse(args)
+
+		reverted, err := migration.Down(db, *steps)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Printf("%d migration(s) desfeita(s)\n", len(reverted))
+	case "status":
+		status, err := migration.GetStatus(db)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, s := range status {
+			state := "pendente"
+			if s.Applied {
+				state = "aplicada em " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+
+			if s.Unknown {
+				state += " (desconhecida por este binário)"
+			}
+
+			fmt.Printf("%s  %-40s %s\n", s.Version, s.Name, state)
+		}
+	default:
+		fmt.Fprint(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// authCode é um código de autorização emitido pelo provedor falso.
+type authCode struct {
+	challenge   string
+	nonce       string
+	redirectURI string
+	clientID    string
+	subject     string
+	expiresAt   time.Time
+}
+
+// runMockOIDC sobe um provedor OpenID Connect falso para desenvolvimento e
+// testes do login por SSO. Toda autorização é aprovada na hora, para o
+// usuário dos flags (ou o login_hint da requisição, usado como subject).
+func runMockOIDC(args []string) {
+	log.SetOutput(os.Stderr)
+
+	flags := flag.NewFlagSet("mock-oidc", flag.ExitOnError)
+	addr := flags.String("addr", "localhost:9000", "endereço de escuta")
+	issuer := flags.String("issuer", "", "issuer anunciado (padrão http://<addr>)")
+	clientID := flags.String("client-id", "go-web-socket", "client_id aceito")
+	subject := flags.String("sub", "mock-user", "subject do usuário")
+	email := flags.String("email", "mock.user@example.com", "e-mail do usuário")
+	name := flags.String("name", "Mock User", "nome do usuário")
+	username := flags.String("username", "mock.user", "preferred_username do usuário")
+	flags.Parse(args)
+
+	if *issuer == "" {
+		*issuer = "http://" + *addr
+	}
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var (
+		mu    sync.Mutex
+		codes = make(map[string]authCode)
+	)
+
+	writeJSON := func(w http.ResponseWriter, status int, body interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"issuer":                                *issuer,
+			"authorization_endpoint":                *issuer + "/authorize",
+			"token_endpoint":                        *issuer + "/token",
+			"jwks_uri":                              *issuer + "/jwks",
+			"response_types_supported":              []string{"code"},
+			"id_token_signing_alg_values_supported": []string{"EdDSA"},
+			"code_challenge_methods_supported":      []string{"S256"},
+		})
+	})
+
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "OKP",
+				"crv": "Ed25519",
+				"kid": "mock",
+				"use": "sig",
+				"alg": "EdDSA",
+				"x":   base64.RawURLEncoding.EncodeToString(public),
+			}},
+		})
+	})
+
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		redirectURI, err := url.Parse(query.Get("redirect_uri"))
+		if err != nil || redirectURI.Scheme == "" {
+			http.Error(w, "redirect_uri inválido", http.StatusBadRequest)
+			return
+		}
+
+		if query.Get("client_id") != *clientID || query.Get("response_type") != "code" ||
+			query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+			http.Error(w, "requisição de autorização inválida", http.StatusBadRequest)
+			return
+		}
+
+		buf := make([]byte, 24)
+		rand.Read(buf)
+		code := base64.RawURLEncoding.EncodeToString(buf)
+
+		sub := *subject
+		if hint := query.Get("login_hint"); hint != "" {
+			sub = hint
+		}
+
+		mu.Lock()
+		codes[code] = authCode{
+			challenge:   query.Get("code_challenge"),
+			nonce:       query.Get("nonce"),
+			redirectURI: query.Get("redirect_uri"),
+			clientID:    query.Get("client_id"),
+			subject:     sub,
+			expiresAt:   time.Now().Add(time.Minute),
+		}
+		mu.Unlock()
+
+		callback := redirectURI.Query()
+		callback.Set("code", code)
+		callback.Set("state", query.Get("state"))
+		redirectURI.RawQuery = callback.Encode()
+
+		http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+	})
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+			return
+		}
+
+		mu.Lock()
+		code, ok := codes[r.PostForm.Get("code")]
+		delete(codes, r.PostForm.Get("code"))
+		mu.Unlock()
+
+		verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+
+		if !ok || time.Now().After(code.expiresAt) ||
+			code.redirectURI != r.PostForm.Get("redirect_uri") ||
+			code.clientID != r.PostForm.Get("client_id") ||
+			code.challenge != base64.RawURLEncoding.EncodeToString(verifier[:]) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		now := time.Now()
+
+		token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.MapClaims{
+			"iss":                *issuer,
+			"sub":                code.subject,
+			"aud":                code.clientID,
+			"iat":                now.Unix(),
+			"exp":                now.Add(5 * time.Minute).Unix(),
+			"nonce":              code.nonce,
+			"email":              *email,
+			"email_verified":     true,
+			"name":               *name,
+			"preferred_username": *username,
+		})
+		token.Header["kid"] = "mock"
+
+		idToken, err := token.SignedString(private)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"access_token": "mock-access-token",
+			"token_type":   "Bearer",
+			"expires_in":   300,
+			"id_token":     idToken,
+		})
+	})
+
+	log.Printf("Provedor OIDC falso em %s (issuer %s, client_id %s)", *addr, *issuer, *clientID)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"go-web-socket/config"
+	userRepository "go-web-socket/internal/repositories/UserRepository"
+	jwtService "go-web-socket/internal/services/JWTService"
+	"log"
+	"os"
+)
+
+// runSetRole altera o papel de um usuário; é assim que o primeiro admin é
+// criado. A mudança vale a partir do próximo token emitido para ele.
+func runSetRole(args []string) {
+	log.SetOutput(os.Stderr)
+
+	if len(args) != 2 || (args[1] != jwtService.RoleUser && args[1] != jwtService.RoleAdmin) {
+		fmt.Fprintln(os.Stderr, "uso: go-web-socket set-role <username> <user|admin>")
+		os.Exit(2)
+	}
+
+	db, err := config.OpenDatabase(config.LoadDatabaseConfig())
+	if err != nil {
+		log.Fatalf("Erro ao conectar ao banco de dados: %v", err)
+	}
+
+	users := userRepository.NewGormUserRepository(db)
+
+	user, err := users.FindByUsername(args[0])
+	if err != nil {
+		log.Fatalf("Erro ao buscar usuário %s: %v", args[0], err)
+	}
+
+	if err := users.SetRole(user.UserId, args[1]); err != nil {
+		log.Fatalf("Erro ao alterar papel: %v", err)
+	}
+
+	fmt.Printf("Usuário %s agora tem o papel %s\n", user.Username, args[1])
+}